@@ -0,0 +1,116 @@
+package extractor
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrBinaryContent indicates content given to a text extractor is not text
+var ErrBinaryContent = errors.New("binary content")
+
+const (
+	// binarySampleSize is how much of the content is examined for binary data
+	binarySampleSize = 8000
+
+	// maxControlRatio is the share of control characters above which content is binary
+	maxControlRatio = 0.1
+
+	// defaultMinStringLength is the shortest printable run reported in strings mode,
+	// as with the Unix strings tool
+	defaultMinStringLength = 4
+)
+
+// isBinaryContent reports whether content is binary data rather than text.
+// Text never contains NUL bytes, except in UTF-16 and UTF-32, and seldom
+// contains control characters other than whitespace and escapes.
+func isBinaryContent(content []byte) bool {
+	if _, enc, _ := sniffBOM(content); enc != nil {
+		return false
+	}
+	if _, enc, _ := sniffUnicodeNulls(content); enc != nil {
+		return false
+	}
+
+	sample := content
+	if len(sample) > binarySampleSize {
+		sample = sample[:binarySampleSize]
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+
+	controls := 0
+	for _, b := range sample {
+		if (b < 0x20 || b == 0x7F) && !strings.ContainsRune("\t\n\v\f\r\x1b", rune(b)) {
+			controls++
+		}
+	}
+	return len(sample) > 0 && float64(controls)/float64(len(sample)) > maxControlRatio
+}
+
+// printableString is a run of printable characters found in binary content
+type printableString struct {
+	offset int
+	text   string
+}
+
+// printableStrings finds the runs of at least minLength printable characters
+// in binary content, in the manner of the Unix strings tool. Runs are read as
+// UTF-8, which includes ASCII, and as UTF-16LE, which Windows programs use for
+// their strings, and are returned in the order they occur.
+func printableStrings(content []byte, minLength int) []printableString {
+	if minLength <= 0 {
+		minLength = defaultMinStringLength
+	}
+
+	var found []printableString
+	var run []rune
+	runStart := 0
+	flush := func() {
+		if len(run) >= minLength {
+			found = append(found, printableString{offset: runStart, text: string(run)})
+		}
+		run = run[:0]
+	}
+
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRune(content[i:])
+		if r == utf8.RuneError || !isPrintableRune(r) {
+			flush()
+			i++
+			runStart = i
+			continue
+		}
+		run = append(run, r)
+		i += size
+	}
+	flush()
+
+	// UTF-16LE strings of ASCII characters, at either byte alignment
+	for alignment := 0; alignment < 2; alignment++ {
+		runStart = alignment
+		for i := alignment; i+1 < len(content); i += 2 {
+			if content[i+1] != 0 || content[i] >= utf8.RuneSelf || !isPrintableRune(rune(content[i])) {
+				flush()
+				runStart = i + 2
+				continue
+			}
+			run = append(run, rune(content[i]))
+		}
+		flush()
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].offset < found[j].offset
+	})
+	return found
+}
+
+// isPrintableRune reports whether a character belongs in a printable run
+func isPrintableRune(r rune) bool {
+	return r == '\t' || r != utf8.RuneError && unicode.IsPrint(r)
+}
@@ -0,0 +1,406 @@
+package extractor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+)
+
+// ErrUnsupportedEncoding indicates a forced character encoding that is not known
+var ErrUnsupportedEncoding = errors.New("unsupported encoding")
+
+// charsetSampleSize bounds how much of the content is examined when detecting its charset
+const charsetSampleSize = 256 * 1024
+
+// textEncoding describes how text content was decoded
+type textEncoding struct {
+	name string
+
+	// confidence of the detection, from 0 to 1
+	confidence float64
+
+	// bom is set when the content started with a byte order mark
+	bom bool
+}
+
+// byteOrderMarks lists the Unicode byte order marks, longest first so that
+// UTF-32LE is not mistaken for UTF-16LE
+var byteOrderMarks = []struct {
+	name     string
+	mark     []byte
+	encoding encoding.Encoding
+}{
+	{"UTF-32BE", []byte{0x00, 0x00, 0xFE, 0xFF}, utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM)},
+	{"UTF-32LE", []byte{0xFF, 0xFE, 0x00, 0x00}, utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM)},
+	{"UTF-8", []byte{0xEF, 0xBB, 0xBF}, textunicode.UTF8},
+	{"UTF-16BE", []byte{0xFE, 0xFF}, textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM)},
+	{"UTF-16LE", []byte{0xFF, 0xFE}, textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM)},
+}
+
+// sniffBOM returns the encoding named by a byte order mark at the start of
+// content and the length of the mark, or a nil encoding if there is none
+func sniffBOM(content []byte) (string, encoding.Encoding, int) {
+	for _, bom := range byteOrderMarks {
+		if bytes.HasPrefix(content, bom.mark) {
+			return bom.name, bom.encoding, len(bom.mark)
+		}
+	}
+	return "", nil, 0
+}
+
+// sniffUnicodeNulls recognizes UTF-32 and UTF-16 without a byte order mark.
+// Text that is mostly ASCII or another single script has zero bytes in fixed
+// positions of each code unit, which other encodings of text never have. The
+// confidence is the share of code units following the pattern.
+func sniffUnicodeNulls(content []byte) (string, encoding.Encoding, float64) {
+	sample := content
+	if len(sample) > charsetSampleSize {
+		sample = sample[:charsetSampleSize]
+	}
+	if len(sample) < 4 || bytes.IndexByte(sample, 0) < 0 {
+		return "", nil, 0
+	}
+
+	// zeros counts the zero bytes at each position modulo 4
+	var zeros [4]int
+	for i, b := range sample {
+		if b == 0 {
+			zeros[i%4]++
+		}
+	}
+	units := float64(len(sample) / 4)
+	ratio := func(positions ...int) float64 {
+		count := 0
+		for _, position := range positions {
+			count += zeros[position]
+		}
+		return float64(count) / units / float64(len(positions))
+	}
+
+	if len(sample)%4 == 0 {
+		// Characters outside the BMP are rare, so the high byte is always zero
+		// and the next one almost always
+		switch {
+		case ratio(3) == 1 && ratio(2) >= 0.9 && ratio(0) < 0.1:
+			return "UTF-32LE", utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM), ratio(2)
+		case ratio(0) == 1 && ratio(1) >= 0.9 && ratio(3) < 0.1:
+			return "UTF-32BE", utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM), ratio(1)
+		}
+	}
+
+	// Code units of UTF-16 have a zero high byte for ASCII and Latin-1 text
+	even, odd := ratio(0, 2), ratio(1, 3)
+	switch {
+	case odd >= 0.3 && even < 0.05:
+		return "UTF-16LE", textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM), min(1, odd+0.5)
+	case even >= 0.3 && odd < 0.05:
+		return "UTF-16BE", textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM), min(1, even+0.5)
+	}
+	return "", nil, 0
+}
+
+// charsetScript is the writing system a legacy charset is used for, which
+// determines how plausible decoded text looks
+type charsetScript int
+
+const (
+	scriptLatin charsetScript = iota
+	scriptCyrillic
+	scriptJapanese
+	scriptSimplifiedChinese
+	scriptTraditionalChinese
+	scriptKorean
+)
+
+// charsetCandidate is a legacy charset considered by detection
+type charsetCandidate struct {
+	name     string
+	encoding encoding.Encoding
+	script   charsetScript
+
+	// prior slightly favours common charsets when the content does not tell them apart
+	prior float64
+}
+
+// charsetCandidates lists the legacy charsets detection chooses from
+var charsetCandidates = []charsetCandidate{
+	{"Windows-1252", charmap.Windows1252, scriptLatin, 0.02},
+	{"Windows-1250", charmap.Windows1250, scriptLatin, 0},
+	{"Windows-1251", charmap.Windows1251, scriptCyrillic, 0.01},
+	{"KOI8-R", charmap.KOI8R, scriptCyrillic, 0},
+	{"ISO-8859-5", charmap.ISO8859_5, scriptCyrillic, 0},
+	{"IBM866", charmap.CodePage866, scriptCyrillic, 0},
+	{"Shift_JIS", japanese.ShiftJIS, scriptJapanese, 0.01},
+	{"EUC-JP", japanese.EUCJP, scriptJapanese, 0},
+	{"GBK", simplifiedchinese.GBK, scriptSimplifiedChinese, 0.01},
+	{"GB18030", simplifiedchinese.GB18030, scriptSimplifiedChinese, 0},
+	{"Big5", traditionalchinese.Big5, scriptTraditionalChinese, 0},
+	{"EUC-KR", korean.EUCKR, scriptKorean, 0},
+}
+
+// Characters common in running text of each language. Decoding with the
+// wrong charset mostly produces rare characters, so the share of common ones
+// tells the candidates apart.
+var (
+	commonSimplifiedChinese = charSet("的一是不了在人有我他这个们中来上大为和国地到以说时要就出会可也你对生能而子那得于着下自之年过发后作里用道行所然家种事成方多经么去法学如都同现当没动面起看定天分还进好小部其些主样理心她本前开但因只从想实日军者意无力它与长把机十民第公此已工使情明性知全三又关点正业外将两高间由问很最重并物手应战向头文体政美相见被利什二等产或新己制身果加西斯月话合回特代内信表化老给世位次度门任常先海通教儿原东声提立及比员解水名真论处走义各入几口认条平系气题活尔更别打女变四神总何电数安少报才结反受目太量再感建务做接必场件计管期市直德资命山金指克许统区保至队形社便空决治展马科司五基眼书非则听白却界达光放强即像难且权思王象完设式色路记南品住告类求据程北边死张该交规万取拉格望觉术领共确传师观清今切院让识候带导争运笑飞风步改收根干造言联持组每济车亲极林服快办议往元英士证近失转夫令准布始怎呢存未远叫台单影具罗字爱击流备兵连调深商算质团集百需价花党华城石级整府离况亚请技际约示复病息究线似官火断精满支视消越器容照须九增研写称企八功吗包片史委乎查轻易早曾除农找装广显吧阿李标谈吃图念六引历首医局突专费号尽另周较注语仅考落青随选列武红响虽推势参希古众构房半节土投某案黑维革划敌致陈律足态护七兴派孩验责营星够章音跟志底站严巴例防族供效续施留讲型料终答紧黄绝奇察母京段依批群项故按河米围江织害斗双境客纪采举杀攻父苏密低朝友诉止细愿千值仍男钱破网热助倒育属坐帝限船脸职速刻乐否刚威毛状率甚独球般普怕弹校苦创假久错承印晚兰试股拿脑预谁益阳若哪微尼继送急血惊伤素药适波夜省初喜卫源食险待述陆习置居劳财环排福纳欢雷警获模充负云停木游龙树疑层冷洲冲射略范竟句室异激汉村哈策演简卡罪判担州静退既衣您宗积余痛检差富灵协角占配征修皮挥胜降阶审沉坚善妈刘读啊超免压银买皇养伊怀执副乱抗犯追帮宣佛岁航优怪香著田铁控税左右份穿艺背阵草脚概恶块顿敢守酒岛托央户烈洋哥索胡款靠评版宝座释景顾弟登货互付伯慢欧换闻危忙核暗姐介坏讨丽良序升监临亮露永呼味野架域沙掉括舰鱼杂误湾吉减编楚肯测败屋跑梦散温困剑渐封救贵枪缺楼县尚毫移娘朋画班智亦耳恩短掌恐遗固席松秘谢鲁遇康虑幸均销钟诗藏赶剧票损忽巨炮旧端探湖录叶春乡附吸予礼港雨呀板庭妇归睛饭额含顺输摇招婚脱补谓督毒油疗旅泽材灭逐莫笔亡鲜词圣择寻厂睡博勒烟授诺伦岸奥唐卖俄炸载洛健堂旁宫喝借君禁阴园谋宋避抓荣姑孙逃牙束跳顶玉镇雪午练迫爷篇肉嘴馆遍凡础洞卷坦牛宁纸诸训私庄祖丝翻暴森塔默握戏隐熟骨访弱蒙歌店鬼软典欲萨伙遭盘爸扩盖弄雄稳忘亿刺拥徒姆杨齐赛趣曲刀床迎冰虚玩析窗醒妻透购替塞努休虎扬途侵刑绿兄迅套贸毕唯谷轮库迹尤竞街促延震弃甲伟麻川申缓潜闪售灯针哲络抵朱埃抱鼓植纯夏忍页杰筑折郑贝尊吴秀混臣雅振染盛怒舞圆搞狂措姓残秋培迷诚宽宇猛摆梅毁伸摩盟末乃悲拍丁赵")
+
+	commonTraditionalChinese = charSet("的一是不了在人有我他這個們中來上大為和國地到以說時要就出會可也你對生能而子那得於著下自之年過發後作裡用道行所然家種事成方多經麼去法學如都同現當沒動面起看定天分還進好小部其些主樣理心她本前開但因只從想實日軍者意無力它與長把機十民第公此已工使情明性知全三又關點正業外將兩高間由問很最重並物手應戰向頭文體政美相見被利什二等產或新己制身果加西斯月話合回特代內信表化老給世位次度門任常先海通教兒原東聲提立及比員解水名真論處走義各入幾口認條平系氣題活爾更別打女變四神總何電數安少報才結反受目太量再感建務做接必場件計管期市直德資命山金指克許統區保至隊形社便空決治展馬科司五基眼書非則聽白卻界達光放強即像難且權思王象完設式色路記南品住告類求據程北邊死張該交規萬取拉格望覺術領共確傳師觀清今切院讓識候帶導爭運笑飛風步改收根乾造言聯持組每濟車親極林服快辦議往元英士證近失轉夫令準布始怎呢存未遠叫台單影具羅字愛擊流備兵連調深商算質團集百需價花黨華城石級整府離況亞請技際約示復病息究線似官火斷精滿支視消越器容照須九增研寫稱企八功嗎包片史委乎查輕易早曾除農找裝廣顯吧阿李標談吃圖念六引歷首醫局突專費號盡另周較注語僅考落青隨選列武紅響雖推勢參希古眾構房半節土投某案黑維革劃敵致陳律足態護七興派孩驗責營星夠章音跟志底站嚴巴例防族供效續施留講型料終答緊黃絕奇察母京段依批群項故按河米圍江織害鬥雙境客紀採舉殺攻父蘇密低朝友訴止細願千值仍男錢破網熱助倒育屬坐帝限船臉職速刻樂否剛威毛狀率甚獨球般普怕彈校苦創假久錯承印晚蘭試股拿腦預誰益陽若哪微尼繼送急血驚傷素藥適波夜省初喜衛源食險待述陸習置居勞財環排福納歡雷警獲模充負雲停木遊龍樹疑層冷洲衝射略範竟句室異激漢村哈策演簡卡罪判擔州靜退既衣您宗積餘痛檢差富靈協角佔配徵修皮揮勝降階審沉堅善媽劉讀啊超免壓銀買皇養伊懷執副亂抗犯追幫宣佛歲航優怪香著田鐵控稅左右份穿藝背陣草腳概惡塊頓敢守酒島託央戶烈洋哥索胡款靠評版寶座釋景顧弟登貨互付伯慢歐換聞危忙核暗姐介壞討麗良序升監臨亮露永呼味野架域沙掉括艦魚雜誤灣吉減編楚肯測敗屋跑夢散溫困劍漸封救貴槍缺樓縣尚毫移娘朋畫班智亦耳恩短掌恐遺固席松祕謝魯遇康慮幸均銷鐘詩藏趕劇票損忽巨炮舊端探湖錄葉春鄉附吸予禮港雨呀板庭婦歸睛飯額含順輸搖招婚脫補謂督毒油療旅澤材滅逐莫筆亡鮮詞聖擇尋廠睡博勒煙授諾倫岸奧唐賣俄炸載洛健堂旁宮喝借君禁陰園謀宋避抓榮姑孫逃牙束跳頂玉鎮雪午練迫爺篇肉嘴館遍凡礎洞卷坦牛寧紙諸訓私莊祖絲翻暴森塔默握戲隱熟骨訪弱蒙歌店鬼軟典欲薩夥遭盤爸擴蓋弄雄穩忘億刺擁徒姆楊齊賽趣曲刀床迎冰虛玩析窗醒妻透購替塞努休虎揚途侵刑綠兄迅套貿畢唯谷輪庫跡尤競街促延震棄甲偉麻川申緩潛閃售燈針哲絡抵朱埃抱鼓植純夏忍頁傑築折鄭貝尊吳秀混臣雅振染盛怒舞圓搞狂措姓殘秋培迷誠寬宇猛擺梅毀伸摩盟末乃悲拍丁趙灣臺")
+
+	commonJapaneseKanji = charSet("日一国会人年大十二本中長出三同時政事自行社見月分議後前民生連五発間対上部東者党地合市業内相方四定今回新場金員九入選立開手米力学問高代明実円関決子動京全目表戦経通外最言氏現理調体化田当八六約主題下首意法不来作性的要用制治度務強気小七成期公持野協取都和統以機平総加山思家話世受区領多県続進正安設保改数記院女初北午指権心界支第産結百派点教報済書府活原先共得解名交資予川向際査勝面委告軍文反元重近千考判認画海参売利組知案道信策集在件団別物側任引使求所次水半品昨論計死官増係感特情投示変打男基私各始島直両朝革価式確村提運終挙果西勢減台広容必応演電歳住争談能無再位置企真流格有疑口過局少放税検藤町常校料沢裁状工建語球営空職証土与急止送援供可役構木割聞身費付施切由説転食比難防補車優夫研収断井何南石足違消境神番規術護展態導鮮備宅害配副算視条幹独警宮究育席輸訪楽起万着乗店述残想線率病農州武声質念待試族象銀域助労例衛然早張映限親額監環験追審商葉義伝働形景落欧担好退準賞訴辺造英被株頭技低毎医復仕去姿味負閣韓渡失移差衆個門写評課末守若脳極種美岡影命含福蔵量望松非撃佐核観察整段横融型白深字答夜製票況音申様財港識注呼渉達良響阪帰針専推討私達思")
+
+	commonKorean = charSet("이다는의에하고을가로지서한기리사자도를으어수나대인정시적해게있일과보부상아전주들그것라만제스성생국문장구와니요면우소동화었신않경내데위여모실방중후세비연간마조관공유미물할야원개치음회분되말학까때년알없러거더저레된습히심체트선드무계민발속명통당단식업현재영표결반점변등각차금했됩람씨린름울번임겠받왔봐록났령범역안본최감행용")
+)
+
+// russianLetters orders the lowercase Russian letters by frequency
+const russianLetters = "оеаинтсрвлкмдпуяызьбгчйхжшюцщэфъё"
+
+// charSet builds a lookup set from the characters of a string
+func charSet(chars string) map[rune]bool {
+	set := make(map[rune]bool, utf8.RuneCountInString(chars))
+	for _, r := range chars {
+		set[r] = true
+	}
+	return set
+}
+
+// charsetMatch is the outcome of charset detection
+type charsetMatch struct {
+	name     string
+	encoding encoding.Encoding
+
+	// confidence is from 0 to 1
+	confidence float64
+}
+
+// isISO2022JP reports whether content is ISO-2022-JP, a 7-bit encoding that
+// is also valid UTF-8 and is recognized by its escape sequences
+func isISO2022JP(content []byte) bool {
+	if !bytes.Contains(content, []byte("\x1b$B")) && !bytes.Contains(content, []byte("\x1b$@")) {
+		return false
+	}
+	decoded, err := japanese.ISO2022JP.NewDecoder().Bytes(content)
+	return err == nil && !bytes.ContainsRune(decoded, utf8.RuneError)
+}
+
+// detectCharset guesses the legacy charset of content that is not valid
+// UTF-8. Each candidate decodes a sample of the content and the decoded text
+// is scored by how plausible its characters are for the candidate's language.
+// The confidence combines the winner's score with its lead over the runner-up.
+func detectCharset(content []byte) charsetMatch {
+	sample := content
+	if len(sample) > charsetSampleSize {
+		sample = sample[:charsetSampleSize]
+	}
+
+	best, second := -1.0, -1.0
+	var match charsetMatch
+	for _, candidate := range charsetCandidates {
+		decoded, err := candidate.encoding.NewDecoder().Bytes(sample)
+		if err != nil {
+			continue
+		}
+		score := scoreDecodedText(string(decoded), candidate.script, len(sample) < len(content)) + candidate.prior
+		switch {
+		case score > best:
+			best, second = score, best
+			match = charsetMatch{name: candidate.name, encoding: candidate.encoding}
+		case score > second:
+			second = score
+		}
+	}
+
+	if match.encoding == nil {
+		return charsetMatch{name: "Windows-1252", encoding: charmap.Windows1252}
+	}
+	match.confidence = max(0, min(1, best-max(second, 0)/2))
+	return match
+}
+
+// scoreDecodedText rates from 0 to 1 how plausible the non-ASCII characters
+// of decoded text are for a script. Words mixing Latin and other letters are
+// penalized, as are undecodable bytes, except at the end of a truncated sample.
+func scoreDecodedText(text string, script charsetScript, truncated bool) float64 {
+	if truncated {
+		// The sample may end in the middle of a multi-byte character
+		text = strings.TrimRight(text, string(utf8.RuneError))
+	}
+
+	total, count := 0.0, 0
+	var word []rune
+	scoreWord := func() {
+		latin, other := false, false
+		for _, r := range word {
+			if r < utf8.RuneSelf || unicode.Is(unicode.Latin, r) {
+				latin = latin || r < utf8.RuneSelf || script != scriptLatin
+			} else {
+				other = true
+			}
+		}
+		for _, r := range word {
+			if r < utf8.RuneSelf {
+				continue
+			}
+			score := runePlausibility(r, script)
+			if latin && other {
+				score *= 0.2
+			}
+			total += score
+			count++
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsMark(r) {
+			word = append(word, r)
+			continue
+		}
+		scoreWord()
+		if r >= utf8.RuneSelf {
+			total += runePlausibility(r, script)
+			count++
+		}
+	}
+	scoreWord()
+
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+// runePlausibility rates from 0 to 1 how likely a non-ASCII character is in
+// text of the given script
+func runePlausibility(r rune, script charsetScript) float64 {
+	cjk := script == scriptJapanese || script == scriptSimplifiedChinese || script == scriptTraditionalChinese || script == scriptKorean
+
+	switch {
+	case r == utf8.RuneError || unicode.IsControl(r) || unicode.Is(unicode.Co, r):
+		return 0
+
+	case r >= 0x3041 && r <= 0x30FF: // hiragana and katakana
+		switch script {
+		case scriptJapanese:
+			return 1
+		case scriptSimplifiedChinese, scriptTraditionalChinese:
+			return 0.3
+		}
+		return 0.05
+
+	case r >= 0xFF61 && r <= 0xFF9F: // halfwidth katakana
+		return 0.05
+
+	case unicode.Is(unicode.Han, r):
+		var common map[rune]bool
+		switch script {
+		case scriptJapanese:
+			common = commonJapaneseKanji
+		case scriptSimplifiedChinese:
+			common = commonSimplifiedChinese
+		case scriptTraditionalChinese:
+			common = commonTraditionalChinese
+		case scriptKorean:
+			return 0.3 // Hanja appear occasionally in Korean text
+		default:
+			return 0.05
+		}
+		if common[r] {
+			return 1
+		}
+		return 0.4
+
+	case r >= 0xAC00 && r <= 0xD7A3: // Hangul syllables
+		if script != scriptKorean {
+			return 0.05
+		}
+		if commonKorean[r] {
+			return 1
+		}
+		return 0.6
+
+	case unicode.Is(unicode.Hangul, r): // isolated jamo
+		return 0.1
+
+	case r >= 0x3000 && r <= 0x303F, r >= 0xFF01 && r <= 0xFF60: // CJK and fullwidth punctuation
+		if !cjk {
+			return 0.05
+		}
+		if strings.ContainsRune("、。「」『』（）！？，：；・〜【】《》〈〉　", r) {
+			return 0.9
+		}
+		return 0.3
+
+	case unicode.Is(unicode.Cyrillic, r):
+		if script != scriptCyrillic {
+			return 0.05
+		}
+		if i := strings.IndexRune(russianLetters, r); i >= 0 {
+			return 1 - 0.4*float64(utf8.RuneCountInString(russianLetters[:i]))/float64(utf8.RuneCountInString(russianLetters))
+		}
+		if strings.ContainsRune("іїєґў", r) {
+			return 0.6 // Ukrainian and Belarusian letters
+		}
+		if unicode.IsUpper(r) {
+			return 0.5
+		}
+		return 0.2
+
+	case r >= 0xC0 && r <= 0x24F && unicode.IsLetter(r): // accented Latin letters
+		if script != scriptLatin {
+			return 0.05
+		}
+		if strings.ContainsRune("éèêëàâäáãåçíìîïóòôöõøúùûüñßæœąćęłńśźżčďěňřšťůžőű", r) {
+			return 0.9
+		}
+		if strings.ContainsRune("ÉÈÊÀÂÄÁÇÍÎÓÔÖØÚÜÑÆŒĄĆĘŁŃŚŹŻČĎĚŇŘŠŤŮŽŐŰ", r) {
+			return 0.5
+		}
+		return 0.1
+
+	case r >= 0xA0 && r <= 0xBF: // Latin-1 punctuation and symbols
+		if script != scriptLatin {
+			return 0.1
+		}
+		if strings.ContainsRune(" ©«»°£§±·½¿¡€", r) {
+			return 0.6
+		}
+		return 0.15
+
+	case r >= 0x2010 && r <= 0x206F: // general punctuation
+		if strings.ContainsRune("‘’“”–—…•", r) {
+			return 0.8
+		}
+		return 0.3
+
+	case r == '€' || r == '№' || r == '™':
+		return 0.7
+	}
+	return 0.05
+}
+
+// lookupEncoding finds an encoding by its WHATWG or IANA name
+func lookupEncoding(name string) (encoding.Encoding, string, error) {
+	if enc, err := htmlindex.Get(name); err == nil {
+		canonical, _ := htmlindex.Name(enc)
+		return enc, canonical, nil
+	}
+	if enc, err := ianaindex.IANA.Encoding(name); err == nil && enc != nil {
+		canonical, _ := ianaindex.IANA.Name(enc)
+		return enc, canonical, nil
+	}
+	return nil, "", fmt.Errorf("%w: %q", ErrUnsupportedEncoding, name)
+}
@@ -0,0 +1,222 @@
+package extractor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CSVDialect describes how a delimited text file is structured
+type CSVDialect struct {
+	// Delimiter separates fields within a record
+	Delimiter rune
+
+	// Quote encloses fields containing delimiters, quotes or line breaks
+	Quote rune
+}
+
+// CSVParseError describes a malformed record in delimited text
+type CSVParseError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *CSVParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// csvDelimiters lists the delimiters considered when sniffing a dialect
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// csvSniffLines bounds how many lines are examined when sniffing a dialect
+const csvSniffLines = 50
+
+// sniffCSVDialect guesses the delimiter and quote character of delimited text.
+// The delimiter that splits the sample into the most consistent number of
+// fields (more than one) wins; ties go to the one producing more fields.
+func sniffCSVDialect(text string) CSVDialect {
+	sample := text
+	lines := 0
+	for i, char := range text {
+		if char == '\n' {
+			lines++
+			if lines >= csvSniffLines {
+				sample = text[:i]
+				break
+			}
+		}
+	}
+
+	quote := sniffCSVQuote(sample)
+
+	best := CSVDialect{Delimiter: ',', Quote: quote}
+	bestScore := 0.0
+	bestFields := 0
+	for _, delimiter := range csvDelimiters {
+		records, _ := parseCSV(sample, CSVDialect{Delimiter: delimiter, Quote: quote})
+
+		counts := make(map[int]int)
+		total := 0
+		for _, record := range records {
+			if isBlankRecord(record) {
+				continue
+			}
+			counts[len(record)]++
+			total++
+		}
+		if total == 0 {
+			continue
+		}
+
+		modeFields, modeCount := 0, 0
+		for fields, count := range counts {
+			if count > modeCount || (count == modeCount && fields > modeFields) {
+				modeFields, modeCount = fields, count
+			}
+		}
+		if modeFields < 2 {
+			continue
+		}
+
+		score := float64(modeCount) / float64(total)
+		if score > bestScore || (score == bestScore && modeFields > bestFields) {
+			best.Delimiter = delimiter
+			bestScore = score
+			bestFields = modeFields
+		}
+	}
+
+	return best
+}
+
+// sniffCSVQuote picks between double and single quotes by counting quotes
+// that open a field (at line start or after a delimiter) and close it (before
+// a delimiter or line end)
+func sniffCSVQuote(sample string) rune {
+	score := func(quote rune) int {
+		count := 0
+		runes := []rune(sample)
+		for i, char := range runes {
+			if char != quote {
+				continue
+			}
+			opens := i == 0 || runes[i-1] == '\n' || isCSVDelimiter(runes[i-1])
+			closes := i == len(runes)-1 || runes[i+1] == '\n' || runes[i+1] == '\r' || isCSVDelimiter(runes[i+1])
+			if opens != closes {
+				count++
+			}
+		}
+		return count
+	}
+
+	if score('\'') > score('"') {
+		return '\''
+	}
+	return '"'
+}
+
+// isCSVDelimiter reports whether a rune is one of the sniffed delimiters
+func isCSVDelimiter(char rune) bool {
+	for _, delimiter := range csvDelimiters {
+		if char == delimiter {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCSV parses delimited text following RFC 4180, generalized to the given
+// dialect. Quoted fields may contain delimiters, doubled quotes and line
+// breaks. Malformed input is parsed leniently and each problem is reported
+// with the line and column where it occurred.
+func parseCSV(text string, dialect CSVDialect) ([][]string, []*CSVParseError) {
+	var records [][]string
+	var parseErrors []*CSVParseError
+
+	var record []string
+	var field strings.Builder
+	inQuotes := false
+	fieldStarted := false
+	quoteLine, quoteColumn := 0, 0
+	line, column := 1, 0
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		column++
+
+		if inQuotes {
+			switch {
+			case char == dialect.Quote && i+1 < len(runes) && runes[i+1] == dialect.Quote:
+				field.WriteRune(char)
+				i++
+				column++
+			case char == dialect.Quote:
+				inQuotes = false
+				if i+1 < len(runes) && runes[i+1] != dialect.Delimiter && runes[i+1] != '\n' && runes[i+1] != '\r' {
+					parseErrors = append(parseErrors, &CSVParseError{
+						Line: line, Column: column + 1, Message: "extraneous character after closing quote",
+					})
+				}
+			default:
+				field.WriteRune(char)
+				if char == '\n' {
+					line++
+					column = 0
+				}
+			}
+			continue
+		}
+
+		switch {
+		case char == dialect.Quote && !fieldStarted:
+			inQuotes = true
+			fieldStarted = true
+			quoteLine, quoteColumn = line, column
+		case char == dialect.Delimiter:
+			record = append(record, field.String())
+			field.Reset()
+			fieldStarted = false
+		case char == '\r' && i+1 < len(runes) && runes[i+1] == '\n':
+			// Handled together with the following line feed
+		case char == '\n' || char == '\r':
+			record = append(record, field.String())
+			records = append(records, record)
+			record = nil
+			field.Reset()
+			fieldStarted = false
+			line++
+			column = 0
+		default:
+			if char == dialect.Quote {
+				parseErrors = append(parseErrors, &CSVParseError{
+					Line: line, Column: column, Message: "bare quote in unquoted field",
+				})
+			}
+			field.WriteRune(char)
+			fieldStarted = true
+		}
+	}
+
+	if inQuotes {
+		parseErrors = append(parseErrors, &CSVParseError{
+			Line: quoteLine, Column: quoteColumn, Message: "quoted field is never closed",
+		})
+	}
+	if fieldStarted || field.Len() > 0 || len(record) > 0 {
+		record = append(record, field.String())
+		records = append(records, record)
+	}
+
+	return records, parseErrors
+}
+
+// isBlankRecord reports whether every field in a record is empty
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // DOCXExtractor handles Microsoft Word DOCX file text extraction
@@ -22,16 +23,25 @@ func NewDOCXExtractor() *DOCXExtractor {
 
 // Extract extracts text and metadata from a DOCX file
 func (e *DOCXExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
+	start := time.Now()
+
 	// Read all content first
 	content, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read DOCX content: %w", err)
 	}
 
-	// Get basic text extraction from content
-	result, err := e.PlainTextExtractor.Extract(strings.NewReader(string(content)), options)
-	if err != nil {
-		return nil, err
+	// Check file size limit
+	if options.MaxFileSize > 0 && int64(len(content)) > options.MaxFileSize {
+		return nil, NewExtractorError(
+			fmt.Sprintf("file size %d exceeds limit %d", len(content), options.MaxFileSize),
+			"docx", "size_check", nil)
+	}
+
+	result := &ExtractResult{
+		Metadata: map[string]interface{}{
+			"size_bytes": len(content),
+		},
 	}
 
 	// Extract text by manually parsing DOCX structure
@@ -48,6 +58,8 @@ func (e *DOCXExtractor) Extract(reader io.Reader, options ExtractOptions) (*Extr
 	result.Metadata["characters"] = strconv.Itoa(len(result.Text))
 	result.Metadata["line_count"] = strconv.Itoa(strings.Count(result.Text, "\n") + 1)
 
+	result.ProcessingTime = time.Since(start)
+
 	return result, nil
 }
 
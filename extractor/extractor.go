@@ -1,18 +1,21 @@
 package extractor
 
 import (
+	"fmt"
 	"io"
+	"sort"
 	"time"
+	"unicode/utf8"
 )
 
 // TextExtractor defines the interface for extracting text from various file formats
 type TextExtractor interface {
 	// Extract extracts text from an io.Reader
 	Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error)
-	
+
 	// ExtractFromFile extracts text from a file path
 	ExtractFromFile(filepath string, options ExtractOptions) (*ExtractResult, error)
-	
+
 	// SupportedTypes returns a list of supported file types/extensions
 	SupportedTypes() []string
 }
@@ -21,33 +24,91 @@ type TextExtractor interface {
 type ExtractOptions struct {
 	// FileType overrides automatic file type detection
 	FileType string
-	
-	// OCRLanguage specifies the language for OCR processing (for images)
+
+	// OCRLanguage specifies the language passed to the OCR engine for images and scanned PDF pages
 	OCRLanguage string
-	
+
 	// MaxFileSize sets the maximum file size to process (in bytes)
 	MaxFileSize int64
-	
+
 	// Timeout sets the maximum time to spend on extraction
 	Timeout time.Duration
-	
+
 	// PreserveFormatting indicates whether to preserve text formatting
 	PreserveFormatting bool
+
+	// FirstPage is the first page to extract, starting at 1 (0 means the first page)
+	FirstPage int
+
+	// LastPage is the last page to extract, inclusive (0 means the last page)
+	LastPage int
+
+	// Password is used to decrypt password-protected documents
+	Password string
+
+	// Encoding forces the character encoding of text content, by WHATWG or IANA name
+	// such as "shift_jis" or "windows-1251" (empty means detect it)
+	Encoding string
 }
 
 // ExtractResult contains the result of text extraction
 type ExtractResult struct {
 	// Text is the extracted plain text content
 	Text string
-	
+
 	// Metadata contains additional information about the extraction
 	Metadata map[string]interface{}
-	
+
 	// FileType is the detected or specified file type
 	FileType string
-	
+
 	// ProcessingTime is the time taken for extraction
 	ProcessingTime time.Duration
+
+	// Pages contains per-page results for paged formats, in page order
+	Pages []PageResult
+}
+
+// PageResult contains the extraction result for a single page
+type PageResult struct {
+	// Number is the page number, starting at 1
+	Number int
+
+	// Label is the page label displayed by viewers, such as "iv" or "A-3", when the document defines one
+	Label string
+
+	// Text is the text extracted from the page
+	Text string
+
+	// Offset is the rune offset of the page text within ExtractResult.Text
+	Offset int
+
+	// Err is set when the page could not be extracted
+	Err error
+
+	// Metadata contains additional information about the page
+	Metadata map[string]interface{}
+}
+
+// PageAt returns the page containing the given rune offset into Text.
+// Offsets falling in the separator between two pages belong to the earlier page.
+func (r *ExtractResult) PageAt(offset int) (*PageResult, bool) {
+	if offset < 0 || len(r.Pages) == 0 {
+		return nil, false
+	}
+
+	index := sort.Search(len(r.Pages), func(i int) bool {
+		return r.Pages[i].Offset > offset
+	}) - 1
+	if index < 0 {
+		return nil, false
+	}
+
+	if index == len(r.Pages)-1 && offset >= r.Pages[index].Offset+utf8.RuneCountInString(r.Pages[index].Text) {
+		return nil, false
+	}
+
+	return &r.Pages[index], true
 }
 
 // ExtractorError represents errors that occur during text extraction
@@ -79,12 +140,37 @@ func NewExtractorError(message, fileType, operation string, cause error) *Extrac
 	}
 }
 
+// pageRange resolves the FirstPage and LastPage options against a document's
+// page count, returning an inclusive 1-based range. A document without pages
+// yields the empty range 1-0 unless a range was requested.
+func pageRange(options ExtractOptions, pageCount int) (int, int, error) {
+	first, last := options.FirstPage, options.LastPage
+	if pageCount == 0 && first <= 0 && last <= 0 {
+		return 1, 0, nil
+	}
+	if first <= 0 {
+		first = 1
+	}
+	if last <= 0 || last > pageCount {
+		last = pageCount
+	}
+
+	if first > pageCount {
+		return 0, 0, fmt.Errorf("first page %d is beyond the last page %d", first, pageCount)
+	}
+	if first > last {
+		return 0, 0, fmt.Errorf("first page %d is after last page %d", first, last)
+	}
+
+	return first, last, nil
+}
+
 // DefaultExtractOptions returns default extraction options
 func DefaultExtractOptions() ExtractOptions {
 	return ExtractOptions{
 		MaxFileSize:        100 * 1024 * 1024, // 100MB
-		Timeout:           30 * time.Second,
-		OCRLanguage:       "eng",
+		Timeout:            30 * time.Second,
+		OCRLanguage:        "eng",
 		PreserveFormatting: false,
 	}
-}
\ No newline at end of file
+}
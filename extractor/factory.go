@@ -2,6 +2,7 @@ package extractor
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/gabriel-vasile/mimetype"
@@ -17,8 +18,8 @@ func CreateExtractorFromPath(filePath string) (TextExtractor, error) {
 
 	// If filetype detected something, use it
 	if mtype.String() != "unknown" {
-		// Map MIME types and extensions to our extractors
-		mimeType := mtype.String()
+		// Map MIME types, without parameters such as the charset, and extensions to our extractors
+		mimeType, _, _ := strings.Cut(mtype.String(), ";")
 
 		// Handle specific MIME types first
 		switch mimeType {
@@ -36,9 +37,30 @@ func CreateExtractorFromPath(filePath string) (TextExtractor, error) {
 			return NewPPTXExtractor(), nil
 		case "application/vnd.ms-powerpoint":
 			return NewLegacyPPTExtractor(), nil // Legacy PPT format
+		case "application/vnd.oasis.opendocument.text", "application/vnd.oasis.opendocument.text-template":
+			return NewODTExtractor(), nil
+		case "application/vnd.oasis.opendocument.spreadsheet", "application/vnd.oasis.opendocument.spreadsheet-template":
+			return NewODSExtractor(), nil
+		case "application/vnd.oasis.opendocument.presentation", "application/vnd.oasis.opendocument.presentation-template":
+			return NewODPExtractor(), nil
+		case "text/rtf", "application/rtf":
+			return NewRTFExtractor(), nil
+		case "text/csv", "text/tab-separated-values":
+			return NewCSVExtractor(), nil
+		case "image/svg+xml":
+			return NewSVGExtractor(), nil
+		case "text/html", "application/xhtml+xml":
+			return NewHTMLExtractor(), nil
 		default:
 			if strings.HasPrefix(mimeType, "text/") {
+				// Markdown is only told apart from plain text by its extension
+				switch strings.ToLower(filepath.Ext(filePath)) {
+				case ".md", ".markdown":
+					return NewMarkdownExtractor(), nil
+				}
 				return NewPlainTextExtractor(), nil
+			} else if strings.HasPrefix(mimeType, "image/") {
+				return NewImageExtractor(), nil
 			} else {
 				return nil, fmt.Errorf("unsupported file type %s for %s", mimeType, filePath)
 			}
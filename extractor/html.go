@@ -0,0 +1,635 @@
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLExtractor extracts the visible text of HTML and XHTML documents
+type HTMLExtractor struct {
+	MaxFileSize int64 // Maximum file size in bytes (default: 50MB)
+
+	// MainContent keeps only the main content of a page, such as the article
+	// of a crawled news page, scoring blocks the way readability tools do and
+	// dropping navigation, sidebars, comments and footers
+	MainContent bool
+}
+
+// HTMLLink is a hyperlink of an HTML document
+type HTMLLink struct {
+	// URL is the href attribute as written in the document
+	URL string
+
+	// Text is the link text, or the title attribute when the link has no text
+	Text string
+}
+
+// HTMLResult contains the extraction result for an HTML document together
+// with its links
+type HTMLResult struct {
+	*ExtractResult
+
+	// Links holds the links of the whole document, in document order, even
+	// when only the main content is extracted
+	Links []HTMLLink
+}
+
+// NewHTMLExtractor creates a new HTML extractor
+func NewHTMLExtractor() *HTMLExtractor {
+	return &HTMLExtractor{
+		MaxFileSize: 50 * 1024 * 1024, // 50MB default
+	}
+}
+
+// htmlSkipped lists elements whose content is not part of the page text
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Nav:      true,
+	atom.Template: true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Canvas:   true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Select:   true,
+}
+
+// htmlParagraphs lists block elements set apart by a blank line
+var htmlParagraphs = map[atom.Atom]bool{
+	atom.P: true, atom.Pre: true, atom.Blockquote: true, atom.Table: true, atom.Figure: true,
+	atom.Ul: true, atom.Ol: true, atom.Dl: true, atom.Hr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+}
+
+// htmlBlocks lists the other block elements, which start on a new line
+var htmlBlocks = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Body: true, atom.Caption: true,
+	atom.Center: true, atom.Dd: true, atom.Details: true, atom.Dialog: true, atom.Div: true,
+	atom.Dt: true, atom.Fieldset: true, atom.Figcaption: true, atom.Footer: true, atom.Form: true,
+	atom.Header: true, atom.Hgroup: true, atom.Legend: true, atom.Li: true, atom.Main: true,
+	atom.Menu: true, atom.Section: true, atom.Summary: true, atom.Tr: true, atom.Thead: true,
+	atom.Tbody: true, atom.Tfoot: true,
+}
+
+// Extract extracts the text of an HTML document
+func (e *HTMLExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
+	result, err := e.ExtractHTML(reader, options)
+	if err != nil {
+		return nil, err
+	}
+	return result.ExtractResult, nil
+}
+
+// ExtractFromFile extracts the text of an HTML file
+func (e *HTMLExtractor) ExtractFromFile(filePath string, options ExtractOptions) (*ExtractResult, error) {
+	result, err := e.ExtractHTMLFromFile(filePath, options)
+	if err != nil {
+		return nil, err
+	}
+	return result.ExtractResult, nil
+}
+
+// ExtractHTMLFromFile extracts the text and links of an HTML file
+func (e *HTMLExtractor) ExtractHTMLFromFile(filePath string, options ExtractOptions) (*HTMLResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return e.ExtractHTML(file, options)
+}
+
+// ExtractHTML extracts the text of an HTML document and returns its links as well
+func (e *HTMLExtractor) ExtractHTML(reader io.Reader, options ExtractOptions) (*HTMLResult, error) {
+	start := time.Now()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HTML content: %w", err)
+	}
+
+	maxSize := e.MaxFileSize
+	if options.MaxFileSize > 0 {
+		maxSize = options.MaxFileSize
+	}
+	if maxSize > 0 && int64(len(content)) > maxSize {
+		return nil, NewExtractorError("file too large", "html", "size_check", nil)
+	}
+
+	source, encoding, err := decodeHTML(content, options.Encoding)
+	if err != nil {
+		return nil, NewExtractorError("failed to convert encoding", "html", "encoding", err)
+	}
+
+	document, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return nil, NewExtractorError("invalid HTML document", "html", "parse", err)
+	}
+
+	metadata := map[string]interface{}{
+		"encoding":            encoding.name,
+		"encoding_confidence": encoding.confidence,
+		"bom":                 encoding.bom,
+	}
+	describeHTML(document, metadata)
+
+	root := document
+	var extra []*html.Node
+	if e.MainContent {
+		if main, siblings := htmlMainContent(document); main != nil {
+			root, extra = main, siblings
+			metadata["main_content"] = true
+		} else {
+			metadata["main_content"] = false
+		}
+	}
+
+	writer := &htmlTextWriter{mainContent: e.MainContent}
+	writer.walk(root, false)
+	for _, node := range extra {
+		writer.lineBreak(2)
+		writer.walk(node, false)
+	}
+	text := writer.String()
+
+	var links []HTMLLink
+	collectHTMLLinks(document, &links)
+
+	metadata["link_count"] = len(links)
+	metadata["line_count"] = strings.Count(text, "\n") + 1
+	metadata["word_count"] = len(strings.Fields(text))
+	metadata["char_count"] = len([]rune(text))
+
+	return &HTMLResult{
+		ExtractResult: &ExtractResult{
+			Text:           text,
+			Metadata:       metadata,
+			FileType:       "html",
+			ProcessingTime: time.Since(start),
+		},
+		Links: links,
+	}, nil
+}
+
+// SupportedTypes returns the file types supported by this extractor
+func (e *HTMLExtractor) SupportedTypes() []string {
+	return []string{"html", "htm", "xhtml"}
+}
+
+// decodeHTML converts an HTML document to UTF-8. The encoding is taken from
+// the options, a byte order mark or a <meta> declaration, and detected like
+// plain text otherwise.
+func decodeHTML(content []byte, forced string) (string, textEncoding, error) {
+	if _, enc, _ := sniffBOM(content); forced == "" && enc == nil {
+		if label := htmlMetaCharset(content); label != "" {
+			// A document that declares UTF-16 is read as UTF-8, as browsers do
+			if strings.HasPrefix(strings.ToLower(label), "utf-16") {
+				label = "utf-8"
+			}
+			if enc, name, err := lookupEncoding(label); err == nil {
+				if decoded, err := enc.NewDecoder().Bytes(content); err == nil {
+					return string(decoded), textEncoding{name: name, confidence: 1}, nil
+				}
+			}
+		}
+	}
+	return (&PlainTextExtractor{}).detectAndConvertEncoding(content, forced)
+}
+
+// xmlDeclarationEncoding matches the encoding of an XHTML document's XML declaration
+var xmlDeclarationEncoding = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding\s*=\s*["']([^"']+)["']`)
+
+// htmlMetaCharset returns the charset declared by the XML declaration of an
+// XHTML document, or by a <meta charset> or <meta http-equiv="Content-Type">
+// element near the start of the document
+func htmlMetaCharset(content []byte) string {
+	if len(content) > 4096 {
+		content = content[:4096]
+	}
+	if match := xmlDeclarationEncoding.FindSubmatch(content); match != nil {
+		return string(match[1])
+	}
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.DataAtom {
+			case atom.Meta:
+				if charset := htmlAttr(token.Attr, "charset"); charset != "" {
+					return charset
+				}
+				if strings.EqualFold(htmlAttr(token.Attr, "http-equiv"), "content-type") {
+					if _, params, err := mime.ParseMediaType(htmlAttr(token.Attr, "content")); err == nil && params["charset"] != "" {
+						return params["charset"]
+					}
+				}
+			case atom.Body:
+				return ""
+			}
+		}
+	}
+}
+
+// describeHTML records the title, language and <meta> descriptions of a document
+func describeHTML(document *html.Node, metadata map[string]interface{}) {
+	var visit func(node *html.Node)
+	visit = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch node.DataAtom {
+			case atom.Html:
+				if language := htmlAttr(node.Attr, "lang"); language != "" {
+					metadata["language"] = language
+				}
+			case atom.Title:
+				if _, ok := metadata["title"]; !ok {
+					if title := collapseSpace(htmlTextContent(node)); title != "" {
+						metadata["title"] = title
+					}
+				}
+			case atom.Meta:
+				name := strings.ToLower(htmlAttr(node.Attr, "name"))
+				if name == "" {
+					name = strings.ToLower(htmlAttr(node.Attr, "property"))
+				}
+				value := collapseSpace(htmlAttr(node.Attr, "content"))
+				switch {
+				case value == "":
+				case name == "description", name == "og:description" && metadata["description"] == nil:
+					metadata["description"] = value
+				case name == "author":
+					metadata["author"] = value
+				case name == "keywords":
+					var keywords []string
+					for _, keyword := range strings.Split(value, ",") {
+						if keyword = strings.TrimSpace(keyword); keyword != "" {
+							keywords = append(keywords, keyword)
+						}
+					}
+					metadata["keywords"] = keywords
+				case name == "og:title" && metadata["title"] == nil:
+					metadata["title"] = value
+				}
+			case atom.Body:
+				return
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(document)
+}
+
+// collectHTMLLinks appends the links of a node in document order
+func collectHTMLLinks(node *html.Node, links *[]HTMLLink) {
+	if node.Type == html.ElementNode {
+		switch node.DataAtom {
+		case atom.Script, atom.Style, atom.Template:
+			return
+		case atom.A:
+			if href := htmlAttr(node.Attr, "href"); href != "" {
+				text := collapseSpace(htmlTextContent(node))
+				if text == "" {
+					text = collapseSpace(htmlAttr(node.Attr, "title"))
+				}
+				*links = append(*links, HTMLLink{URL: href, Text: text})
+			}
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		collectHTMLLinks(child, links)
+	}
+}
+
+// htmlTextWriter renders the text of a DOM tree with block elements on their
+// own lines, table cells separated by tabs and whitespace collapsed outside
+// preformatted text
+type htmlTextWriter struct {
+	b strings.Builder
+
+	// breaks is the number of line breaks owed before the next text
+	breaks int
+
+	// separator is owed before the next text on the same line
+	separator string
+
+	// mainContent also skips the boilerplate of pages
+	mainContent bool
+}
+
+// walk renders a node and its descendants
+func (w *htmlTextWriter) walk(node *html.Node, pre bool) {
+	switch node.Type {
+	case html.TextNode:
+		w.write(node.Data, pre)
+		return
+	case html.ElementNode:
+		if htmlSkipped[node.DataAtom] || htmlHidden(node) || (w.mainContent && htmlBoilerplate(node)) {
+			return
+		}
+	case html.DocumentNode:
+	default:
+		return
+	}
+
+	breaks := 0
+	switch {
+	case htmlParagraphs[node.DataAtom]:
+		breaks = 2
+	case htmlBlocks[node.DataAtom]:
+		breaks = 1
+	case node.DataAtom == atom.Br:
+		// A line break between paragraphs adds nothing to their blank line
+		if w.breaks < 2 {
+			w.flush()
+			w.b.WriteByte('\n')
+		}
+	case node.DataAtom == atom.Td || node.DataAtom == atom.Th:
+		if w.breaks == 0 && w.b.Len() > 0 {
+			w.separator = "\t"
+		}
+	}
+	w.lineBreak(breaks)
+
+	pre = pre || node.DataAtom == atom.Pre || node.DataAtom == atom.Textarea
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		w.walk(child, pre)
+	}
+	w.lineBreak(breaks)
+}
+
+// write adds text, collapsing its whitespace unless it is preformatted
+func (w *htmlTextWriter) write(text string, pre bool) {
+	if pre {
+		if text != "" {
+			w.flush()
+			w.b.WriteString(text)
+		}
+		return
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		if text != "" && w.separator == "" {
+			w.separator = " "
+		}
+		return
+	}
+	if strings.TrimLeft(text, " \t\n\r\f") != text && w.separator == "" {
+		w.separator = " "
+	}
+	w.flush()
+	w.b.WriteString(strings.Join(fields, " "))
+	if strings.TrimRight(text, " \t\n\r\f") != text {
+		w.separator = " "
+	}
+}
+
+// lineBreak owes at least n line breaks before the next text
+func (w *htmlTextWriter) lineBreak(n int) {
+	w.breaks = max(w.breaks, n)
+}
+
+// flush writes the line breaks or separator owed before new text, counting
+// the line breaks already written
+func (w *htmlTextWriter) flush() {
+	if w.b.Len() > 0 {
+		written := len(w.b.String()) - len(strings.TrimRight(w.b.String(), "\n"))
+		switch {
+		case w.breaks > written:
+			w.b.WriteString(strings.Repeat("\n", w.breaks-written))
+		case w.breaks == 0 && written == 0:
+			w.b.WriteString(w.separator)
+		}
+	}
+	w.breaks, w.separator = 0, ""
+}
+
+// String returns the rendered text without surrounding blank lines
+func (w *htmlTextWriter) String() string {
+	return strings.Trim(w.b.String(), "\n")
+}
+
+// htmlHidden reports whether an element is hidden from readers
+func htmlHidden(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		switch attr.Key {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if strings.EqualFold(strings.TrimSpace(attr.Val), "true") {
+				return true
+			}
+		case "style":
+			for _, declaration := range strings.Split(attr.Val, ";") {
+				property, value, ok := strings.Cut(declaration, ":")
+				if !ok {
+					continue
+				}
+				property = strings.ToLower(strings.TrimSpace(property))
+				value = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important")))
+				if property == "display" && value == "none" || property == "visibility" && value == "hidden" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+var (
+	// htmlPositiveHint matches class names and ids of main content
+	htmlPositiveHint = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+
+	// htmlNegativeHint matches class names and ids of page boilerplate
+	htmlNegativeHint = regexp.MustCompile(`(?i)\b(ad|ads|advert|banner|breadcrumbs?|combx|comment|comments|community|cookie|disqus|extra|footer|footnote|header|legends|menu|meta|modal|nav|outbrain|pager|pagination|popup|promo|related|remark|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tags|taboola|tool|widget)\b`)
+)
+
+// htmlBoilerplate reports whether an element is unlikely to be part of the
+// main content of a page
+func htmlBoilerplate(node *html.Node) bool {
+	switch node.DataAtom {
+	case atom.Header, atom.Footer, atom.Aside, atom.Form, atom.Button, atom.Dialog:
+		return true
+	}
+	if strings.EqualFold(htmlAttr(node.Attr, "role"), "navigation") || strings.EqualFold(htmlAttr(node.Attr, "role"), "complementary") {
+		return true
+	}
+	hints := htmlAttr(node.Attr, "class") + " " + htmlAttr(node.Attr, "id")
+	return htmlNegativeHint.MatchString(hints) && !htmlPositiveHint.MatchString(hints)
+}
+
+// htmlMainContent finds the element holding the main content of a page, like
+// readability tools do. Each paragraph adds to the score of its parent and
+// half as much to its grandparent, depending on its length and commas. Scores
+// are weighed by the element type, class names and link density. Siblings of
+// the best element that score well are returned as well, as content is often
+// split across them.
+func htmlMainContent(document *html.Node) (*html.Node, []*html.Node) {
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+	addScore := func(node *html.Node, score float64) {
+		if node == nil || node.Type != html.ElementNode || node.DataAtom == atom.Html {
+			return
+		}
+		if _, ok := scores[node]; !ok {
+			scores[node] = htmlBaseScore(node)
+			order = append(order, node)
+		}
+		scores[node] += score
+	}
+
+	var visit func(node *html.Node)
+	visit = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			if htmlSkipped[node.DataAtom] || htmlHidden(node) || htmlBoilerplate(node) {
+				return
+			}
+			switch node.DataAtom {
+			case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+				text := collapseSpace(htmlTextContent(node))
+				if length := len([]rune(text)); length >= 25 {
+					score := 1 + float64(strings.Count(text, ",")) + min(float64(length/100), 3)
+					addScore(node.Parent, score)
+					if node.Parent != nil {
+						addScore(node.Parent.Parent, score/2)
+					}
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(document)
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, node := range order {
+		scores[node] *= 1 - htmlLinkDensity(node)
+		if scores[node] > bestScore {
+			best, bestScore = node, scores[node]
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	var siblings []*html.Node
+	threshold := max(10, bestScore*0.2)
+	for sibling := best.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type != html.ElementNode {
+			continue
+		}
+		if score, ok := scores[sibling]; ok && score >= threshold {
+			siblings = append(siblings, sibling)
+			continue
+		}
+		if sibling.DataAtom == atom.P {
+			text := collapseSpace(htmlTextContent(sibling))
+			if len([]rune(text)) > 80 && htmlLinkDensity(sibling) < 0.25 {
+				siblings = append(siblings, sibling)
+			}
+		}
+	}
+	return best, siblings
+}
+
+// htmlBaseScore is the initial score of a content candidate
+func htmlBaseScore(node *html.Node) float64 {
+	score := 0.0
+	switch node.DataAtom {
+	case atom.Article, atom.Main:
+		score += 10
+	case atom.Div:
+		score += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score += 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		score -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score -= 5
+	}
+
+	hints := htmlAttr(node.Attr, "class") + " " + htmlAttr(node.Attr, "id")
+	if htmlNegativeHint.MatchString(hints) {
+		score -= 25
+	}
+	if htmlPositiveHint.MatchString(hints) {
+		score += 25
+	}
+	return score
+}
+
+// htmlLinkDensity returns the share of an element's text that is link text
+func htmlLinkDensity(node *html.Node) float64 {
+	total := len(collapseSpace(htmlTextContent(node)))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var visit func(node *html.Node)
+	visit = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.DataAtom == atom.A {
+			linked += len(collapseSpace(htmlTextContent(node)))
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(node)
+	return float64(linked) / float64(total)
+}
+
+// htmlTextContent returns the text of a node and its descendants, without scripts and styles
+func htmlTextContent(node *html.Node) string {
+	var b strings.Builder
+	var visit func(node *html.Node)
+	visit = func(node *html.Node) {
+		switch {
+		case node.Type == html.TextNode:
+			b.WriteString(node.Data)
+		case node.Type == html.ElementNode && (node.DataAtom == atom.Script || node.DataAtom == atom.Style || node.DataAtom == atom.Template):
+		default:
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				visit(child)
+			}
+		}
+	}
+	visit(node)
+	return b.String()
+}
+
+// htmlAttr returns the value of an attribute
+func htmlAttr(attrs []html.Attribute, name string) string {
+	for _, attr := range attrs {
+		if attr.Key == name {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
+
+// collapseSpace replaces runs of whitespace with single spaces
+func collapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
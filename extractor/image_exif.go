@@ -0,0 +1,397 @@
+package extractor
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// EXIFMetadata holds commonly used EXIF tags of an image
+type EXIFMetadata struct {
+	Make             string
+	Model            string
+	Software         string
+	Artist           string
+	Copyright        string
+	ImageDescription string
+	UserComment      string
+
+	// Windows Explorer properties
+	XPTitle    string
+	XPComment  string
+	XPAuthor   string
+	XPKeywords string
+	XPSubject  string
+
+	// DateTime is when the file was last changed and DateTimeOriginal when
+	// the picture was taken. EXIF dates carry no time zone unless the
+	// matching offset tag is present; otherwise they are returned as UTC.
+	DateTime         time.Time
+	DateTimeOriginal time.Time
+
+	// Orientation is the EXIF orientation, from 1 (upright) to 8, or 0 if not recorded
+	Orientation int
+
+	// GPS holds the position the picture was taken at, or nil if not recorded
+	GPS *GPSPosition
+}
+
+// GPSPosition is a position recorded by a camera
+type GPSPosition struct {
+	// Latitude and Longitude are in decimal degrees, negative to the south and west
+	Latitude  float64
+	Longitude float64
+
+	// Altitude is in meters above sea level
+	Altitude float64
+}
+
+// EXIF tags
+const (
+	exifImageDescriptionTag   = 0x010E
+	exifMakeTag               = 0x010F
+	exifModelTag              = 0x0110
+	exifOrientationTag        = 0x0112
+	exifSoftwareTag           = 0x0131
+	exifDateTimeTag           = 0x0132
+	exifArtistTag             = 0x013B
+	exifXMPTag                = 0x02BC
+	exifCopyrightTag          = 0x8298
+	exifIPTCTag               = 0x83BB
+	exifIFDTag                = 0x8769
+	exifGPSIFDTag             = 0x8825
+	exifDateTimeOriginalTag   = 0x9003
+	exifOffsetTimeTag         = 0x9010
+	exifOffsetTimeOriginalTag = 0x9011
+	exifUserCommentTag        = 0x9286
+	exifXPTitleTag            = 0x9C9B
+	exifXPCommentTag          = 0x9C9C
+	exifXPAuthorTag           = 0x9C9D
+	exifXPKeywordsTag         = 0x9C9E
+	exifXPSubjectTag          = 0x9C9F
+)
+
+// GPS tags
+const (
+	gpsLatitudeRefTag  = 1
+	gpsLatitudeTag     = 2
+	gpsLongitudeRefTag = 3
+	gpsLongitudeTag    = 4
+	gpsAltitudeRefTag  = 5
+	gpsAltitudeTag     = 6
+)
+
+// exifTypeSizes holds the size in bytes of each TIFF field type
+var exifTypeSizes = map[uint16]int{
+	1:  1, // BYTE
+	2:  1, // ASCII
+	3:  2, // SHORT
+	4:  4, // LONG
+	5:  8, // RATIONAL
+	6:  1, // SBYTE
+	7:  1, // UNDEFINED
+	8:  2, // SSHORT
+	9:  4, // SLONG
+	10: 8, // SRATIONAL
+	11: 4, // FLOAT
+	12: 8, // DOUBLE
+	13: 4, // IFD
+}
+
+// exifEntry is a single entry of a TIFF image file directory
+type exifEntry struct {
+	tag, kind uint16
+	count     uint32
+
+	// value holds the entry's data, or the four bytes of its offset when the
+	// data does not fit in the entry
+	value []byte
+}
+
+// exifBlock is EXIF data in TIFF layout
+type exifBlock struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// parseEXIFHeader checks the TIFF header of EXIF data and reads its byte order
+func parseEXIFHeader(data []byte) *exifBlock {
+	if len(data) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return nil
+	}
+	return &exifBlock{data: data, order: order}
+}
+
+// ifd reads the image file directory at the given offset, returning nil if it
+// lies outside the data
+func (b *exifBlock) ifd(offset uint32) []exifEntry {
+	if offset < 8 || int64(offset)+2 > int64(len(b.data)) {
+		return nil
+	}
+	count := int(b.order.Uint16(b.data[offset:]))
+	start := int(offset) + 2
+	if start+count*12 > len(b.data) {
+		return nil
+	}
+
+	entries := make([]exifEntry, count)
+	for i := range entries {
+		raw := b.data[start+i*12 : start+(i+1)*12]
+		entries[i] = exifEntry{
+			tag:   b.order.Uint16(raw),
+			kind:  b.order.Uint16(raw[2:]),
+			count: b.order.Uint32(raw[4:]),
+			value: raw[8:12],
+		}
+	}
+	return entries
+}
+
+// firstIFD returns the directory describing the main image
+func (b *exifBlock) firstIFD() []exifEntry {
+	return b.ifd(b.order.Uint32(b.data[4:]))
+}
+
+// bytes returns the data of an entry, or nil if it lies outside the block
+func (b *exifBlock) bytes(entry exifEntry) []byte {
+	size, ok := exifTypeSizes[entry.kind]
+	if !ok || entry.count > uint32(len(b.data)) {
+		return nil
+	}
+	length := int(entry.count) * size
+	if length <= 4 {
+		return entry.value[:length]
+	}
+	offset := int64(b.order.Uint32(entry.value))
+	if offset+int64(length) > int64(len(b.data)) {
+		return nil
+	}
+	return b.data[offset : offset+int64(length)]
+}
+
+// uint returns the first value of a BYTE, SHORT, LONG or IFD entry
+func (b *exifBlock) uint(entry exifEntry) (uint32, bool) {
+	switch entry.kind {
+	case 1:
+		return uint32(entry.value[0]), true
+	case 3:
+		return uint32(b.order.Uint16(entry.value)), true
+	case 4, 13:
+		return b.order.Uint32(entry.value), true
+	}
+	return 0, false
+}
+
+// string returns the value of an ASCII entry without trailing NULs and spaces
+func (b *exifBlock) string(entry exifEntry) string {
+	if entry.kind != 2 {
+		return ""
+	}
+	return strings.TrimRight(decodeLegacyText(b.bytes(entry)), "\x00 ")
+}
+
+// rationals returns the values of a RATIONAL entry
+func (b *exifBlock) rationals(entry exifEntry) []float64 {
+	if entry.kind != 5 {
+		return nil
+	}
+	data := b.bytes(entry)
+	values := make([]float64, 0, len(data)/8)
+	for i := 0; i+8 <= len(data); i += 8 {
+		numerator, denominator := b.order.Uint32(data[i:]), b.order.Uint32(data[i+4:])
+		if denominator == 0 {
+			return nil
+		}
+		values = append(values, float64(numerator)/float64(denominator))
+	}
+	return values
+}
+
+// parseEXIF reads the tags of interest from the main image, EXIF and GPS directories
+func parseEXIF(block *exifBlock) *EXIFMetadata {
+	result := &EXIFMetadata{}
+	var dateTime, dateTimeOriginal, offsetTime, offsetTimeOriginal string
+
+	var readIFD func(entries []exifEntry, depth int)
+	readIFD = func(entries []exifEntry, depth int) {
+		for _, entry := range entries {
+			switch entry.tag {
+			case exifImageDescriptionTag:
+				result.ImageDescription = block.string(entry)
+			case exifMakeTag:
+				result.Make = block.string(entry)
+			case exifModelTag:
+				result.Model = block.string(entry)
+			case exifSoftwareTag:
+				result.Software = block.string(entry)
+			case exifArtistTag:
+				result.Artist = block.string(entry)
+			case exifCopyrightTag:
+				result.Copyright = block.string(entry)
+			case exifDateTimeTag:
+				dateTime = block.string(entry)
+			case exifDateTimeOriginalTag:
+				dateTimeOriginal = block.string(entry)
+			case exifOffsetTimeTag:
+				offsetTime = block.string(entry)
+			case exifOffsetTimeOriginalTag:
+				offsetTimeOriginal = block.string(entry)
+			case exifUserCommentTag:
+				result.UserComment = decodeUserComment(block.bytes(entry), block.order)
+			case exifXPTitleTag:
+				result.XPTitle = decodeUCS2(block.bytes(entry))
+			case exifXPCommentTag:
+				result.XPComment = decodeUCS2(block.bytes(entry))
+			case exifXPAuthorTag:
+				result.XPAuthor = decodeUCS2(block.bytes(entry))
+			case exifXPKeywordsTag:
+				result.XPKeywords = decodeUCS2(block.bytes(entry))
+			case exifXPSubjectTag:
+				result.XPSubject = decodeUCS2(block.bytes(entry))
+			case exifOrientationTag:
+				if value, ok := block.uint(entry); ok && value >= 1 && value <= 8 {
+					result.Orientation = int(value)
+				}
+			case exifIFDTag:
+				if offset, ok := block.uint(entry); ok && depth == 0 {
+					readIFD(block.ifd(offset), depth+1)
+				}
+			case exifGPSIFDTag:
+				if offset, ok := block.uint(entry); ok && depth == 0 {
+					result.GPS = parseGPS(block, block.ifd(offset))
+				}
+			}
+		}
+	}
+	readIFD(block.firstIFD(), 0)
+
+	result.DateTime = parseEXIFDate(dateTime, offsetTime)
+	result.DateTimeOriginal = parseEXIFDate(dateTimeOriginal, offsetTimeOriginal)
+	return result
+}
+
+// parseGPS reads the position from a GPS directory, returning nil if it has none
+func parseGPS(block *exifBlock, entries []exifEntry) *GPSPosition {
+	var latitude, longitude, altitude []float64
+	var latitudeRef, longitudeRef string
+	belowSeaLevel := false
+	for _, entry := range entries {
+		switch entry.tag {
+		case gpsLatitudeRefTag:
+			latitudeRef = block.string(entry)
+		case gpsLatitudeTag:
+			latitude = block.rationals(entry)
+		case gpsLongitudeRefTag:
+			longitudeRef = block.string(entry)
+		case gpsLongitudeTag:
+			longitude = block.rationals(entry)
+		case gpsAltitudeRefTag:
+			value, _ := block.uint(entry)
+			belowSeaLevel = value == 1
+		case gpsAltitudeTag:
+			altitude = block.rationals(entry)
+		}
+	}
+	if len(latitude) != 3 || len(longitude) != 3 {
+		return nil
+	}
+
+	degrees := func(values []float64, negative bool) float64 {
+		result := values[0] + values[1]/60 + values[2]/3600
+		if negative {
+			result = -result
+		}
+		return math.Round(result*1e7) / 1e7
+	}
+	position := &GPSPosition{
+		Latitude:  degrees(latitude, latitudeRef == "S"),
+		Longitude: degrees(longitude, longitudeRef == "W"),
+	}
+	if len(altitude) == 1 {
+		position.Altitude = altitude[0]
+		if belowSeaLevel {
+			position.Altitude = -position.Altitude
+		}
+	}
+	return position
+}
+
+// parseEXIFDate parses an EXIF date such as "2023:06:01 14:30:00" with an
+// optional offset such as "+02:00"
+func parseEXIFDate(value, offset string) time.Time {
+	location := time.UTC
+	if offset != "" {
+		if parsed, err := time.Parse("-07:00", offset); err == nil {
+			_, seconds := parsed.Zone()
+			location = time.FixedZone(offset, seconds)
+		}
+	}
+	parsed, err := time.ParseInLocation("2006:01:02 15:04:05", value, location)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+
+// decodeUserComment decodes an EXIF UserComment, whose first eight bytes name its character code
+func decodeUserComment(data []byte, order binary.ByteOrder) string {
+	if len(data) < 8 {
+		return ""
+	}
+	code, text := string(data[:8]), data[8:]
+	var value string
+	switch code {
+	case "UNICODE\x00":
+		units := make([]uint16, len(text)/2)
+		for i := range units {
+			units[i] = order.Uint16(text[2*i:])
+		}
+		value = string(utf16.Decode(units))
+	case "JIS\x00\x00\x00\x00\x00":
+		return "" // JIS X 0208 is not supported
+	default: // ASCII or undefined
+		value = decodeLegacyText(text)
+	}
+	return strings.TrimSpace(strings.TrimRight(value, "\x00"))
+}
+
+// decodeUCS2 decodes the little-endian UCS-2 text of a Windows XP tag
+func decodeUCS2(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+	return strings.TrimSpace(strings.TrimRight(string(utf16.Decode(units)), "\x00"))
+}
+
+// exifOrientation returns the EXIF orientation of an image, from 1 (upright)
+// to 8, or 1 if the image does not record one
+func exifOrientation(content []byte) int {
+	block := parseEXIFHeader(findImageMetadata(content).exif)
+	if block == nil {
+		return 1
+	}
+	for _, entry := range block.firstIFD() {
+		if entry.tag != exifOrientationTag {
+			continue
+		}
+		if value, ok := block.uint(entry); ok && value >= 1 && value <= 8 {
+			return int(value)
+		}
+	}
+	return 1
+}
@@ -0,0 +1,411 @@
+package extractor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// IPTCMetadata holds commonly used IPTC-IIM properties of an image
+type IPTCMetadata struct {
+	ObjectName string
+	Headline   string
+	Caption    string
+	Keywords   []string
+	Bylines    []string
+	City       string
+	Country    string
+	Credit     string
+	Source     string
+	Copyright  string
+
+	// DateCreated is when the content was created
+	DateCreated time.Time
+}
+
+// IPTC application record datasets
+const (
+	iptcObjectName  = 5
+	iptcKeywords    = 25
+	iptcDateCreated = 55
+	iptcTimeCreated = 60
+	iptcByline      = 80
+	iptcCity        = 90
+	iptcCountry     = 101
+	iptcHeadline    = 105
+	iptcCredit      = 110
+	iptcSource      = 115
+	iptcCopyright   = 116
+	iptcCaption     = 120
+)
+
+// maxMetadataSize bounds the size of a compressed metadata block once inflated
+const maxMetadataSize = 16 * 1024 * 1024
+
+// imageMetadataBlocks holds the raw metadata blocks embedded in an image file
+type imageMetadataBlocks struct {
+	// exif is EXIF data in TIFF layout
+	exif []byte
+
+	// iptc is a sequence of IPTC-IIM datasets
+	iptc []byte
+
+	// xmp is an XMP packet
+	xmp []byte
+}
+
+var (
+	jpegEXIFPrefix      = []byte("Exif\x00\x00")
+	jpegXMPPrefix       = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	jpegPhotoshopPrefix = []byte("Photoshop 3.0\x00")
+)
+
+// findImageMetadata locates the EXIF, IPTC and XMP blocks of a JPEG, PNG,
+// WebP or TIFF file
+func findImageMetadata(content []byte) imageMetadataBlocks {
+	var blocks imageMetadataBlocks
+	switch {
+	case bytes.HasPrefix(content, []byte{0xFF, 0xD8}):
+		for i := 2; i+4 <= len(content) && content[i] == 0xFF; {
+			marker := content[i+1]
+			if marker == 0xD9 || marker == 0xDA {
+				break // end of image or start of the compressed data
+			}
+			length := int(binary.BigEndian.Uint16(content[i+2:]))
+			if length < 2 || i+2+length > len(content) {
+				break
+			}
+			segment := content[i+4 : i+2+length]
+			switch {
+			case marker == 0xE1 && bytes.HasPrefix(segment, jpegEXIFPrefix) && blocks.exif == nil:
+				blocks.exif = segment[len(jpegEXIFPrefix):]
+			case marker == 0xE1 && bytes.HasPrefix(segment, jpegXMPPrefix) && blocks.xmp == nil:
+				blocks.xmp = segment[len(jpegXMPPrefix):]
+			case marker == 0xED && bytes.HasPrefix(segment, jpegPhotoshopPrefix) && blocks.iptc == nil:
+				blocks.iptc = photoshopIPTC(segment[len(jpegPhotoshopPrefix):])
+			}
+			i += 2 + length
+		}
+
+	case bytes.HasPrefix(content, []byte("\x89PNG\r\n\x1a\n")):
+		for i := 8; i+12 <= len(content); {
+			length := int(binary.BigEndian.Uint32(content[i:]))
+			if length < 0 || i+12+length > len(content) {
+				break
+			}
+			data := content[i+8 : i+8+length]
+			switch string(content[i+4 : i+8]) {
+			case "eXIf":
+				blocks.exif = data
+			case "iTXt":
+				if keyword, text, ok := parsePNGText(data); ok && keyword == "XML:com.adobe.xmp" {
+					blocks.xmp = text
+				}
+			}
+			i += 12 + length
+		}
+
+	case bytes.HasPrefix(content, []byte("RIFF")) && len(content) >= 12 && string(content[8:12]) == "WEBP":
+		for i := 12; i+8 <= len(content); {
+			length := int(binary.LittleEndian.Uint32(content[i+4:]))
+			if length < 0 || i+8+length > len(content) {
+				break
+			}
+			data := content[i+8 : i+8+length]
+			switch string(content[i : i+4]) {
+			case "EXIF":
+				// Some writers keep the JPEG segment prefix
+				blocks.exif = bytes.TrimPrefix(data, jpegEXIFPrefix)
+			case "XMP ":
+				blocks.xmp = data
+			}
+			i += 8 + length + length%2
+		}
+
+	default:
+		// A TIFF file is itself EXIF data, with IPTC and XMP in tags of the first directory
+		block := parseEXIFHeader(content)
+		if block == nil {
+			break
+		}
+		blocks.exif = content
+		for _, entry := range block.firstIFD() {
+			switch entry.tag {
+			case exifXMPTag:
+				blocks.xmp = block.bytes(entry)
+			case exifIPTCTag:
+				blocks.iptc = block.bytes(entry)
+			}
+		}
+	}
+	return blocks
+}
+
+// photoshopIPTC returns the IPTC data from Photoshop image resource blocks
+func photoshopIPTC(data []byte) []byte {
+	for i := 0; i+8 <= len(data) && string(data[i:i+4]) == "8BIM"; {
+		id := binary.BigEndian.Uint16(data[i+4:])
+
+		// The resource name is a Pascal string padded to an even length
+		nameLength := int(data[i+6]) + 1
+		nameLength += nameLength % 2
+		start := i + 6 + nameLength
+		if start+4 > len(data) {
+			break
+		}
+		size := int(binary.BigEndian.Uint32(data[start:]))
+		if size < 0 || start+4+size > len(data) {
+			break
+		}
+		if id == 0x0404 {
+			return data[start+4 : start+4+size]
+		}
+		i = start + 4 + size + size%2
+	}
+	return nil
+}
+
+// parsePNGText reads the keyword and text of a PNG iTXt chunk
+func parsePNGText(data []byte) (string, []byte, bool) {
+	keyword, rest, ok := bytes.Cut(data, []byte{0})
+	if !ok || len(rest) < 2 {
+		return "", nil, false
+	}
+	compressed := rest[0] == 1
+
+	// Skip the compression method, language tag and translated keyword
+	_, rest, ok = bytes.Cut(rest[2:], []byte{0})
+	if !ok {
+		return "", nil, false
+	}
+	_, text, ok := bytes.Cut(rest, []byte{0})
+	if !ok {
+		return "", nil, false
+	}
+
+	if compressed {
+		reader, err := zlib.NewReader(bytes.NewReader(text))
+		if err != nil {
+			return "", nil, false
+		}
+		defer reader.Close()
+		text, err = io.ReadAll(io.LimitReader(reader, maxMetadataSize))
+		if err != nil {
+			return "", nil, false
+		}
+	}
+	return string(keyword), text, true
+}
+
+// parseIPTC reads the application record datasets of interest from IPTC-IIM data
+func parseIPTC(data []byte) *IPTCMetadata {
+	result := &IPTCMetadata{}
+	var date, clock string
+	for i := 0; i+5 <= len(data) && data[i] == 0x1C; {
+		record, dataset := data[i+1], data[i+2]
+		length := int(binary.BigEndian.Uint16(data[i+3:]))
+		if length&0x8000 != 0 || i+5+length > len(data) {
+			break // extended datasets are not used for text properties
+		}
+		value := strings.TrimSpace(decodeLegacyText(data[i+5 : i+5+length]))
+		i += 5 + length
+
+		if record != 2 || value == "" {
+			continue
+		}
+		switch dataset {
+		case iptcObjectName:
+			result.ObjectName = value
+		case iptcHeadline:
+			result.Headline = value
+		case iptcCaption:
+			result.Caption = value
+		case iptcKeywords:
+			result.Keywords = append(result.Keywords, value)
+		case iptcByline:
+			result.Bylines = append(result.Bylines, value)
+		case iptcCity:
+			result.City = value
+		case iptcCountry:
+			result.Country = value
+		case iptcCredit:
+			result.Credit = value
+		case iptcSource:
+			result.Source = value
+		case iptcCopyright:
+			result.Copyright = value
+		case iptcDateCreated:
+			date = value
+		case iptcTimeCreated:
+			clock = value
+		}
+	}
+
+	if date != "" {
+		if clock != "" {
+			result.DateCreated, _ = time.Parse("20060102150405-0700", date+clock)
+		}
+		if result.DateCreated.IsZero() {
+			result.DateCreated, _ = time.Parse("20060102", date)
+		}
+	}
+	return result
+}
+
+// decodeLegacyText decodes metadata text that is UTF-8 in current files and
+// usually Latin-1 in older ones
+func decodeLegacyText(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// readImageMetadata parses the EXIF, IPTC and XMP metadata embedded in an
+// image file, leaving the results nil for blocks the file does not contain
+func readImageMetadata(content []byte, result *ImageResult) {
+	blocks := findImageMetadata(content)
+	if block := parseEXIFHeader(blocks.exif); block != nil {
+		result.EXIF = parseEXIF(block)
+	}
+	if len(blocks.iptc) > 0 {
+		result.IPTC = parseIPTC(blocks.iptc)
+	}
+	if len(blocks.xmp) > 0 {
+		result.XMP = parseXMP(blocks.xmp)
+	}
+}
+
+// imageDescription collects the descriptive properties of an image, taking
+// each from EXIF, then IPTC, then XMP
+type imageDescription struct {
+	title, description, comment, subject, author, copyright string
+	keywords                                                []string
+	dateTaken                                               time.Time
+}
+
+// describe merges the descriptive properties of the image's metadata blocks
+func (r *ImageResult) describe() imageDescription {
+	var d imageDescription
+	first := func(target *string, values ...string) {
+		for _, value := range values {
+			if *target == "" {
+				*target = value
+			}
+		}
+	}
+	addKeywords := func(keywords ...string) {
+		for _, keyword := range keywords {
+			keyword = strings.TrimSpace(keyword)
+			if keyword == "" {
+				continue
+			}
+			duplicate := false
+			for _, existing := range d.keywords {
+				duplicate = duplicate || strings.EqualFold(existing, keyword)
+			}
+			if !duplicate {
+				d.keywords = append(d.keywords, keyword)
+			}
+		}
+	}
+
+	if exif := r.EXIF; exif != nil {
+		first(&d.title, exif.XPTitle)
+		first(&d.description, exif.ImageDescription)
+		first(&d.comment, exif.UserComment, exif.XPComment)
+		first(&d.subject, exif.XPSubject)
+		first(&d.author, exif.Artist, exif.XPAuthor)
+		first(&d.copyright, exif.Copyright)
+		addKeywords(strings.Split(exif.XPKeywords, ";")...)
+		d.dateTaken = exif.DateTimeOriginal
+	}
+	if iptc := r.IPTC; iptc != nil {
+		first(&d.title, iptc.ObjectName, iptc.Headline)
+		first(&d.description, iptc.Caption)
+		first(&d.author, strings.Join(iptc.Bylines, ", "))
+		first(&d.copyright, iptc.Copyright)
+		addKeywords(iptc.Keywords...)
+		if d.dateTaken.IsZero() {
+			d.dateTaken = iptc.DateCreated
+		}
+	}
+	if xmp := r.XMP; xmp != nil {
+		first(&d.title, xmp.Title)
+		first(&d.description, xmp.Description)
+		first(&d.author, strings.Join(xmp.Creators, ", "))
+		first(&d.copyright, xmp.Rights)
+		addKeywords(xmp.Subjects...)
+		if d.dateTaken.IsZero() {
+			d.dateTaken = xmp.CreateDate
+		}
+	}
+	return d
+}
+
+// addImageMetadata records the embedded metadata of interest in the result metadata
+func (r *ImageResult) addImageMetadata(metadata map[string]interface{}) {
+	d := r.describe()
+	fields := map[string]string{
+		"title":       d.title,
+		"description": d.description,
+		"comment":     d.comment,
+		"subject":     d.subject,
+		"author":      d.author,
+		"copyright":   d.copyright,
+	}
+	if exif := r.EXIF; exif != nil {
+		fields["camera_make"] = exif.Make
+		fields["camera_model"] = exif.Model
+		fields["software"] = exif.Software
+		if gps := exif.GPS; gps != nil {
+			metadata["gps_latitude"] = gps.Latitude
+			metadata["gps_longitude"] = gps.Longitude
+			metadata["gps_altitude"] = gps.Altitude
+		}
+	}
+	if iptc := r.IPTC; iptc != nil {
+		fields["city"] = iptc.City
+		fields["country"] = iptc.Country
+	}
+	for key, value := range fields {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	if len(d.keywords) > 0 {
+		metadata["keywords"] = d.keywords
+	}
+	if !d.dateTaken.IsZero() {
+		metadata["date_taken"] = d.dateTaken.Format(time.RFC3339)
+	}
+	metadata["exif"] = r.EXIF != nil
+	metadata["iptc"] = r.IPTC != nil
+	metadata["xmp"] = r.XMP != nil
+}
+
+// metadataText formats the text-bearing metadata properties as "Label: value" lines
+func (r *ImageResult) metadataText() string {
+	d := r.describe()
+	var lines []string
+	for _, field := range []struct{ label, value string }{
+		{"Title", d.title},
+		{"Subject", d.subject},
+		{"Description", d.description},
+		{"Comment", d.comment},
+		{"Keywords", strings.Join(d.keywords, ", ")},
+	} {
+		if field.value != "" {
+			lines = append(lines, field.label+": "+field.value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,445 @@
+package extractor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// ImageThreshold selects how images are binarized before OCR
+type ImageThreshold string
+
+const (
+	// ThresholdNone leaves gray levels unchanged
+	ThresholdNone ImageThreshold = ""
+
+	// ThresholdOtsu uses a single threshold chosen from the image histogram
+	ThresholdOtsu ImageThreshold = "otsu"
+
+	// ThresholdAdaptive compares each pixel with the mean of its neighbourhood,
+	// which copes with uneven lighting
+	ThresholdAdaptive ImageThreshold = "adaptive"
+)
+
+// ImagePreprocessing selects the steps applied to an image before it is passed
+// to the OCR engine. Steps run in the order of the fields below. Contrast
+// stretching, upscaling and thresholding work on gray levels, so enabling any
+// of them also converts the image to grayscale. All steps are off by default.
+type ImagePreprocessing struct {
+	// AutoOrient rotates and flips the image upright according to its EXIF orientation
+	AutoOrient bool
+
+	// CropBorders removes uniform margins and scanner borders around the content
+	CropBorders bool
+
+	// Grayscale converts the image to gray levels
+	Grayscale bool
+
+	// StretchContrast spreads the gray levels over the full range
+	StretchContrast bool
+
+	// MinSize scales up images whose width and height are both smaller, so the
+	// larger side reaches MinSize, by at most 4x (0 disables upscaling)
+	MinSize int
+
+	// Threshold binarizes the image to dark text on a white background
+	Threshold ImageThreshold
+}
+
+// DefaultImagePreprocessing returns a preprocessing configuration suited to
+// photographs and scans of documents
+func DefaultImagePreprocessing() ImagePreprocessing {
+	return ImagePreprocessing{
+		AutoOrient:      true,
+		CropBorders:     true,
+		Grayscale:       true,
+		StretchContrast: true,
+		MinSize:         1000,
+		Threshold:       ThresholdOtsu,
+	}
+}
+
+// Preprocessing parameters
+const (
+	// cropTolerance is the largest gray level difference within a row or column considered uniform
+	cropTolerance = 24
+
+	// cropPadding is how many pixels of a removed margin are kept around the content
+	cropPadding = 4
+
+	// contrastClip is the share of pixels at each end of the histogram ignored when stretching
+	contrastClip = 0.01
+
+	// maxUpscale bounds the upscaling factor
+	maxUpscale = 4.0
+
+	// adaptiveThresholdBias is how much darker than its neighbourhood mean a pixel must be to count as ink
+	adaptiveThresholdBias = 0.15
+)
+
+// apply runs the enabled steps on an image decoded from content and returns the
+// processed image with the names of the steps that changed it
+func (p ImagePreprocessing) apply(img image.Image, content []byte) (image.Image, []string) {
+	steps := []string{}
+
+	if p.AutoOrient {
+		if orientation := exifOrientation(content); orientation != 1 {
+			img = orientImage(img, orientation)
+			steps = append(steps, "exif_orientation")
+		}
+	}
+
+	if p.CropBorders {
+		if cropped, ok := cropBorders(img); ok {
+			img = cropped
+			steps = append(steps, "crop_borders")
+		}
+	}
+
+	if !p.Grayscale && !p.StretchContrast && p.MinSize <= 0 && p.Threshold == ThresholdNone {
+		return img, steps
+	}
+	gray, ok := img.(*image.Gray)
+	if !ok {
+		gray = image.NewGray(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+		draw.Draw(gray, gray.Bounds(), img, img.Bounds().Min, draw.Src)
+		steps = append(steps, "grayscale")
+	}
+
+	if p.StretchContrast && stretchContrast(gray) {
+		steps = append(steps, "contrast_stretch")
+	}
+
+	if p.MinSize > 0 {
+		if scaled, ok := upscale(gray, p.MinSize); ok {
+			gray = scaled
+			steps = append(steps, "upscale")
+		}
+	}
+
+	switch p.Threshold {
+	case ThresholdOtsu:
+		otsuBinarize(gray)
+		steps = append(steps, "otsu_threshold")
+	case ThresholdAdaptive:
+		adaptiveBinarize(gray)
+		steps = append(steps, "adaptive_threshold")
+	}
+
+	return gray, steps
+}
+
+// orientImage turns an image upright according to an EXIF orientation from 2 to 8
+func orientImage(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	outWidth, outHeight := width, height
+	if orientation >= 5 {
+		outWidth, outHeight = height, width
+	}
+
+	// source maps a pixel of the upright image to the stored image
+	source := func(x, y int) (int, int) {
+		switch orientation {
+		case 2: // mirrored
+			return width - 1 - x, y
+		case 3: // rotated 180°
+			return width - 1 - x, height - 1 - y
+		case 4: // mirrored vertically
+			return x, height - 1 - y
+		case 5: // transposed
+			return y, x
+		case 6: // needs rotating 90° clockwise
+			return y, height - 1 - x
+		case 7: // transversed
+			return width - 1 - y, height - 1 - x
+		case 8: // needs rotating 90° counter-clockwise
+			return width - 1 - y, x
+		}
+		return x, y
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			sx, sy := source(x, y)
+			out.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return out
+}
+
+// cropBorders removes rows and columns of uniform color at the edges of the
+// image, such as margins and scanner borders. A few pixels of margin are kept
+// where it matches the content background. It reports false if there is
+// nothing to remove or the whole image is uniform.
+func cropBorders(img image.Image) (image.Image, bool) {
+	bounds := img.Bounds()
+	level := func(x, y int) int {
+		return int(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+	}
+
+	// uniform returns the mean gray level of n pixels from (x0, y0) in steps
+	// of (dx, dy), or -1 if they are not of a uniform color
+	uniform := func(x0, y0, dx, dy, n int) int {
+		low, high, sum := 255, 0, 0
+		for i := 0; i < n; i++ {
+			value := level(x0+i*dx, y0+i*dy)
+			low, high, sum = min(low, value), max(high, value), sum+value
+			if high-low > cropTolerance {
+				return -1
+			}
+		}
+		return sum / max(n, 1)
+	}
+
+	// edge tracks the lines removed from one side of the image
+	type edge struct {
+		removed int
+
+		// level and run are the color and length of the innermost run of
+		// similar lines removed
+		level, run int
+	}
+	// trim removes uniform lines from one side; line returns the level of the
+	// i-th line inwards or -1
+	trim := func(e *edge, limit int, line func(i int) int) {
+		for e.removed < limit {
+			value := line(e.removed)
+			if value < 0 {
+				return
+			}
+			if e.run > 0 && abs(value-e.level) <= cropTolerance {
+				e.run++
+			} else {
+				e.run = 1
+			}
+			e.level = value
+			e.removed++
+		}
+	}
+
+	// A margin inside a border is only uniform once the border is removed,
+	// so trimming repeats until nothing changes. A pass that leaves nothing
+	// has reached uniformly colored content and is undone.
+	var top, bottom, left, right edge
+	for pass := 0; ; pass++ {
+		previous := [4]edge{top, bottom, left, right}
+		x0, width := bounds.Min.X+left.removed, bounds.Dx()-left.removed-right.removed
+		trim(&top, bounds.Dy()-bottom.removed, func(i int) int { return uniform(x0, bounds.Min.Y+i, 1, 0, width) })
+		trim(&bottom, bounds.Dy()-top.removed, func(i int) int { return uniform(x0, bounds.Max.Y-1-i, 1, 0, width) })
+
+		y0, height := bounds.Min.Y+top.removed, bounds.Dy()-top.removed-bottom.removed
+		if height > 0 {
+			trim(&left, bounds.Dx()-right.removed, func(i int) int { return uniform(bounds.Min.X+i, y0, 0, 1, height) })
+			trim(&right, bounds.Dx()-left.removed, func(i int) int { return uniform(bounds.Max.X-1-i, y0, 0, 1, height) })
+		}
+
+		if height <= 0 || left.removed+right.removed >= bounds.Dx() {
+			if pass == 0 {
+				return img, false
+			}
+			top, bottom, left, right = previous[0], previous[1], previous[2], previous[3]
+			break
+		}
+		if [4]edge{top, bottom, left, right} == previous {
+			break
+		}
+	}
+	crop := image.Rect(bounds.Min.X+left.removed, bounds.Min.Y+top.removed, bounds.Max.X-right.removed, bounds.Max.Y-bottom.removed)
+
+	// The most common level of the content is taken as its background
+	var histogram [256]int
+	for y := crop.Min.Y; y < crop.Max.Y; y++ {
+		for x := crop.Min.X; x < crop.Max.X; x++ {
+			histogram[level(x, y)]++
+		}
+	}
+	background := 0
+	for value, count := range histogram {
+		if count > histogram[background] {
+			background = value
+		}
+	}
+	padding := func(e edge) int {
+		if e.run == 0 || abs(e.level-background) > cropTolerance {
+			return 0
+		}
+		return min(e.run, cropPadding)
+	}
+	crop.Min.X -= padding(left)
+	crop.Min.Y -= padding(top)
+	crop.Max.X += padding(right)
+	crop.Max.Y += padding(bottom)
+	if crop == bounds {
+		return img, false
+	}
+
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(crop), true
+	}
+	out := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	draw.Draw(out, out.Bounds(), img, crop.Min, draw.Src)
+	return out, true
+}
+
+// grayHistogram counts the pixels of each gray level
+func grayHistogram(gray *image.Gray) [256]int {
+	var histogram [256]int
+	bounds := gray.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			histogram[gray.GrayAt(x, y).Y]++
+		}
+	}
+	return histogram
+}
+
+// mapGray replaces every gray level through a lookup table
+func mapGray(gray *image.Gray, table [256]uint8) {
+	bounds := gray.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := gray.Pix[gray.PixOffset(bounds.Min.X, y):gray.PixOffset(bounds.Max.X, y)]
+		for i, value := range row {
+			row[i] = table[value]
+		}
+	}
+}
+
+// stretchContrast maps the gray levels between the darkest and lightest
+// percentile to the full range and reports whether anything changed
+func stretchContrast(gray *image.Gray) bool {
+	histogram := grayHistogram(gray)
+	total := gray.Bounds().Dx() * gray.Bounds().Dy()
+	clip := int(float64(total) * contrastClip)
+
+	low, count := 0, 0
+	for ; low < 255; low++ {
+		if count += histogram[low]; count > clip {
+			break
+		}
+	}
+	high := 255
+	for count = 0; high > 0; high-- {
+		if count += histogram[high]; count > clip {
+			break
+		}
+	}
+	if high <= low || (low == 0 && high == 255) {
+		return false
+	}
+
+	var table [256]uint8
+	for value := range table {
+		scaled := (value - low) * 255 / (high - low)
+		table[value] = uint8(max(0, min(scaled, 255)))
+	}
+	mapGray(gray, table)
+	return true
+}
+
+// upscale enlarges a small image with bilinear interpolation so its larger side
+// reaches minSize, reporting false if the image is already large enough
+func upscale(gray *image.Gray, minSize int) (*image.Gray, bool) {
+	bounds := gray.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 || width >= minSize || height >= minSize {
+		return gray, false
+	}
+	scale := min(float64(minSize)/float64(max(width, height)), maxUpscale)
+	outWidth, outHeight := int(float64(width)*scale), int(float64(height)*scale)
+
+	out := image.NewGray(image.Rect(0, 0, outWidth, outHeight))
+	for y := 0; y < outHeight; y++ {
+		sy := max(0, (float64(y)+0.5)/scale-0.5)
+		y0 := min(int(sy), height-1)
+		y1 := min(y0+1, height-1)
+		fy := sy - float64(y0)
+		for x := 0; x < outWidth; x++ {
+			sx := max(0, (float64(x)+0.5)/scale-0.5)
+			x0 := min(int(sx), width-1)
+			x1 := min(x0+1, width-1)
+			fx := sx - float64(x0)
+
+			at := func(x, y int) float64 {
+				return float64(gray.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y)
+			}
+			top := at(x0, y0)*(1-fx) + at(x1, y0)*fx
+			bottom := at(x0, y1)*(1-fx) + at(x1, y1)*fx
+			out.Pix[y*out.Stride+x] = uint8(top*(1-fy) + bottom*fy + 0.5)
+		}
+	}
+	return out, true
+}
+
+// normalizePolarity inverts the image when light text is printed on a dark
+// background, taking the less common side of the Otsu threshold as the text
+func normalizePolarity(gray *image.Gray) int {
+	histogram := grayHistogram(gray)
+	total := gray.Bounds().Dx() * gray.Bounds().Dy()
+	threshold := otsuThreshold(histogram, total)
+
+	dark := 0
+	for value := 0; value <= threshold; value++ {
+		dark += histogram[value]
+	}
+	if dark*2 <= total {
+		return threshold
+	}
+
+	var table [256]uint8
+	for value := range table {
+		table[value] = uint8(255 - value)
+	}
+	mapGray(gray, table)
+	return 254 - threshold
+}
+
+// otsuBinarize turns the image into dark text on white using Otsu's threshold
+func otsuBinarize(gray *image.Gray) {
+	threshold := normalizePolarity(gray)
+	var table [256]uint8
+	for value := range table {
+		if value > threshold {
+			table[value] = 255
+		}
+	}
+	mapGray(gray, table)
+}
+
+// adaptiveBinarize turns the image into dark text on white, marking pixels
+// noticeably darker than the mean of their neighbourhood as ink
+func adaptiveBinarize(gray *image.Gray) {
+	normalizePolarity(gray)
+	bounds := gray.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Summed-area table of the gray levels, with a zero first row and column
+	integral := make([]int64, (width+1)*(height+1))
+	for y := 0; y < height; y++ {
+		var row int64
+		for x := 0; x < width; x++ {
+			row += int64(gray.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y)
+			integral[(y+1)*(width+1)+x+1] = integral[y*(width+1)+x+1] + row
+		}
+	}
+
+	radius := max(7, min(width, height)/16)
+	for y := 0; y < height; y++ {
+		y0, y1 := max(0, y-radius), min(height, y+radius+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := max(0, x-radius), min(width, x+radius+1)
+			sum := integral[y1*(width+1)+x1] - integral[y0*(width+1)+x1] - integral[y1*(width+1)+x0] + integral[y0*(width+1)+x0]
+			mean := float64(sum) / float64((x1-x0)*(y1-y0))
+
+			offset := gray.PixOffset(bounds.Min.X+x, bounds.Min.Y+y)
+			if float64(gray.Pix[offset]) < mean*(1-adaptiveThresholdBias) {
+				gray.Pix[offset] = 0
+			} else {
+				gray.Pix[offset] = 255
+			}
+		}
+	}
+}
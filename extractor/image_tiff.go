@@ -0,0 +1,78 @@
+package extractor
+
+import (
+	"image"
+	"io"
+
+	"golang.org/x/image/tiff"
+)
+
+// maxTIFFPages bounds the number of pages read from a TIFF file
+const maxTIFFPages = 10000
+
+// tiffPageOffsets returns the offsets of the image file directories of a
+// TIFF file, one per page, or nil if the content is not a TIFF file
+func tiffPageOffsets(content []byte) []uint32 {
+	block := parseEXIFHeader(content)
+	if block == nil {
+		return nil
+	}
+
+	var offsets []uint32
+	seen := make(map[uint32]bool)
+	offset := block.order.Uint32(content[4:])
+	for offset != 0 && !seen[offset] && len(offsets) < maxTIFFPages {
+		entries := block.ifd(offset)
+		if entries == nil {
+			break
+		}
+		seen[offset] = true
+		offsets = append(offsets, offset)
+
+		next := int(offset) + 2 + len(entries)*12
+		if next+4 > len(content) {
+			break
+		}
+		offset = block.order.Uint32(content[next:])
+	}
+	return offsets
+}
+
+// decodeTIFFPage decodes the page of a TIFF file whose directory is at the
+// given offset. The decoder only reads the first page, so it is given a view
+// of the file whose header points at the requested page instead.
+func decodeTIFFPage(content []byte, offset uint32) (image.Image, error) {
+	page := &tiffPage{content: content}
+	copy(page.header[:], content[:8])
+	parseEXIFHeader(content).order.PutUint32(page.header[4:], offset)
+	return tiff.Decode(page)
+}
+
+// tiffPage presents TIFF content with a replaced header
+type tiffPage struct {
+	content []byte
+	header  [8]byte
+
+	// position is the offset of the next Read
+	position int64
+}
+
+func (p *tiffPage) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 || off >= int64(len(p.content)) {
+		return 0, io.EOF
+	}
+	n := copy(b, p.content[off:])
+	for i := off; i < int64(len(p.header)) && i < off+int64(n); i++ {
+		b[i-off] = p.header[i]
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (p *tiffPage) Read(b []byte) (int, error) {
+	n, err := p.ReadAt(b, p.position)
+	p.position += int64(n)
+	return n, err
+}
@@ -0,0 +1,348 @@
+package extractor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"golang.org/x/net/html"
+	"gopkg.in/yaml.v3"
+)
+
+// MarkdownExtractor extracts the text of Markdown documents, parsed as
+// CommonMark with the GitHub Flavored Markdown extensions
+type MarkdownExtractor struct {
+	*PlainTextExtractor
+
+	// TableFormat selects how tables are rendered in the text; TSV when empty
+	TableFormat TableFormat
+}
+
+// NewMarkdownExtractor creates a new Markdown extractor
+func NewMarkdownExtractor() *MarkdownExtractor {
+	return &MarkdownExtractor{
+		PlainTextExtractor: NewPlainTextExtractor(),
+	}
+}
+
+// Extract extracts text from Markdown. Syntax is removed unless formatting is
+// preserved, in which case the source is returned as is. Front matter is
+// reported in the metadata rather than the text.
+func (e *MarkdownExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
+	start := time.Now()
+
+	result, err := e.PlainTextExtractor.Extract(reader, options)
+	if err != nil {
+		return nil, err
+	}
+
+	frontMatter, format, body := splitFrontMatter(result.Text)
+	if frontMatter != nil {
+		result.Metadata["front_matter"] = frontMatter
+		result.Metadata["front_matter_format"] = format
+		for _, key := range []string{"title", "author", "description", "date"} {
+			if value := frontMatterString(frontMatter[key]); value != "" {
+				result.Metadata[key] = value
+			}
+		}
+		for _, key := range []string{"tags", "keywords"} {
+			if values := frontMatterStrings(frontMatter[key]); len(values) > 0 {
+				result.Metadata["keywords"] = values
+				break
+			}
+		}
+	}
+
+	source := []byte(body)
+	document := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(source))
+	renderer := &markdownRenderer{source: source, tableFormat: e.TableFormat}
+	rendered := renderer.blocks(document, "\n\n")
+
+	if !options.PreserveFormatting {
+		result.Text = rendered
+		result.Metadata["line_count"] = strings.Count(result.Text, "\n") + 1
+		result.Metadata["char_count"] = len([]rune(result.Text))
+	}
+
+	result.Metadata["heading_count"] = renderer.headings
+	result.Metadata["link_count"] = renderer.links
+	result.Metadata["image_count"] = renderer.images
+	result.Metadata["code_block_count"] = renderer.codeBlocks
+	result.Metadata["table_count"] = renderer.tables
+	if _, ok := result.Metadata["title"]; !ok && renderer.title != "" {
+		result.Metadata["title"] = renderer.title
+	}
+
+	// Override the file type set by parent extractor
+	result.FileType = "markdown"
+	result.ProcessingTime = time.Since(start)
+
+	return result, nil
+}
+
+// ExtractFromFile extracts text from a Markdown file
+func (e *MarkdownExtractor) ExtractFromFile(filepath string, options ExtractOptions) (*ExtractResult, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, NewExtractorError("failed to open file", "markdown", "open", err)
+	}
+	defer file.Close()
+
+	return e.Extract(file, options)
+}
+
+// SupportedTypes returns Markdown-specific supported types
+func (e *MarkdownExtractor) SupportedTypes() []string {
+	return []string{"md", "markdown"}
+}
+
+// splitFrontMatter separates a YAML block fenced by "---" or a TOML block
+// fenced by "+++" from the start of a document. A block that does not parse
+// as a map of fields is not front matter; "---" also starts a thematic break.
+func splitFrontMatter(document string) (map[string]interface{}, string, string) {
+	first, rest, ok := strings.Cut(document, "\n")
+	if !ok {
+		return nil, "", document
+	}
+
+	var format, closing string
+	switch strings.TrimRight(first, " \t\r") {
+	case "---":
+		format, closing = "yaml", "---"
+	case "+++":
+		format, closing = "toml", "+++"
+	default:
+		return nil, "", document
+	}
+
+	var block []string
+	for len(rest) > 0 {
+		line, remaining, _ := strings.Cut(rest, "\n")
+		rest = remaining
+		trimmed := strings.TrimRight(line, " \t\r")
+		if trimmed == closing || (format == "yaml" && trimmed == "...") {
+			fields := make(map[string]interface{})
+			var err error
+			if format == "yaml" {
+				err = yaml.Unmarshal([]byte(strings.Join(block, "\n")), &fields)
+			} else {
+				_, err = toml.Decode(strings.Join(block, "\n"), &fields)
+			}
+			if err != nil || len(fields) == 0 {
+				return nil, "", document
+			}
+			return fields, format, rest
+		}
+		block = append(block, line)
+	}
+	return nil, "", document
+}
+
+// frontMatterString formats a scalar front matter value
+func frontMatterString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	case int, int64, float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// frontMatterStrings reads a list of strings, or a comma-separated string, from front matter
+func frontMatterStrings(value interface{}) []string {
+	var values []string
+	switch v := value.(type) {
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s := frontMatterString(item); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// markdownRenderer renders a Markdown syntax tree as plain text and counts
+// its elements
+type markdownRenderer struct {
+	source      []byte
+	tableFormat TableFormat
+
+	headings, links, images, codeBlocks, tables int
+
+	// title is the text of the first level 1 heading
+	title string
+}
+
+// blocks renders the block children of a node, skipping empty ones
+func (r *markdownRenderer) blocks(parent ast.Node, separator string) string {
+	var parts []string
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		if part := r.block(child); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, separator)
+}
+
+// block renders a block node
+func (r *markdownRenderer) block(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.Heading:
+		r.headings++
+		heading := r.inline(n)
+		if n.Level == 1 && r.title == "" {
+			r.title = strings.Join(strings.Fields(heading), " ")
+		}
+		return heading
+
+	case *ast.Paragraph, *ast.TextBlock:
+		return r.inline(n)
+
+	case *ast.Blockquote:
+		return r.blocks(n, "\n\n")
+
+	case *ast.List:
+		number := n.Start
+		var items []string
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			var parts []string
+			for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+				part := r.block(child)
+				if _, nested := child.(*ast.List); nested {
+					part = "  " + strings.ReplaceAll(part, "\n", "\n  ")
+				}
+				if part != "" {
+					parts = append(parts, part)
+				}
+			}
+			content := strings.Join(parts, "\n")
+			if n.IsOrdered() {
+				content = strconv.Itoa(number) + ". " + content
+				number++
+			}
+			items = append(items, content)
+		}
+		return strings.Join(items, "\n")
+
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		r.codeBlocks++
+		return strings.TrimRight(r.lines(n), "\n")
+
+	case *ast.HTMLBlock:
+		raw := r.lines(n)
+		if n.HasClosure() {
+			raw += string(n.ClosureLine.Value(r.source))
+		}
+		return htmlText(raw)
+
+	case *east.Table:
+		r.tables++
+		table := &Table{HasHeader: true}
+		for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+			var cells []string
+			for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+				cells = append(cells, r.inline(cell))
+			}
+			if _, header := row.(*east.TableHeader); header {
+				table.Columns = cells
+			} else {
+				table.Rows = append(table.Rows, cells)
+			}
+		}
+		return table.Render(r.tableFormat)
+
+	case *ast.ThematicBreak:
+		return ""
+
+	}
+
+	return r.blocks(node, "\n\n")
+}
+
+// lines concatenates the source lines of a block
+func (r *markdownRenderer) lines(node ast.Node) string {
+	var b strings.Builder
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		b.Write(segment.Value(r.source))
+	}
+	return b.String()
+}
+
+// inline renders the inline children of a node
+func (r *markdownRenderer) inline(parent ast.Node) string {
+	var b strings.Builder
+	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.Text:
+			value := n.Value(r.source)
+			if !n.IsRaw() {
+				value = util.ResolveEntityNames(util.ResolveNumericReferences(util.UnescapePunctuations(value)))
+			}
+			b.Write(value)
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte('\n')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		case *ast.Link:
+			r.links++
+			b.WriteString(r.inline(n))
+		case *ast.AutoLink:
+			r.links++
+			b.Write(n.Label(r.source))
+		case *ast.Image:
+			r.images++
+			b.WriteString(r.inline(n))
+		case *ast.RawHTML:
+			// Inline tags carry no text
+		case *east.TaskCheckBox:
+			if n.IsChecked {
+				b.WriteString("[x] ")
+			} else {
+				b.WriteString("[ ] ")
+			}
+		default:
+			b.WriteString(r.inline(n))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// htmlText returns the text content of an HTML fragment
+func htmlText(fragment string) string {
+	var b strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(b.String())
+		case html.TextToken:
+			b.Write(tokenizer.Text())
+		}
+	}
+}
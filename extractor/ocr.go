@@ -0,0 +1,46 @@
+package extractor
+
+import (
+	"image"
+	"time"
+)
+
+// OCREngine recognizes text in images. Implementations may wrap a local OCR
+// library, an external program or a service; tests can plug in a fake.
+type OCREngine interface {
+	// Recognize returns the text found in the image
+	Recognize(img image.Image, options OCROptions) (*OCRResult, error)
+}
+
+// OCROptions contains configuration for a single recognition
+type OCROptions struct {
+	// Language is the language of the text, as given in ExtractOptions.OCRLanguage
+	Language string
+
+	// Timeout bounds the time spent on the recognition, as given in
+	// ExtractOptions.Timeout (0 means no limit)
+	Timeout time.Duration
+}
+
+// OCRResult contains the text recognized in an image
+type OCRResult struct {
+	// Text is the recognized text, with lines separated by newlines
+	Text string
+
+	// Words holds the recognized words in reading order, if the engine reports them
+	Words []OCRWord
+
+	// Confidence is the engine's overall confidence, from 0 to 1
+	Confidence float64
+}
+
+// OCRWord is a single recognized word
+type OCRWord struct {
+	Text string
+
+	// Bounds is the word's bounding box in image pixel coordinates
+	Bounds image.Rectangle
+
+	// Confidence is the engine's confidence in the word, from 0 to 1
+	Confidence float64
+}
@@ -0,0 +1,557 @@
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ErrUnsupportedOCRLanguage indicates the OCR engine has no glyphs for the requested language
+var ErrUnsupportedOCRLanguage = errors.New("unsupported OCR language")
+
+// Built-in OCR parameters
+const (
+	// ocrMaxSkew is the largest skew angle, in degrees, that is corrected
+	ocrMaxSkew = 5.0
+
+	// ocrSkewStep is the resolution, in degrees, of the skew search
+	ocrSkewStep = 0.25
+
+	// ocrThinLine is the height, relative to the typical line, below which a
+	// band of ink is treated as part of a neighbouring line (accents, i-dots)
+	ocrThinLine = 0.4
+
+	// ocrWordGap is the gap between glyphs, relative to the em size, that
+	// separates words when letter and word spacing cannot be told apart
+	ocrWordGap = 0.25
+
+	// ocrMinWordGap is the narrowest gap, relative to the em size, taken as word spacing
+	ocrMinWordGap = 0.15
+
+	// ocrSkewSamples is the most ink pixels sampled when estimating skew
+	ocrSkewSamples = 200000
+
+	// ocrMaxPixels bounds the size of an image the built-in engine will process
+	ocrMaxPixels = 1 << 25
+
+	// ocrSpeckArea is the ink area, relative to the square of the typical
+	// glyph height, below which a component is noise such as a speck of dust
+	// or a dotted spell-check underline rather than part of a character
+	ocrSpeckArea = 0.005
+)
+
+// BuiltinOCREngine is a pure-Go OCR engine for clean, printed, high-contrast
+// text such as screenshots and rendered documents. It binarizes the image,
+// corrects small skew, segments lines, words and glyphs from connected
+// components and classifies each glyph against templates rendered from the
+// bundled Go fonts. Glyphs are compared by features normalized for size,
+// stroke weight and x-height, so text set in other fonts is read as well. It
+// does not handle handwriting, photographs or noisy scans.
+//
+// The language selects the glyph set: "eng" recognizes printable ASCII and
+// "digits" recognizes numbers with common separators and signs.
+type BuiltinOCREngine struct{}
+
+// NewBuiltinOCREngine creates a built-in OCR engine
+func NewBuiltinOCREngine() *BuiltinOCREngine {
+	return &BuiltinOCREngine{}
+}
+
+// BuiltinOCRLanguages returns the languages the built-in engine recognizes
+func BuiltinOCRLanguages() []string {
+	languages := make([]string, 0, len(ocrCharsets))
+	for language := range ocrCharsets {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Recognize returns the text found in the image
+func (e *BuiltinOCREngine) Recognize(img image.Image, options OCROptions) (*OCRResult, error) {
+	language := options.Language
+	if language == "" {
+		language = "eng"
+	}
+	templates, err := ocrTemplatesFor(language)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx()*bounds.Dy() > ocrMaxPixels {
+		return nil, fmt.Errorf("image too large for OCR: %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	bitmap := binarize(img).deskew()
+	bitmap.despeckle()
+
+	result := &OCRResult{}
+	var lineTexts []string
+	totalConfidence, glyphCount := 0.0, 0
+	for _, line := range bitmap.lines() {
+		chars, confidences, em := templates.readLine(bitmap, line)
+
+		var words []string
+		next := 0
+		for _, word := range line.words(em) {
+			text := string(chars[next : next+len(word)])
+			confidence := 0.0
+			box := word[0].box
+			for i, glyph := range word {
+				confidence += confidences[next+i]
+				box = box.Union(glyph.box)
+			}
+			next += len(word)
+
+			words = append(words, text)
+			totalConfidence += confidence
+			glyphCount += len(word)
+			result.Words = append(result.Words, OCRWord{
+				Text:       text,
+				Bounds:     box.Add(bitmap.origin),
+				Confidence: confidence / float64(len(word)),
+			})
+		}
+		lineTexts = append(lineTexts, strings.Join(words, " "))
+	}
+
+	result.Text = strings.Join(lineTexts, "\n")
+	if glyphCount > 0 {
+		result.Confidence = totalConfidence / float64(glyphCount)
+	}
+	return result, nil
+}
+
+// ocrBitmap is a binarized image where true marks ink
+type ocrBitmap struct {
+	width, height int
+	pix           []bool
+
+	// origin is the position of the bitmap's top-left pixel in the source image
+	origin image.Point
+
+	// labels holds the connected component of every ink pixel, or -1
+	labels []int
+
+	// components holds the bounding box of every connected component
+	components []image.Rectangle
+}
+
+// at reports whether the pixel is ink; pixels outside the bitmap are not
+func (b *ocrBitmap) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.width || y >= b.height {
+		return false
+	}
+	return b.pix[y*b.width+x]
+}
+
+// binarize converts an image to ink and background using Otsu's threshold.
+// The less common class is taken as ink, so light text on a dark background
+// is handled as well as dark text on a light one.
+func binarize(img image.Image) *ocrBitmap {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	gray := make([]uint8, width*height)
+	var histogram [256]int
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y
+			gray[y*width+x] = value
+			histogram[value]++
+		}
+	}
+
+	threshold := otsuThreshold(histogram, width*height)
+	dark := 0
+	for value := 0; value <= threshold; value++ {
+		dark += histogram[value]
+	}
+	inkIsDark := dark*2 <= width*height
+
+	bitmap := &ocrBitmap{width: width, height: height, pix: make([]bool, width*height), origin: bounds.Min}
+	for i, value := range gray {
+		bitmap.pix[i] = (int(value) <= threshold) == inkIsDark
+	}
+	return bitmap
+}
+
+// otsuThreshold returns the gray level that best separates the histogram into two classes
+func otsuThreshold(histogram [256]int, total int) int {
+	sum := 0.0
+	for value, count := range histogram {
+		sum += float64(value * count)
+	}
+
+	best, bestVariance := 127, -1.0
+	sumBelow, countBelow := 0.0, 0
+	for value, count := range histogram {
+		countBelow += count
+		if countBelow == 0 {
+			continue
+		}
+		countAbove := total - countBelow
+		if countAbove == 0 {
+			break
+		}
+		sumBelow += float64(value * count)
+		meanBelow := sumBelow / float64(countBelow)
+		meanAbove := (sum - sumBelow) / float64(countAbove)
+		variance := float64(countBelow) * float64(countAbove) * (meanBelow - meanAbove) * (meanBelow - meanAbove)
+		if variance > bestVariance {
+			best, bestVariance = value, variance
+		}
+	}
+	return best
+}
+
+// deskew estimates the angle of the text lines from the projection profile
+// and shears the bitmap so lines run horizontally
+func (b *ocrBitmap) deskew() *ocrBitmap {
+	// Sample ink pixels so large images stay cheap to score
+	ink := 0
+	for _, pixel := range b.pix {
+		if pixel {
+			ink++
+		}
+	}
+	step := ink/ocrSkewSamples + 1
+	points := make([]image.Point, 0, ink/step+1)
+	seen := 0
+	for i, pixel := range b.pix {
+		if pixel {
+			if seen%step == 0 {
+				points = append(points, image.Pt(i%b.width, i/b.width))
+			}
+			seen++
+		}
+	}
+
+	best, bestScore := 0.0, profileScore(points, 0)
+	for angle := ocrSkewStep; angle <= ocrMaxSkew; angle += ocrSkewStep {
+		for _, candidate := range []float64{angle, -angle} {
+			if score := profileScore(points, candidate); score > bestScore*1.01 {
+				best, bestScore = candidate, score
+			}
+		}
+	}
+	if best == 0 {
+		return b
+	}
+
+	// Shift every column vertically by its offset along the skewed line
+	slope := math.Tan(best * math.Pi / 180)
+	center := float64(b.width) / 2
+	shift := int(math.Ceil(math.Abs(slope) * center))
+	sheared := &ocrBitmap{width: b.width, height: b.height + 2*shift, origin: b.origin.Sub(image.Pt(0, shift))}
+	sheared.pix = make([]bool, sheared.width*sheared.height)
+	for x := 0; x < b.width; x++ {
+		offset := shift - int(math.Round((float64(x)-center)*slope))
+		for y := 0; y < b.height; y++ {
+			if b.pix[y*b.width+x] {
+				sheared.pix[(y+offset)*sheared.width+x] = true
+			}
+		}
+	}
+	return sheared
+}
+
+// profileScore measures how sharply ink concentrates in rows when lines are
+// assumed to slope by the given angle; the true skew gives the highest score
+func profileScore(points []image.Point, angle float64) float64 {
+	slope := math.Tan(angle * math.Pi / 180)
+	rows := make(map[int]float64)
+	for _, point := range points {
+		rows[point.Y-int(math.Round(float64(point.X)*slope))]++
+	}
+	score := 0.0
+	for _, count := range rows {
+		score += count * count
+	}
+	return score
+}
+
+// label finds the 8-connected components of ink
+func (b *ocrBitmap) label() {
+	b.labels = make([]int, len(b.pix))
+	for i := range b.labels {
+		b.labels[i] = -1
+	}
+	b.components = nil
+
+	var stack []int
+	for start, ink := range b.pix {
+		if !ink || b.labels[start] >= 0 {
+			continue
+		}
+		id := len(b.components)
+		box := image.Rect(start%b.width, start/b.width, start%b.width+1, start/b.width+1)
+		b.labels[start] = id
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			index := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := index%b.width, index/b.width
+			box = box.Union(image.Rect(x, y, x+1, y+1))
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if !b.at(nx, ny) {
+						continue
+					}
+					neighbor := ny*b.width + nx
+					if b.labels[neighbor] < 0 {
+						b.labels[neighbor] = id
+						stack = append(stack, neighbor)
+					}
+				}
+			}
+		}
+		b.components = append(b.components, box)
+	}
+}
+
+// despeckle removes components too small to be part of a character. The
+// typical glyph height is the height of the component holding the median ink
+// pixel, so many specks cannot outweigh a few glyphs.
+func (b *ocrBitmap) despeckle() {
+	b.label()
+	areas := make([]int, len(b.components))
+	total := 0
+	for _, id := range b.labels {
+		if id >= 0 {
+			areas[id]++
+			total++
+		}
+	}
+
+	order := make([]int, len(b.components))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return b.components[order[i]].Dy() < b.components[order[j]].Dy()
+	})
+	typical, counted := 0, 0
+	for _, id := range order {
+		counted += areas[id]
+		if counted*2 >= total {
+			typical = b.components[id].Dy()
+			break
+		}
+	}
+
+	minArea := ocrSpeckArea * float64(typical*typical)
+	removed := false
+	for i, id := range b.labels {
+		if id >= 0 && float64(areas[id]) < minArea {
+			b.pix[i] = false
+			removed = true
+		}
+	}
+	if removed {
+		b.label()
+	}
+}
+
+// ocrGlyph is one character: one or more connected components stacked vertically
+type ocrGlyph struct {
+	box        image.Rectangle
+	components []int
+
+	// coreMinX and coreMaxX bound the glyph's ink above the baseline,
+	// ignoring descenders such as the hook of "j" that reach under a neighbour
+	coreMinX, coreMaxX int
+}
+
+// ocrLine is a row of glyphs with its baseline and reference height
+type ocrLine struct {
+	glyphs []ocrGlyph
+
+	// baseline is the row most glyphs rest on
+	baseline int
+
+	// height is the height of the tallest glyph resting on the baseline
+	height int
+}
+
+// lines segments the bitmap into text lines from top to bottom
+func (b *ocrBitmap) lines() []*ocrLine {
+	if b.labels == nil {
+		b.label()
+	}
+
+	// Bands of consecutive rows containing ink
+	type band struct{ top, bottom int }
+	var bands []band
+	inBand := false
+	for y := 0; y < b.height; y++ {
+		ink := false
+		for x := 0; x < b.width && !ink; x++ {
+			ink = b.pix[y*b.width+x]
+		}
+		switch {
+		case ink && !inBand:
+			bands = append(bands, band{top: y, bottom: y + 1})
+			inBand = true
+		case ink:
+			bands[len(bands)-1].bottom = y + 1
+		default:
+			inBand = false
+		}
+	}
+	if len(bands) == 0 {
+		return nil
+	}
+
+	// Thin bands hold accents or dots separated from their line by a gap
+	heights := make([]float64, len(bands))
+	for i, band := range bands {
+		heights[i] = float64(band.bottom - band.top)
+	}
+	typical := median(heights)
+	var merged []band
+	for i := 0; i < len(bands); i++ {
+		current := bands[i]
+		if float64(current.bottom-current.top) < ocrThinLine*typical && i+1 < len(bands) &&
+			(len(merged) == 0 || bands[i+1].top-current.bottom <= current.top-merged[len(merged)-1].bottom) {
+			bands[i+1].top = current.top
+			continue
+		}
+		if float64(current.bottom-current.top) < ocrThinLine*typical && len(merged) > 0 {
+			merged[len(merged)-1].bottom = current.bottom
+			continue
+		}
+		merged = append(merged, current)
+	}
+
+	// Assign components to the band containing their vertical center
+	lines := make([]*ocrLine, len(merged))
+	members := make([][]int, len(merged))
+	for id, box := range b.components {
+		center := (box.Min.Y + box.Max.Y) / 2
+		index := sort.Search(len(merged), func(i int) bool { return merged[i].bottom > center })
+		if index == len(merged) {
+			index--
+		}
+		members[index] = append(members[index], id)
+	}
+
+	for i := range merged {
+		lines[i] = b.buildLine(members[i])
+	}
+
+	var nonEmpty []*ocrLine
+	for _, line := range lines {
+		if len(line.glyphs) > 0 {
+			nonEmpty = append(nonEmpty, line)
+		}
+	}
+	return nonEmpty
+}
+
+// buildLine groups a line's components into glyphs, joining components that
+// overlap horizontally such as the dot and stem of "i", and measures the line
+func (b *ocrBitmap) buildLine(ids []int) *ocrLine {
+	sort.Slice(ids, func(i, j int) bool {
+		return b.components[ids[i]].Min.X < b.components[ids[j]].Min.X
+	})
+
+	line := &ocrLine{}
+	for _, id := range ids {
+		box := b.components[id]
+		if n := len(line.glyphs); n > 0 {
+			last := &line.glyphs[n-1]
+			overlap := min(last.box.Max.X, box.Max.X) - max(last.box.Min.X, box.Min.X)
+			narrower := min(last.box.Dx(), box.Dx())
+			if overlap*2 >= narrower {
+				last.box = last.box.Union(box)
+				last.components = append(last.components, id)
+				continue
+			}
+		}
+		line.glyphs = append(line.glyphs, ocrGlyph{box: box, components: []int{id}})
+	}
+	if len(line.glyphs) == 0 {
+		return line
+	}
+
+	bottoms := make([]float64, len(line.glyphs))
+	for i, glyph := range line.glyphs {
+		bottoms[i] = float64(glyph.box.Max.Y)
+	}
+	line.baseline = int(median(bottoms))
+	for _, glyph := range line.glyphs {
+		if abs(glyph.box.Max.Y-line.baseline) <= 1 && glyph.box.Dy() > line.height {
+			line.height = glyph.box.Dy()
+		}
+	}
+	if line.height == 0 {
+		line.height = line.glyphs[0].box.Dy()
+	}
+
+	for i := range line.glyphs {
+		glyph := &line.glyphs[i]
+		glyph.coreMinX, glyph.coreMaxX = glyph.box.Min.X, glyph.box.Max.X
+		members := make(map[int]bool, len(glyph.components))
+		for _, id := range glyph.components {
+			members[id] = true
+		}
+		minX, maxX := glyph.box.Max.X, glyph.box.Min.X
+		for y := glyph.box.Min.Y; y < min(glyph.box.Max.Y, line.baseline); y++ {
+			for x := glyph.box.Min.X; x < glyph.box.Max.X; x++ {
+				if b.at(x, y) && members[b.labels[y*b.width+x]] {
+					minX, maxX = min(minX, x), max(maxX, x+1)
+				}
+			}
+		}
+		if minX < maxX {
+			glyph.coreMinX, glyph.coreMaxX = minX, maxX
+		}
+	}
+	return line
+}
+
+// words splits the line's glyphs into words. Gaps between glyphs usually fall
+// into two groups, letter spacing and word spacing, and the widest jump
+// between the sorted gaps separates them. Without a clear jump, gaps wider
+// than a fixed fraction of the em size separate words.
+func (l *ocrLine) words(em float64) [][]ocrGlyph {
+	gaps := make([]float64, 0, len(l.glyphs))
+	for i := 1; i < len(l.glyphs); i++ {
+		gaps = append(gaps, float64(l.glyphs[i].coreMinX-l.glyphs[i-1].coreMaxX))
+	}
+
+	threshold := math.Max(2, ocrWordGap*em)
+	sorted := append([]float64(nil), gaps...)
+	sort.Float64s(sorted)
+	widest := 0.0
+	for i := 1; i < len(sorted); i++ {
+		lower, upper := sorted[i-1], sorted[i]
+		if upper-lower > widest && upper >= ocrMinWordGap*em && upper >= 1.8*lower+1 {
+			widest = upper - lower
+			threshold = (lower + upper) / 2
+		}
+	}
+
+	var words [][]ocrGlyph
+	for i, glyph := range l.glyphs {
+		if i == 0 || gaps[i-1] > threshold {
+			words = append(words, nil)
+		}
+		words[len(words)-1] = append(words[len(words)-1], glyph)
+	}
+	return words
+}
+
+// abs returns the absolute value of an integer
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
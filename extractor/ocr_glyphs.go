@@ -0,0 +1,480 @@
+package extractor
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Glyph classification parameters
+const (
+	// ocrGridWidth and ocrGridHeight are the size of the normalized glyph shape
+	ocrGridWidth  = 12
+	ocrGridHeight = 16
+
+	// ocrTemplateSize is the pixel size templates are rendered at
+	ocrTemplateSize = 48
+
+	// ocrAspectWeight and ocrPositionWeight scale the size and position terms
+	// of the glyph distance relative to the shape term
+	ocrAspectWeight   = 0.1
+	ocrPositionWeight = 1.0
+
+	// ocrTopologyWeight is added to the glyph distance for every difference
+	// in the number of parts or holes
+	ocrTopologyWeight = 0.2
+
+	// ocrMaxDistance is the glyph distance at which confidence reaches zero
+	ocrMaxDistance = 0.3
+
+	// ocrMinXHeight and ocrMaxXHeight bound the tops, relative to the
+	// reference height, of glyphs taken to show a line's x-height
+	ocrMinXHeight = 0.45
+	ocrMaxXHeight = 0.85
+)
+
+// ocrCharsets lists the characters each built-in language recognizes
+var ocrCharsets = map[string]string{
+	"eng":    printableASCII(),
+	"digits": "0123456789.,:-+%$/()",
+}
+
+// printableASCII returns the visible ASCII characters
+func printableASCII() string {
+	chars := make([]byte, 0, 94)
+	for c := byte('!'); c <= '~'; c++ {
+		chars = append(chars, c)
+	}
+	return string(chars)
+}
+
+// ocrTemplate is the normalized appearance of a character in one font
+type ocrTemplate struct {
+	char rune
+	glyphFeatures
+}
+
+// glyphFeatures describes a glyph independently of its size, stroke weight
+// and the proportions of its font
+type glyphFeatures struct {
+	// shape holds the ink coverage of each cell of the glyph's box scaled to
+	// the grid, normalized to zero mean and unit variance so that light and
+	// heavy strokes of the same form compare as equal
+	shape [ocrGridWidth * ocrGridHeight]float64
+
+	// aspect is the logarithm of the box's height over its width
+	aspect float64
+
+	// top and bottom are the heights of the box's edges above the baseline.
+	// measureGlyph returns them relative to the reference height; before
+	// glyphs are compared they are placed on a scale where the baseline is 0,
+	// the x-height 1 and the reference height 2, so fonts with different
+	// x-heights line up.
+	top, bottom float64
+
+	// parts and holes count the glyph's separate pieces of ink, such as the
+	// dot of "i", and the background regions it encloses, such as in "o" or "8"
+	parts, holes int
+}
+
+// ocrTemplateSet holds the templates of one language
+type ocrTemplateSet struct {
+	templates []ocrTemplate
+
+	// xHeight is the height of lowercase letters and em the font size, both
+	// relative to the reference height, averaged over the template fonts
+	xHeight, em float64
+}
+
+var (
+	ocrTemplateMu    sync.Mutex
+	ocrTemplateCache = make(map[string]*ocrTemplateSet)
+)
+
+// ocrTemplatesFor returns the templates of a language, rendering them on first use
+func ocrTemplatesFor(language string) (*ocrTemplateSet, error) {
+	charset, ok := ocrCharsets[language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedOCRLanguage, language)
+	}
+
+	ocrTemplateMu.Lock()
+	defer ocrTemplateMu.Unlock()
+	if set, ok := ocrTemplateCache[language]; ok {
+		return set, nil
+	}
+
+	faces, err := ocrTemplateFaces()
+	if err != nil {
+		return nil, err
+	}
+	set := &ocrTemplateSet{}
+	for _, face := range faces {
+		templates, xHeight, em := renderTemplates(face, charset)
+		set.templates = append(set.templates, templates...)
+		set.xHeight += xHeight / float64(len(faces))
+		set.em += em / float64(len(faces))
+	}
+	ocrTemplateCache[language] = set
+	return set, nil
+}
+
+// ocrTemplateFaces returns the bundled fonts templates are rendered from:
+// sans-serif regular and bold, monospace and a small bitmap font
+func ocrTemplateFaces() ([]font.Face, error) {
+	faces := []font.Face{basicfont.Face7x13}
+	for _, data := range [][]byte{goregular.TTF, gobold.TTF, gomono.TTF} {
+		parsed, err := opentype.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template font: %w", err)
+		}
+		face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: ocrTemplateSize, DPI: 72, Hinting: font.HintingNone})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load template font: %w", err)
+		}
+		faces = append(faces, face)
+	}
+	return faces, nil
+}
+
+// renderTemplates draws every character of the charset and measures it. The
+// reference height is the tallest letter or digit resting on the baseline,
+// matching how lines are measured in recognized images. It also returns the
+// font's x-height and em size relative to the reference height.
+func renderTemplates(face font.Face, charset string) ([]ocrTemplate, float64, float64) {
+	size := face.Metrics().Height.Ceil()
+	canvas := image.NewGray(image.Rect(0, 0, 3*size, 3*size))
+	baseline := 2 * size
+
+	// render draws a character and returns its ink and bounding box
+	render := func(char rune) (*ocrBitmap, image.Rectangle) {
+		draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+		drawer := font.Drawer{Dst: canvas, Src: image.Black, Face: face, Dot: fixed.P(size, baseline)}
+		drawer.DrawString(string(char))
+
+		ink := binarize(canvas)
+		box := image.Rectangle{}
+		for y := 0; y < ink.height; y++ {
+			for x := 0; x < ink.width; x++ {
+				if ink.pix[y*ink.width+x] {
+					box = box.Union(image.Rect(x, y, x+1, y+1))
+				}
+			}
+		}
+		return ink, box
+	}
+
+	type rendered struct {
+		char rune
+		box  image.Rectangle
+		ink  *ocrBitmap
+	}
+	var glyphs []rendered
+	reference := 0
+	for _, char := range charset {
+		ink, box := render(char)
+		if box.Empty() {
+			continue
+		}
+		glyphs = append(glyphs, rendered{char: char, box: box, ink: ink})
+
+		isAlphanumeric := char >= '0' && char <= '9' || char >= 'A' && char <= 'Z' || char >= 'a' && char <= 'z'
+		if isAlphanumeric && abs(box.Max.Y-baseline) <= 1 && box.Dy() > reference {
+			reference = box.Dy()
+		}
+	}
+	if reference == 0 {
+		reference = size
+	}
+
+	// The x-height is measured from the ink of "x" even when the charset
+	// lacks it, as the bounds of bitmap fonts cover the whole cell
+	xHeight := reference
+	if _, box := render('x'); !box.Empty() {
+		xHeight = baseline - box.Min.Y
+	}
+	em := float64(face.Metrics().Ascent + face.Metrics().Descent)
+
+	templates := make([]ocrTemplate, 0, len(glyphs)+1)
+	for _, glyph := range glyphs {
+		features := measureGlyph(glyph.box, baseline, reference, func(x, y int) bool {
+			return glyph.ink.at(x, y)
+		})
+		templates = append(templates, ocrTemplate{
+			char:          glyph.char,
+			glyphFeatures: features.placed(1, float64(xHeight)/float64(reference)),
+		})
+	}
+
+	// Sans-serif fonts draw "l" as a plain stroke where the template fonts
+	// give it a tail or serifs, so a solid bar as wide as "|" and as tall as
+	// "l" stands in for it
+	boxes := make(map[rune]image.Rectangle, len(glyphs))
+	for _, glyph := range glyphs {
+		boxes[glyph.char] = glyph.box
+	}
+	if ell, ok := boxes['l']; ok {
+		if bar, ok := boxes['|']; ok {
+			stroke := image.Rect(bar.Min.X, ell.Min.Y, bar.Max.X, baseline)
+			features := measureGlyph(stroke, baseline, reference, func(x, y int) bool {
+				return image.Pt(x, y).In(stroke)
+			})
+			templates = append(templates, ocrTemplate{
+				char:          'l',
+				glyphFeatures: features.placed(1, float64(xHeight)/float64(reference)),
+			})
+		}
+	}
+	return templates, float64(xHeight) / float64(reference), em / 64 / float64(reference)
+}
+
+// measureGlyph computes the features of the glyph in box; ink reports whether
+// a pixel belongs to the glyph
+func measureGlyph(box image.Rectangle, baseline, reference int, ink func(x, y int) bool) glyphFeatures {
+	var features glyphFeatures
+	width, height := float64(box.Dx()), float64(box.Dy())
+
+	// Sample each cell at 3x3 points so boxes smaller than the grid scale up too
+	for row := 0; row < ocrGridHeight; row++ {
+		for col := 0; col < ocrGridWidth; col++ {
+			hits := 0
+			for sy := 0; sy < 3; sy++ {
+				for sx := 0; sx < 3; sx++ {
+					x := box.Min.X + int((float64(col)+(float64(sx)+0.5)/3)*width/ocrGridWidth)
+					y := box.Min.Y + int((float64(row)+(float64(sy)+0.5)/3)*height/ocrGridHeight)
+					if ink(x, y) {
+						hits++
+					}
+				}
+			}
+			features.shape[row*ocrGridWidth+col] = float64(hits) / 9
+		}
+	}
+	features.shape = normalizeShape(blurShape(features.shape))
+
+	features.parts, features.holes = glyphTopology(box, ink)
+	features.aspect = math.Log(height / width)
+	features.top = float64(baseline-box.Min.Y) / float64(reference)
+	features.bottom = float64(baseline-box.Max.Y) / float64(reference)
+	return features
+}
+
+// placed returns the features with the top and bottom moved onto the
+// x-height scale, after multiplying them by scale. xHeight is the x-height
+// relative to the scaled reference height.
+func (f glyphFeatures) placed(scale, xHeight float64) glyphFeatures {
+	position := func(height float64) float64 {
+		height *= scale
+		if height <= xHeight {
+			return height / xHeight
+		}
+		return 1 + (height-xHeight)/(1-xHeight)
+	}
+	f.top, f.bottom = position(f.top), position(f.bottom)
+	return f
+}
+
+// normalizeShape shifts and scales the shape grid to zero mean and unit
+// variance. A grid without contrast, such as a solid bar, becomes all zeros.
+func normalizeShape(shape [ocrGridWidth * ocrGridHeight]float64) [ocrGridWidth * ocrGridHeight]float64 {
+	mean := 0.0
+	for _, value := range shape {
+		mean += value
+	}
+	mean /= float64(len(shape))
+
+	variance := 0.0
+	for _, value := range shape {
+		variance += (value - mean) * (value - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(shape)))
+
+	var normalized [ocrGridWidth * ocrGridHeight]float64
+	if deviation < 1e-9 {
+		return normalized
+	}
+	for i, value := range shape {
+		normalized[i] = (value - mean) / deviation
+	}
+	return normalized
+}
+
+// blurShape smooths the shape grid with a 3x3 box filter, so small
+// differences in stroke position between fonts weigh less than differences in form
+func blurShape(shape [ocrGridWidth * ocrGridHeight]float64) [ocrGridWidth * ocrGridHeight]float64 {
+	var blurred [ocrGridWidth * ocrGridHeight]float64
+	for row := 0; row < ocrGridHeight; row++ {
+		for col := 0; col < ocrGridWidth; col++ {
+			sum, count := 0.0, 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					r, c := row+dy, col+dx
+					if r >= 0 && r < ocrGridHeight && c >= 0 && c < ocrGridWidth {
+						sum += shape[r*ocrGridWidth+c]
+						count++
+					}
+				}
+			}
+			blurred[row*ocrGridWidth+col] = sum / float64(count)
+		}
+	}
+	return blurred
+}
+
+// glyphTopology counts the 8-connected pieces of ink in the box and the
+// 4-connected background regions that do not reach the edge of the box
+func glyphTopology(box image.Rectangle, ink func(x, y int) bool) (parts, holes int) {
+	width, height := box.Dx(), box.Dy()
+	visited := make([]bool, width*height)
+
+	fill := func(start int, isInk bool, neighbors [][2]int) bool {
+		touchesEdge := false
+		stack := []int{start}
+		visited[start] = true
+		for len(stack) > 0 {
+			index := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := index%width, index/width
+			if x == 0 || y == 0 || x == width-1 || y == height-1 {
+				touchesEdge = true
+			}
+			for _, offset := range neighbors {
+				nx, ny := x+offset[0], y+offset[1]
+				if nx < 0 || ny < 0 || nx >= width || ny >= height {
+					continue
+				}
+				neighbor := ny*width + nx
+				if !visited[neighbor] && ink(box.Min.X+nx, box.Min.Y+ny) == isInk {
+					visited[neighbor] = true
+					stack = append(stack, neighbor)
+				}
+			}
+		}
+		return touchesEdge
+	}
+
+	eight := [][2]int{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}}
+	four := [][2]int{{0, -1}, {-1, 0}, {1, 0}, {0, 1}}
+	for index := range visited {
+		if visited[index] {
+			continue
+		}
+		if ink(box.Min.X+index%width, box.Min.Y+index/width) {
+			fill(index, true, eight)
+			parts++
+		} else if !fill(index, false, four) {
+			holes++
+		}
+	}
+	return parts, holes
+}
+
+// distance compares two glyphs; 0 means identical
+func (f *glyphFeatures) distance(other *glyphFeatures) float64 {
+	shape := 0.0
+	for i := range f.shape {
+		d := f.shape[i] - other.shape[i]
+		shape += d * d
+	}
+	// For normalized grids this is one minus their correlation
+	shape /= 2 * float64(len(f.shape))
+
+	aspect := f.aspect - other.aspect
+	top := f.top - other.top
+	bottom := f.bottom - other.bottom
+	topology := abs(f.parts-other.parts) + abs(f.holes-other.holes)
+	return shape + ocrAspectWeight*aspect*aspect + ocrPositionWeight*(top*top+bottom*bottom) +
+		ocrTopologyWeight*float64(topology)
+}
+
+// readLine classifies every glyph of a line, returning the characters, their
+// confidences in glyph order and the line's em size in pixels. A line's
+// reference height is its tallest glyph resting on the baseline, which is
+// the ascender height unless the line has only lowercase letters without
+// ascenders. Its x-height is either measured from the line or, for lines of
+// capitals and digits, the templates' average. All readings are tried and
+// the closest match wins.
+func (t *ocrTemplateSet) readLine(b *ocrBitmap, line *ocrLine) ([]rune, []float64, float64) {
+	features := make([]glyphFeatures, len(line.glyphs))
+	for i, glyph := range line.glyphs {
+		members := make(map[int]bool, len(glyph.components))
+		for _, id := range glyph.components {
+			members[id] = true
+		}
+		features[i] = measureGlyph(glyph.box, line.baseline, line.height, func(x, y int) bool {
+			return b.at(x, y) && members[b.labels[y*b.width+x]]
+		})
+	}
+
+	chars, distances, total := t.classify(features, 1, t.xHeight)
+	reference := float64(line.height)
+	if xHeight, ok := lineXHeight(features); ok {
+		ownChars, ownDistances, ownTotal := t.classify(features, 1, xHeight)
+		if ownTotal < total {
+			chars, distances, total = ownChars, ownDistances, ownTotal
+		}
+	}
+	if t.xHeight > 0 && t.xHeight < 0.9 {
+		lowChars, lowDistances, lowTotal := t.classify(features, t.xHeight, t.xHeight)
+		if lowTotal < total {
+			chars, distances = lowChars, lowDistances
+			reference /= t.xHeight
+		}
+	}
+
+	confidences := make([]float64, len(distances))
+	for i, d := range distances {
+		confidences[i] = math.Max(0, 1-d/ocrMaxDistance)
+	}
+	return chars, confidences, reference * t.em
+}
+
+// lineXHeight estimates a line's x-height relative to its reference height
+// as the median top of the glyphs that end well below the reference height,
+// which are mostly lowercase letters. It reports false for lines without
+// such glyphs.
+func lineXHeight(features []glyphFeatures) (float64, bool) {
+	var tops []float64
+	for _, glyph := range features {
+		if glyph.top >= ocrMinXHeight && glyph.top <= ocrMaxXHeight {
+			tops = append(tops, glyph.top)
+		}
+	}
+	if len(tops) == 0 {
+		return 0, false
+	}
+	return median(tops), true
+}
+
+// classify finds the nearest template for every glyph after placing the
+// glyphs' vertical positions on the x-height scale, returning the
+// characters, their distances and the total distance
+func (t *ocrTemplateSet) classify(features []glyphFeatures, scale, xHeight float64) ([]rune, []float64, float64) {
+	chars := make([]rune, len(features))
+	distances := make([]float64, len(features))
+	total := 0.0
+	for i := range features {
+		glyph := features[i].placed(scale, xHeight)
+
+		best, bestDistance := '?', math.Inf(1)
+		for j := range t.templates {
+			if d := glyph.distance(&t.templates[j].glyphFeatures); d < bestDistance {
+				best, bestDistance = t.templates[j].char, d
+			}
+		}
+		chars[i] = best
+		distances[i] = bestDistance
+		total += bestDistance
+	}
+	return chars, distances, total
+}
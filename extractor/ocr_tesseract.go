@@ -0,0 +1,326 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrTesseractNotFound indicates no tesseract binary was found on the PATH
+var ErrTesseractNotFound = errors.New("tesseract not found")
+
+// TesseractFormat selects the output format requested from tesseract
+type TesseractFormat string
+
+const (
+	// TesseractTSV requests tab-separated word boxes
+	TesseractTSV TesseractFormat = "tsv"
+
+	// TesseractHOCR requests hOCR markup
+	TesseractHOCR TesseractFormat = "hocr"
+)
+
+// tesseractWaitDelay is how long to wait for the output of a killed tesseract
+// process to close, in case it left children holding it open
+const tesseractWaitDelay = time.Second
+
+// TesseractOCREngine recognizes text by running the tesseract command-line
+// program. The image is written to its standard input as PNG and the words
+// are read back, with their boxes and confidences, from TSV or hOCR output.
+type TesseractOCREngine struct {
+	// Path is the tesseract executable
+	Path string
+
+	// PageSegMode is passed as --psm; 0 keeps tesseract's default
+	// (fully automatic page segmentation)
+	PageSegMode int
+
+	// Format is the output format requested from tesseract (default: TSV)
+	Format TesseractFormat
+}
+
+// NewTesseractOCREngine creates an engine that runs the given tesseract executable
+func NewTesseractOCREngine(path string) *TesseractOCREngine {
+	return &TesseractOCREngine{
+		Path:   path,
+		Format: TesseractTSV,
+	}
+}
+
+// FindTesseract creates an engine for the tesseract binary on the PATH
+func FindTesseract() (*TesseractOCREngine, error) {
+	path, err := exec.LookPath("tesseract")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTesseractNotFound, err)
+	}
+	return NewTesseractOCREngine(path), nil
+}
+
+// Recognize runs tesseract on the image, killing it if it outlives options.Timeout
+func (e *TesseractOCREngine) Recognize(img image.Image, options OCROptions) (*OCRResult, error) {
+	var input bytes.Buffer
+	if err := png.Encode(&input, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	language := options.Language
+	if language == "" {
+		language = "eng"
+	}
+	format := e.Format
+	if format == "" {
+		format = TesseractTSV
+	}
+	if format != TesseractTSV && format != TesseractHOCR {
+		return nil, fmt.Errorf("unsupported tesseract output format %q", format)
+	}
+
+	args := []string{"stdin", "stdout", "-l", language}
+	if e.PageSegMode > 0 {
+		args = append(args, "--psm", strconv.Itoa(e.PageSegMode))
+	}
+	args = append(args, string(format))
+
+	ctx := context.Background()
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Path, args...)
+	cmd.Stdin = &input
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = tesseractWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("tesseract timed out after %v: %w", options.Timeout, context.DeadlineExceeded)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("tesseract failed: %w: %s", err, message)
+		}
+		return nil, fmt.Errorf("tesseract failed: %w", err)
+	}
+
+	var words []tesseractWord
+	var err error
+	if format == TesseractHOCR {
+		words, err = parseHOCR(&stdout)
+	} else {
+		words, err = parseTesseractTSV(&stdout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tesseract output: %w", err)
+	}
+	return tesseractResult(words), nil
+}
+
+// tesseractWord is a recognized word with its position in the page layout
+type tesseractWord struct {
+	OCRWord
+	block, paragraph, line int
+}
+
+// tesseractResult joins words into lines, separating paragraphs with a blank
+// line, and averages the word confidences
+func tesseractResult(words []tesseractWord) *OCRResult {
+	result := &OCRResult{}
+	var text strings.Builder
+	total := 0.0
+	for i, word := range words {
+		if i > 0 {
+			previous := words[i-1]
+			switch {
+			case word.block != previous.block || word.paragraph != previous.paragraph:
+				text.WriteString("\n\n")
+			case word.line != previous.line:
+				text.WriteString("\n")
+			default:
+				text.WriteString(" ")
+			}
+		}
+		text.WriteString(word.Text)
+		result.Words = append(result.Words, word.OCRWord)
+		total += word.Confidence
+	}
+
+	result.Text = text.String()
+	if len(words) > 0 {
+		result.Confidence = total / float64(len(words))
+	}
+	return result
+}
+
+// parseTesseractTSV reads the word rows of tesseract's TSV output. Columns are
+// located by the header so that added columns do not break parsing.
+func parseTesseractTSV(r io.Reader) ([]tesseractWord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		return nil, scanner.Err()
+	}
+
+	columns := make(map[string]int)
+	for i, name := range strings.Split(strings.TrimRight(scanner.Text(), "\r"), "\t") {
+		columns[name] = i
+	}
+	required := []string{"level", "block_num", "par_num", "line_num", "left", "top", "width", "height", "conf", "text"}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var words []tesseractWord
+	for row := 2; scanner.Scan(); row++ {
+		fields := strings.Split(strings.TrimRight(scanner.Text(), "\r"), "\t")
+		field := func(name string) string {
+			if i := columns[name]; i < len(fields) {
+				return fields[i]
+			}
+			return ""
+		}
+
+		// Level 5 rows are words; the others describe pages, blocks, paragraphs and lines
+		text := strings.TrimSpace(field("text"))
+		if field("level") != "5" || text == "" {
+			continue
+		}
+
+		var numbers [7]int
+		for i, name := range []string{"block_num", "par_num", "line_num", "left", "top", "width", "height"} {
+			n, err := strconv.Atoi(field(name))
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid %s: %w", row, name, err)
+			}
+			numbers[i] = n
+		}
+		confidence, err := strconv.ParseFloat(field("conf"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid conf: %w", row, err)
+		}
+
+		words = append(words, tesseractWord{
+			OCRWord: OCRWord{
+				Text:       text,
+				Bounds:     image.Rect(numbers[3], numbers[4], numbers[3]+numbers[5], numbers[4]+numbers[6]),
+				Confidence: tesseractConfidence(confidence),
+			},
+			block:     numbers[0],
+			paragraph: numbers[1],
+			line:      numbers[2],
+		})
+	}
+	return words, scanner.Err()
+}
+
+// parseHOCR reads the words of tesseract's hOCR output
+func parseHOCR(r io.Reader) ([]tesseractWord, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var words []tesseractWord
+	var current tesseractWord
+	var word *strings.Builder
+	wordDepth, depth := 0, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return words, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if word != nil {
+				continue
+			}
+			var class, title string
+			for _, attr := range t.Attr {
+				switch attr.Name.Local {
+				case "class":
+					class = attr.Value
+				case "title":
+					title = attr.Value
+				}
+			}
+			switch class {
+			case "ocr_carea":
+				current.block++
+			case "ocr_par":
+				current.paragraph++
+			case "ocr_line", "ocr_header", "ocr_caption", "ocr_textfloat":
+				current.line++
+			case "ocrx_word":
+				current.Bounds, current.Confidence = parseHOCRTitle(title)
+				word = &strings.Builder{}
+				wordDepth = depth
+			}
+		case xml.CharData:
+			if word != nil {
+				word.Write(t)
+			}
+		case xml.EndElement:
+			if word != nil && depth == wordDepth {
+				if text := strings.TrimSpace(word.String()); text != "" {
+					current.Text = text
+					words = append(words, current)
+				}
+				word = nil
+			}
+			depth--
+		}
+	}
+}
+
+// parseHOCRTitle reads the bounding box and word confidence from an hOCR
+// title attribute such as "bbox 36 92 96 116; x_wconf 96"
+func parseHOCRTitle(title string) (image.Rectangle, float64) {
+	var bounds image.Rectangle
+	confidence := 0.0
+	for _, property := range strings.Split(title, ";") {
+		fields := strings.Fields(property)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "bbox":
+			if len(fields) == 5 {
+				var coords [4]int
+				for i := range coords {
+					coords[i], _ = strconv.Atoi(fields[i+1])
+				}
+				bounds = image.Rect(coords[0], coords[1], coords[2], coords[3])
+			}
+		case "x_wconf":
+			if len(fields) == 2 {
+				value, _ := strconv.ParseFloat(fields[1], 64)
+				confidence = tesseractConfidence(value)
+			}
+		}
+	}
+	return bounds, confidence
+}
+
+// tesseractConfidence converts a tesseract confidence from 0-100 to 0-1
+func tesseractConfidence(value float64) float64 {
+	return max(0, min(value, 100)) / 100
+}
@@ -0,0 +1,423 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// OpenDocument XML namespaces
+const (
+	odfOfficeNS       = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odfTextNS         = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odfTableNS        = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odfDrawNS         = "urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"
+	odfPresentationNS = "urn:oasis:names:tc:opendocument:xmlns:presentation:1.0"
+	odfMetaNS         = "urn:oasis:names:tc:opendocument:xmlns:meta:1.0"
+	odfDCNS           = "http://purl.org/dc/elements/1.1/"
+)
+
+const (
+	// maxODFPartSize bounds the uncompressed size of a part read from an OpenDocument package
+	maxODFPartSize = 256 * 1024 * 1024
+
+	// maxODFRepeat bounds how often a repeated row or cell with content is expanded
+	maxODFRepeat = 1000
+)
+
+// odfNode is an element or, when name is empty, character data of an
+// OpenDocument XML part
+type odfNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*odfNode
+	text     string
+}
+
+// is reports whether the node is the element with the given namespace and local name
+func (n *odfNode) is(space, local string) bool {
+	return n.name.Space == space && n.name.Local == local
+}
+
+// attr returns the value of an attribute
+func (n *odfNode) attr(space, local string) string {
+	for _, attr := range n.attrs {
+		if attr.Name.Space == space && attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// find returns the first descendant element with the given name, or nil
+func (n *odfNode) find(space, local string) *odfNode {
+	for _, child := range n.children {
+		if child.is(space, local) {
+			return child
+		}
+		if found := child.find(space, local); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// repeat reads a repetition count attribute such as table:number-columns-repeated
+func (n *odfNode) repeat(local string) int {
+	count, err := strconv.Atoi(n.attr(odfTableNS, local))
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+// odfPackage is an OpenDocument ZIP package
+type odfPackage struct {
+	files map[string]*zip.File
+}
+
+// openODFPackage opens the ZIP package of an OpenDocument file
+func openODFPackage(content []byte) (*odfPackage, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenDocument package as ZIP: %w", err)
+	}
+
+	pkg := &odfPackage{files: make(map[string]*zip.File, len(zipReader.File))}
+	for _, file := range zipReader.File {
+		pkg.files[file.Name] = file
+	}
+	return pkg, nil
+}
+
+// mimeType returns the media type stored in the package's mimetype file
+func (p *odfPackage) mimeType() string {
+	data, err := p.read("mimetype")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// read returns the uncompressed content of a part
+func (p *odfPackage) read(name string) ([]byte, error) {
+	file, ok := p.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in OpenDocument package", name)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxODFPartSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(data) > maxODFPartSize {
+		return nil, fmt.Errorf("%s exceeds %d bytes", name, maxODFPartSize)
+	}
+	return data, nil
+}
+
+// parse reads a part into a tree of nodes
+func (p *odfPackage) parse(name string) (*odfNode, error) {
+	data, err := p.read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	root := &odfNode{}
+	stack := []*odfNode{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &odfNode{name: t.Name, attrs: t.Attr}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &odfNode{text: string(t)})
+		}
+	}
+	return root, nil
+}
+
+// content returns the body of the document in content.xml, such as
+// <office:text> or <office:spreadsheet>
+func (p *odfPackage) content(body string) (*odfNode, error) {
+	root, err := p.parse("content.xml")
+	if err != nil {
+		return nil, err
+	}
+	node := root.find(odfOfficeNS, body)
+	if node == nil {
+		return nil, fmt.Errorf("content.xml has no office:%s element", body)
+	}
+	return node, nil
+}
+
+// addODFMetadata records the document properties of meta.xml, which is
+// optional in a package
+func (p *odfPackage) addODFMetadata(metadata map[string]interface{}) {
+	root, err := p.parse("meta.xml")
+	if err != nil {
+		return
+	}
+	meta := root.find(odfOfficeNS, "meta")
+	if meta == nil {
+		return
+	}
+
+	var keywords []string
+	for _, child := range meta.children {
+		value := collapseSpace(odfPlainText(child))
+		switch {
+		case child.is(odfMetaNS, "keyword"):
+			if value != "" {
+				keywords = append(keywords, value)
+			}
+		case child.is(odfMetaNS, "document-statistic"):
+			if pages, err := strconv.Atoi(child.attr(odfMetaNS, "page-count")); err == nil {
+				metadata["page_count"] = pages
+			}
+		case value == "":
+		case child.is(odfDCNS, "title"):
+			metadata["title"] = value
+		case child.is(odfDCNS, "subject"):
+			metadata["subject"] = value
+		case child.is(odfDCNS, "description"):
+			metadata["description"] = value
+		case child.is(odfDCNS, "language"):
+			metadata["language"] = value
+		case child.is(odfDCNS, "creator"):
+			metadata["last_modified_by"] = value
+		case child.is(odfDCNS, "date"):
+			metadata["modified"] = value
+		case child.is(odfMetaNS, "initial-creator"):
+			metadata["author"] = value
+		case child.is(odfMetaNS, "creation-date"):
+			metadata["created"] = value
+		case child.is(odfMetaNS, "generator"):
+			metadata["generator"] = value
+		}
+	}
+	if len(keywords) > 0 {
+		metadata["keywords"] = keywords
+	}
+	if _, ok := metadata["author"]; !ok && metadata["last_modified_by"] != nil {
+		metadata["author"] = metadata["last_modified_by"]
+	}
+}
+
+// odfPlainText returns the character data of a node and its descendants
+func odfPlainText(node *odfNode) string {
+	if node.name.Local == "" {
+		return node.text
+	}
+	var b strings.Builder
+	for _, child := range node.children {
+		b.WriteString(odfPlainText(child))
+	}
+	return b.String()
+}
+
+// odfTextRenderer renders the text elements of an OpenDocument body
+type odfTextRenderer struct {
+	// notes collects the footnotes and endnotes met so far, each with its citation
+	notes []string
+
+	paragraphs, headings, tables, lists, comments int
+}
+
+// blocks renders the block-level children of a node, one entry per
+// paragraph, heading or table
+func (r *odfTextRenderer) blocks(node *odfNode) []string {
+	var blocks []string
+	for _, child := range node.children {
+		blocks = append(blocks, r.block(child)...)
+	}
+	return blocks
+}
+
+// block renders a block-level element
+func (r *odfTextRenderer) block(node *odfNode) []string {
+	switch {
+	case node.name.Local == "":
+		return nil
+
+	case node.is(odfTextNS, "p"), node.is(odfTextNS, "h"):
+		if node.is(odfTextNS, "h") {
+			r.headings++
+		} else {
+			r.paragraphs++
+		}
+		text, floating := r.inline(node)
+		var blocks []string
+		if text = strings.TrimSpace(text); text != "" {
+			blocks = append(blocks, text)
+		}
+		return append(blocks, floating...)
+
+	case node.is(odfTextNS, "list"):
+		r.lists++
+		return r.blocks(node)
+
+	case node.is(odfTableNS, "table"):
+		r.tables++
+		var lines []string
+		for _, row := range odfTableRows(node) {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = strings.Join(r.blocks(cell), " ")
+			}
+			for len(cells) > 0 && cells[len(cells)-1] == "" {
+				cells = cells[:len(cells)-1]
+			}
+			if len(cells) > 0 {
+				lines = append(lines, tsvRow(cells))
+			}
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		return []string{strings.Join(lines, "\n")}
+
+	case node.is(odfTextNS, "tracked-changes"), node.is(odfTextNS, "sequence-decls"),
+		node.is(odfTextNS, "variable-decls"), node.is(odfTextNS, "user-field-decls"),
+		node.is(odfOfficeNS, "forms"), node.is(odfOfficeNS, "annotation"),
+		node.is(odfTableNS, "table-columns"), node.is(odfTableNS, "table-column"):
+		return nil
+	}
+
+	// Sections, list items, indexes, frames and text boxes contain blocks
+	return r.blocks(node)
+}
+
+// inline renders the text of a paragraph. Text boxes anchored in the
+// paragraph are returned as separate blocks.
+func (r *odfTextRenderer) inline(node *odfNode) (string, []string) {
+	var b strings.Builder
+	var floating []string
+	var visit func(node *odfNode)
+	visit = func(node *odfNode) {
+		for _, child := range node.children {
+			switch {
+			case child.name.Local == "":
+				// Whitespace in text content collapses as in HTML; <text:s> encodes extra spaces
+				text := strings.Join(strings.Fields(child.text), " ")
+				if child.text != "" && strings.TrimLeft(child.text, " \t\r\n") != child.text {
+					text = " " + text
+				}
+				if text != " " && strings.TrimRight(child.text, " \t\r\n") != child.text {
+					text += " "
+				}
+				b.WriteString(text)
+			case child.is(odfTextNS, "s"):
+				count, err := strconv.Atoi(child.attr(odfTextNS, "c"))
+				if err != nil || count < 1 {
+					count = 1
+				}
+				b.WriteString(strings.Repeat(" ", min(count, 100)))
+			case child.is(odfTextNS, "tab"):
+				b.WriteByte('\t')
+			case child.is(odfTextNS, "line-break"):
+				b.WriteByte('\n')
+			case child.is(odfTextNS, "note"):
+				citation := strings.TrimSpace(odfPlainText(child.find(odfTextNS, "note-citation")))
+				if body := child.find(odfTextNS, "note-body"); body != nil {
+					r.notes = append(r.notes, fmt.Sprintf("[%s] %s", citation, strings.Join(r.blocks(body), " ")))
+				}
+				b.WriteString("[" + citation + "]")
+			case child.is(odfOfficeNS, "annotation"):
+				r.comments++
+			case child.is(odfDrawNS, "text-box"):
+				floating = append(floating, r.blocks(child)...)
+			case child.is(odfTextNS, "tracked-changes"), child.is(odfOfficeNS, "annotation-end"),
+				child.is(odfDrawNS, "image"), child.is(odfPresentationNS, "notes"):
+			default:
+				visit(child)
+			}
+		}
+	}
+	visit(node)
+	return b.String(), floating
+}
+
+// odfTableRows returns the cells of a table row by row, expanding repeated
+// rows and cells that have content. Trailing empty rows are dropped.
+func odfTableRows(table *odfNode) [][]*odfNode {
+	var rows [][]*odfNode
+	var visit func(node *odfNode)
+	visit = func(node *odfNode) {
+		for _, child := range node.children {
+			switch {
+			case child.is(odfTableNS, "table-row"):
+				var cells, pending []*odfNode
+				for _, cell := range child.children {
+					if !cell.is(odfTableNS, "table-cell") && !cell.is(odfTableNS, "covered-table-cell") {
+						continue
+					}
+					count := cell.repeat("number-columns-repeated")
+					if strings.TrimSpace(odfPlainText(cell)) == "" && odfCellValue(cell) == "" {
+						// Empty cells only matter when content follows them
+						for i := 0; i < min(count, maxODFRepeat); i++ {
+							pending = append(pending, cell)
+						}
+						continue
+					}
+					cells = append(cells, pending...)
+					pending = nil
+					for i := 0; i < min(count, maxODFRepeat); i++ {
+						cells = append(cells, cell)
+					}
+				}
+				count := 1
+				if len(cells) > 0 {
+					count = min(child.repeat("number-rows-repeated"), maxODFRepeat)
+				}
+				for i := 0; i < count; i++ {
+					rows = append(rows, cells)
+				}
+			case child.is(odfTableNS, "table-header-rows"), child.is(odfTableNS, "table-rows"),
+				child.is(odfTableNS, "table-row-group"):
+				visit(child)
+			}
+		}
+	}
+	visit(table)
+
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
+
+// odfCellValue returns the value attribute of a spreadsheet cell, used when
+// the cell has no text paragraph
+func odfCellValue(cell *odfNode) string {
+	for _, attr := range []string{"value", "date-value", "time-value", "boolean-value", "string-value"} {
+		if value := cell.attr(odfOfficeNS, attr); value != "" {
+			return value
+		}
+	}
+	return ""
+}
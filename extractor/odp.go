@@ -0,0 +1,148 @@
+package extractor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ODPExtractor handles OpenDocument presentation (ODP) file text extraction
+type ODPExtractor struct{}
+
+// NewODPExtractor creates a new ODP extractor
+func NewODPExtractor() *ODPExtractor {
+	return &ODPExtractor{}
+}
+
+// Extract extracts text and metadata from an ODP file. Each slide in the
+// page range becomes a page whose text is the slide's shapes followed by its
+// speaker notes; slides are separated by a blank line.
+func (e *ODPExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
+	start := time.Now()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ODP content: %w", err)
+	}
+
+	if options.MaxFileSize > 0 && int64(len(content)) > options.MaxFileSize {
+		return nil, NewExtractorError(
+			fmt.Sprintf("file size %d exceeds limit %d", len(content), options.MaxFileSize),
+			"odp", "size_check", nil)
+	}
+
+	pkg, err := openODFPackage(content)
+	if err != nil {
+		return nil, NewExtractorError("failed to open ODP package", "odp", "parse", err)
+	}
+	body, err := pkg.content("presentation")
+	if err != nil {
+		return nil, NewExtractorError("failed to read ODP content", "odp", "parse", err)
+	}
+
+	var slides []*odfNode
+	for _, child := range body.children {
+		if child.is(odfDrawNS, "page") {
+			slides = append(slides, child)
+		}
+	}
+
+	result := &ExtractResult{
+		FileType: "odp",
+		Metadata: map[string]interface{}{
+			"size_bytes": len(content),
+		},
+	}
+	pkg.addODFMetadata(result.Metadata)
+
+	firstPage, lastPage, err := pageRange(options, len(slides))
+	if err != nil {
+		return nil, NewExtractorError("invalid page range", "odp", "page_range", err)
+	}
+
+	var textBuilder strings.Builder
+	offset := 0
+	for pageNum := firstPage; pageNum <= lastPage; pageNum++ {
+		slide := slides[pageNum-1]
+		pageResult := PageResult{
+			Number:   pageNum,
+			Label:    slide.attr(odfDrawNS, "name"),
+			Metadata: make(map[string]interface{}),
+		}
+
+		renderer := &odfTextRenderer{}
+		var notes []string
+		slideText := strings.Join(odpShapes(renderer, slide, &notes), "\n")
+		pageText := slideText
+		if len(notes) > 0 {
+			pageResult.Metadata["notes"] = strings.Join(notes, "\n")
+			if pageText != "" {
+				pageText += "\n\n"
+			}
+			pageText += strings.Join(notes, "\n")
+		}
+		pageResult.Text = pageText
+
+		if pageNum > firstPage {
+			textBuilder.WriteString("\n\n") // Separate slides
+			offset += 2
+		}
+		pageResult.Offset = offset
+		textBuilder.WriteString(pageResult.Text)
+		offset += utf8.RuneCountInString(pageResult.Text)
+
+		result.Pages = append(result.Pages, pageResult)
+	}
+
+	result.Text = textBuilder.String()
+	result.Metadata["slides"] = strconv.Itoa(len(slides))
+	result.Metadata["page_count"] = len(slides)
+	result.Metadata["pages_extracted"] = len(result.Pages)
+	result.Metadata["characters"] = strconv.Itoa(len(result.Text))
+	result.Metadata["line_count"] = strconv.Itoa(strings.Count(result.Text, "\n") + 1)
+
+	result.ProcessingTime = time.Since(start)
+
+	return result, nil
+}
+
+// odpShapes renders the text of the shapes on a slide, skipping empty
+// placeholders, and collects the text of its speaker notes
+func odpShapes(renderer *odfTextRenderer, node *odfNode, notes *[]string) []string {
+	var blocks []string
+	for _, child := range node.children {
+		switch {
+		case child.is(odfPresentationNS, "notes"):
+			// The notes page repeats a thumbnail of the slide, which has no text
+			*notes = append(*notes, odpShapes(renderer, child, new([]string))...)
+		case child.attr(odfPresentationNS, "placeholder") == "true":
+			// Placeholders hold the layout's prompt text, not slide content
+		case child.is(odfTextNS, "p"), child.is(odfTextNS, "h"), child.is(odfTextNS, "list"),
+			child.is(odfTableNS, "table"):
+			blocks = append(blocks, renderer.block(child)...)
+		case child.name.Local != "":
+			blocks = append(blocks, odpShapes(renderer, child, notes)...)
+		}
+	}
+	return blocks
+}
+
+// ExtractFromFile extracts text from an ODP file
+func (e *ODPExtractor) ExtractFromFile(filePath string, options ExtractOptions) (*ExtractResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return e.Extract(file, options)
+}
+
+// SupportedTypes returns the file types supported by this extractor
+func (e *ODPExtractor) SupportedTypes() []string {
+	return []string{"odp", "otp"}
+}
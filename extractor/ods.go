@@ -0,0 +1,173 @@
+package extractor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ODSExtractor handles OpenDocument spreadsheet (ODS) file text extraction
+type ODSExtractor struct{}
+
+// NewODSExtractor creates a new ODS extractor
+func NewODSExtractor() *ODSExtractor {
+	return &ODSExtractor{}
+}
+
+// odsSheet is a sheet of an ODS file with the text of its cells
+type odsSheet struct {
+	name string
+	rows [][]string
+}
+
+// readODSSheets reads the sheets of an ODS package
+func readODSSheets(content []byte) (*odfPackage, []odsSheet, error) {
+	pkg, err := openODFPackage(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := pkg.content("spreadsheet")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sheets []odsSheet
+	for _, child := range body.children {
+		if !child.is(odfTableNS, "table") {
+			continue
+		}
+		sheet := odsSheet{name: child.attr(odfTableNS, "name")}
+		for _, row := range odfTableRows(child) {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = odsCellText(cell)
+			}
+			sheet.rows = append(sheet.rows, cells)
+		}
+		sheets = append(sheets, sheet)
+	}
+	return pkg, sheets, nil
+}
+
+// odsCellText returns the displayed text of a cell, falling back to its value
+func odsCellText(cell *odfNode) string {
+	renderer := &odfTextRenderer{}
+	if text := strings.TrimSpace(strings.Join(renderer.blocks(cell), "\n")); text != "" {
+		return text
+	}
+	return odfCellValue(cell)
+}
+
+// Extract extracts text and metadata from an ODS file. Like XLSX, the text of
+// non-empty cells is concatenated without separators.
+func (e *ODSExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
+	start := time.Now()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ODS content: %w", err)
+	}
+
+	if options.MaxFileSize > 0 && int64(len(content)) > options.MaxFileSize {
+		return nil, NewExtractorError(
+			fmt.Sprintf("file size %d exceeds limit %d", len(content), options.MaxFileSize),
+			"ods", "size_check", nil)
+	}
+
+	pkg, sheets, err := readODSSheets(content)
+	if err != nil {
+		return nil, NewExtractorError("failed to parse ODS file", "ods", "parse", err)
+	}
+
+	var extractedText strings.Builder
+	totalRows := 0
+	totalCells := 0
+	for _, sheet := range sheets {
+		for _, row := range sheet.rows {
+			totalRows++
+			for _, cell := range row {
+				totalCells++
+				// Simply concatenate cell text without any separators
+				extractedText.WriteString(strings.TrimSpace(cell))
+			}
+		}
+	}
+
+	result := &ExtractResult{
+		Text:     extractedText.String(),
+		FileType: "ods",
+		Metadata: map[string]interface{}{
+			"size_bytes": len(content),
+		},
+	}
+	pkg.addODFMetadata(result.Metadata)
+
+	result.Metadata["sheets"] = strconv.Itoa(len(sheets))
+	result.Metadata["rows"] = strconv.Itoa(totalRows)
+	result.Metadata["cells"] = strconv.Itoa(totalCells)
+	result.Metadata["character_count"] = strconv.Itoa(len(result.Text))
+	result.Metadata["line_count"] = strconv.Itoa(strings.Count(result.Text, "\n") + 1)
+
+	result.ProcessingTime = time.Since(start)
+
+	return result, nil
+}
+
+// ExtractFromFile extracts text from an ODS file
+func (e *ODSExtractor) ExtractFromFile(filePath string, options ExtractOptions) (*ExtractResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return e.Extract(file, options)
+}
+
+// ExtractTables extracts every sheet of an ODS file as a header-keyed table
+func (e *ODSExtractor) ExtractTables(reader io.Reader, options ExtractOptions, tableOptions TableOptions) ([]*Table, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ODS content: %w", err)
+	}
+
+	if options.MaxFileSize > 0 && int64(len(content)) > options.MaxFileSize {
+		return nil, NewExtractorError(
+			fmt.Sprintf("file size %d exceeds limit %d", len(content), options.MaxFileSize),
+			"ods", "size_check", nil)
+	}
+
+	_, sheets, err := readODSSheets(content)
+	if err != nil {
+		return nil, NewExtractorError("failed to parse ODS file", "ods", "parse", err)
+	}
+
+	tables := make([]*Table, 0, len(sheets))
+	for _, sheet := range sheets {
+		table, err := buildTable(sheet.name, sheet.rows, tableOptions)
+		if err != nil {
+			return nil, NewExtractorError(fmt.Sprintf("invalid table options for sheet %s", sheet.name), "ods", "table", err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// ExtractTablesFromFile extracts every sheet of an ODS file as a header-keyed table
+func (e *ODSExtractor) ExtractTablesFromFile(filePath string, options ExtractOptions, tableOptions TableOptions) ([]*Table, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return e.ExtractTables(file, options, tableOptions)
+}
+
+// SupportedTypes returns the file types supported by this extractor
+func (e *ODSExtractor) SupportedTypes() []string {
+	return []string{"ods", "ots"}
+}
@@ -0,0 +1,90 @@
+package extractor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ODTExtractor handles OpenDocument text (ODT) file text extraction
+type ODTExtractor struct{}
+
+// NewODTExtractor creates a new ODT extractor
+func NewODTExtractor() *ODTExtractor {
+	return &ODTExtractor{}
+}
+
+// Extract extracts text and metadata from an ODT file. Paragraphs, headings
+// and list items are written one per line and table rows as tab-separated
+// cells. Footnotes and endnotes are marked by their citation in the text and
+// follow the body after a blank line.
+func (e *ODTExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
+	start := time.Now()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ODT content: %w", err)
+	}
+
+	if options.MaxFileSize > 0 && int64(len(content)) > options.MaxFileSize {
+		return nil, NewExtractorError(
+			fmt.Sprintf("file size %d exceeds limit %d", len(content), options.MaxFileSize),
+			"odt", "size_check", nil)
+	}
+
+	pkg, err := openODFPackage(content)
+	if err != nil {
+		return nil, NewExtractorError("failed to open ODT package", "odt", "parse", err)
+	}
+	body, err := pkg.content("text")
+	if err != nil {
+		return nil, NewExtractorError("failed to read ODT content", "odt", "parse", err)
+	}
+
+	renderer := &odfTextRenderer{}
+	text := strings.Join(renderer.blocks(body), "\n")
+	if len(renderer.notes) > 0 {
+		text += "\n\n" + strings.Join(renderer.notes, "\n")
+	}
+
+	result := &ExtractResult{
+		Text:     text,
+		FileType: "odt",
+		Metadata: map[string]interface{}{
+			"size_bytes": len(content),
+		},
+	}
+	pkg.addODFMetadata(result.Metadata)
+
+	result.Metadata["paragraphs"] = strconv.Itoa(renderer.paragraphs)
+	result.Metadata["headings"] = strconv.Itoa(renderer.headings)
+	result.Metadata["lists"] = strconv.Itoa(renderer.lists)
+	result.Metadata["table_count"] = strconv.Itoa(renderer.tables)
+	result.Metadata["notes"] = strconv.Itoa(len(renderer.notes))
+	result.Metadata["comments"] = strconv.Itoa(renderer.comments)
+	result.Metadata["characters"] = strconv.Itoa(len(result.Text))
+	result.Metadata["line_count"] = strconv.Itoa(strings.Count(result.Text, "\n") + 1)
+
+	result.ProcessingTime = time.Since(start)
+
+	return result, nil
+}
+
+// ExtractFromFile extracts text from an ODT file
+func (e *ODTExtractor) ExtractFromFile(filePath string, options ExtractOptions) (*ExtractResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return e.Extract(file, options)
+}
+
+// SupportedTypes returns the file types supported by this extractor
+func (e *ODTExtractor) SupportedTypes() []string {
+	return []string{"odt", "ott"}
+}
@@ -1,17 +1,57 @@
 package extractor
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/dslipak/pdf"
 )
 
+// Errors returned when opening encrypted PDFs. They are wrapped in an
+// ExtractorError and can be matched with errors.Is.
+var (
+	// ErrPasswordRequired indicates the PDF is encrypted and no password was given
+	ErrPasswordRequired = errors.New("password required")
+
+	// ErrWrongPassword indicates the given password does not open the PDF
+	ErrWrongPassword = errors.New("wrong password")
+
+	// ErrUnsupportedEncryption indicates the PDF uses a security handler that cannot be decrypted,
+	// such as a non-standard filter, an unknown crypt filter method or an RC4 key shorter than 88 bits
+	ErrUnsupportedEncryption = errors.New("unsupported encryption")
+)
+
 // PDFExtractor provides PDF text extraction capabilities
-type PDFExtractor struct{}
+type PDFExtractor struct {
+	// DetectColumns reads multi-column pages one column at a time instead of line by line across the page
+	DetectColumns bool
+
+	// IncludeFormFields appends "name: value" lines for filled-in form fields to the text of their page
+	IncludeFormFields bool
+
+	// IncludeAnnotations appends the contents of notes, free text and markup annotations to the text of their page
+	IncludeAnnotations bool
+
+	// Cleanup selects optional post-processing of the extracted text
+	Cleanup PDFCleanup
+
+	// DetectTables finds tables from ruling lines and aligned text, returns them in
+	// PDFResult.Tables and renders them in the page text in TableFormat
+	DetectTables bool
+
+	// TableFormat selects how detected tables are rendered in the text; TSV when empty
+	TableFormat TableFormat
+
+	// OCR, when set, recognizes the text of scanned pages: pages that draw
+	// images but no text
+	OCR OCREngine
+}
 
 // NewPDFExtractor creates a new PDF extractor
 func NewPDFExtractor() *PDFExtractor {
@@ -20,96 +60,378 @@ func NewPDFExtractor() *PDFExtractor {
 
 // Extract extracts text from a PDF reader
 func (e *PDFExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
-	start := time.Now()
+	result, err := e.ExtractPDF(reader, options)
+	if err != nil {
+		return nil, err
+	}
+	return result.ExtractResult, nil
+}
+
+// ExtractFromFile extracts text from a PDF file
+func (e *PDFExtractor) ExtractFromFile(filepath string, options ExtractOptions) (*ExtractResult, error) {
+	result, err := e.ExtractPDFFromFile(filepath, options)
+	if err != nil {
+		return nil, err
+	}
+	return result.ExtractResult, nil
+}
+
+// ExtractReaderAt extracts text from a PDF available through random access,
+// such as an *os.File or *bytes.Reader, without copying it into memory
+func (e *PDFExtractor) ExtractReaderAt(reader io.ReaderAt, size int64, options ExtractOptions) (*ExtractResult, error) {
+	result, err := e.ExtractPDFReaderAt(reader, size, options)
+	if err != nil {
+		return nil, err
+	}
+	return result.ExtractResult, nil
+}
 
-	// Read all content into memory
+// ExtractPDF extracts text from a PDF reader along with document information,
+// XMP metadata, the outline and page labels
+func (e *PDFExtractor) ExtractPDF(reader io.Reader, options ExtractOptions) (*PDFResult, error) {
+	// Read all content into memory, as the PDF library needs random access
 	content, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, NewExtractorError("failed to read PDF content", "pdf", "read", err)
 	}
 
+	return e.ExtractPDFReaderAt(bytes.NewReader(content), int64(len(content)), options)
+}
+
+// ExtractPDFFromFile extracts text and document information from a PDF file
+func (e *PDFExtractor) ExtractPDFFromFile(filepath string, options ExtractOptions) (*PDFResult, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, NewExtractorError("failed to open file", "pdf", "open", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, NewExtractorError("failed to stat file", "pdf", "stat", err)
+	}
+
+	return e.ExtractPDFReaderAt(file, info.Size(), options)
+}
+
+// ExtractPDFReaderAt extracts text and document information from a PDF
+// available through random access
+func (e *PDFExtractor) ExtractPDFReaderAt(reader io.ReaderAt, size int64, options ExtractOptions) (*PDFResult, error) {
+	start := time.Now()
+
 	// Check file size limit
-	if options.MaxFileSize > 0 && int64(len(content)) > options.MaxFileSize {
+	if options.MaxFileSize > 0 && size > options.MaxFileSize {
 		return nil, NewExtractorError(
-			fmt.Sprintf("file size %d exceeds limit %d", len(content), options.MaxFileSize),
+			fmt.Sprintf("file size %d exceeds limit %d", size, options.MaxFileSize),
 			"pdf", "size_check", nil)
 	}
 
-	// Create a temporary file for the PDF library
-	tempFile, err := os.CreateTemp("", "pdf_extract_*.pdf")
+	// Open PDF, decrypting it if necessary
+	doc, err := e.openPDF(reader, size, options.Password)
 	if err != nil {
-		return nil, NewExtractorError("failed to create temp file", "pdf", "temp_file", err)
+		return nil, err
 	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
 
-	// Write content to temp file
-	if _, err := tempFile.Write(content); err != nil {
-		return nil, NewExtractorError("failed to write temp file", "pdf", "temp_write", err)
-	}
-	tempFile.Close()
+	return e.extractDocument(doc, size, options, start)
+}
 
-	// Open PDF file
-	r, err := pdf.Open(tempFile.Name())
+// extractDocument extracts the requested pages and document information from
+// an opened PDF
+func (e *PDFExtractor) extractDocument(doc *pdfDocument, size int64, options ExtractOptions, start time.Time) (*PDFResult, error) {
+	r := doc.r
+	pageCount := r.NumPage()
+	firstPage, lastPage, err := pageRange(options, pageCount)
 	if err != nil {
-		return nil, NewExtractorError("failed to open PDF", "pdf", "open", err)
+		return nil, NewExtractorError("invalid page range", "pdf", "page_range", err)
 	}
 
-	// Extract text from all pages
-	var textBuilder strings.Builder
-	pageCount := r.NumPage()
+	result := &PDFResult{}
+	e.readDocumentInfo(doc, result)
+	forms := readFormFields(doc.root())
+
+	// Extract text from each page in range, recording failures per page
+	var pages []PageResult
+	var formTexts []string
+	var failedPages []int
+	var scannedPages []int
+
+	for pageNum := firstPage; pageNum <= lastPage; pageNum++ {
+		pageResult := PageResult{
+			Number:   pageNum,
+			Metadata: make(map[string]interface{}),
+		}
+		if pageNum <= len(result.PageLabels) {
+			pageResult.Label = result.PageLabels[pageNum-1]
+		}
 
-	for pageNum := 1; pageNum <= pageCount; pageNum++ {
 		page := r.Page(pageNum)
 		if page.V.IsNull() {
-			continue
+			pageResult.Err = fmt.Errorf("page %d not found", pageNum)
+		} else if pageText, tables, err := e.extractPageText(page); err != nil {
+			pageResult.Err = fmt.Errorf("page %d: %w", pageNum, err)
+		} else {
+			for _, table := range tables {
+				result.Tables = append(result.Tables, PDFTable{Table: table, Page: pageNum})
+			}
+			if len(tables) > 0 {
+				pageResult.Metadata["tables"] = len(tables)
+			}
+
+			// Normalize line endings if not preserving formatting
+			if !options.PreserveFormatting {
+				pageText = e.normalizeLineEndings(pageText)
+			}
+			pageResult.Text = pageText
 		}
 
-		// Extract text from page
-		pageText, err := e.extractPageText(page)
-		if err != nil {
-			// Log error but continue with other pages
-			continue
+		// A page without text may be a scan, which only OCR can read
+		if pageResult.Err == nil && !page.V.IsNull() && strings.TrimSpace(pageResult.Text) == "" {
+			if graphics, err := inspectPageGraphics(doc.pageNode(pageNum, page)); err == nil && len(graphics.images) > 0 {
+				pageResult.Metadata["images"] = len(graphics.images)
+				if !graphics.hasText {
+					pageResult.Metadata["scanned"] = true
+					scannedPages = append(scannedPages, pageNum)
+					e.recognizePage(&pageResult, graphics.images, doc, options)
+				}
+			}
 		}
 
-		textBuilder.WriteString(pageText)
-		if pageNum < pageCount {
-			textBuilder.WriteString("\n\n") // Separate pages
+		formText := ""
+		if !page.V.IsNull() {
+			annotations, fields := forms.readPageAnnotations(doc.pageNode(pageNum, page), pageNum)
+			result.Annotations = append(result.Annotations, annotations...)
+
+			if !e.IncludeFormFields {
+				fields = nil
+			}
+			if !e.IncludeAnnotations {
+				annotations = nil
+			}
+			formText = formatFormText(fields, annotations)
+		}
+
+		if pageResult.Err != nil {
+			failedPages = append(failedPages, pageNum)
 		}
+
+		pages = append(pages, pageResult)
+		formTexts = append(formTexts, formText)
 	}
 
-	text := textBuilder.String()
+	// Clean up the page content before form text is added, so it cannot be
+	// mistaken for a running footer
+	removedLines := e.Cleanup.apply(pages)
+
+	// Join the pages, recording where each one starts
+	var textBuilder strings.Builder
+	offset := 0
+	for i := range pages {
+		if formTexts[i] != "" {
+			if pages[i].Text != "" {
+				pages[i].Text += "\n\n"
+			}
+			pages[i].Text += formTexts[i]
+		}
 
-	// Normalize line endings if not preserving formatting
-	if !options.PreserveFormatting {
-		text = e.normalizeLineEndings(text)
+		if i > 0 {
+			textBuilder.WriteString("\n\n") // Separate pages
+			offset += 2
+		}
+		pages[i].Offset = offset
+		textBuilder.WriteString(pages[i].Text)
+		offset += utf8.RuneCountInString(pages[i].Text)
 	}
 
+	text := textBuilder.String()
+
 	metadata := map[string]interface{}{
-		"page_count": pageCount,
-		"size_bytes": len(content),
-		"line_count": strings.Count(text, "\n") + 1,
-		"char_count": len([]rune(text)),
-		"word_count": len(strings.Fields(text)),
+		"page_count":      pageCount,
+		"pages_extracted": len(pages),
+		"size_bytes":      int(size),
+		"line_count":      strings.Count(text, "\n") + 1,
+		"char_count":      len([]rune(text)),
+		"word_count":      len(strings.Fields(text)),
+	}
+	if len(failedPages) > 0 {
+		metadata["failed_pages"] = failedPages
+	}
+	if len(scannedPages) > 0 {
+		metadata["scanned_pages"] = scannedPages
+	}
+	if removedLines > 0 {
+		metadata["header_footer_lines_removed"] = removedLines
+	}
+	result.Fields = forms.fields
+	if len(result.Fields) > 0 {
+		metadata["form_fields"] = len(result.Fields)
+	}
+	if len(result.Annotations) > 0 {
+		metadata["annotations"] = len(result.Annotations)
+	}
+	if len(result.Tables) > 0 {
+		metadata["tables"] = len(result.Tables)
+	}
+	if doc.encryption != "" {
+		metadata["encrypted"] = true
+		metadata["encryption"] = doc.encryption
+	} else {
+		metadata["encrypted"] = false
 	}
+	e.addDocumentMetadata(result, metadata)
 
-	return &ExtractResult{
+	result.ExtractResult = &ExtractResult{
 		Text:           text,
 		Metadata:       metadata,
 		FileType:       "pdf",
 		ProcessingTime: time.Since(start),
-	}, nil
+		Pages:          pages,
+	}
+	return result, nil
 }
 
-// ExtractFromFile extracts text from a PDF file
-func (e *PDFExtractor) ExtractFromFile(filepath string, options ExtractOptions) (*ExtractResult, error) {
-	file, err := os.Open(filepath)
+// pdfDocument is an opened PDF: the library's reader together with the raw
+// objects of the same file
+type pdfDocument struct {
+	r *pdf.Reader
+
+	// raw reads objects by reference, or is nil when the cross-reference
+	// data could not be read
+	raw *pdfFile
+
+	// encryption describes the encryption method of an encrypted document
+	encryption string
+
+	// rawEncrypted reports that the library decrypts the document, so raw
+	// strings and stream data are still encrypted
+	rawEncrypted bool
+
+	// pages caches the references of the page objects in page order
+	pages []pdfRef
+}
+
+// root returns the document catalog
+func (doc *pdfDocument) root() pdfNode {
+	if doc.raw == nil {
+		return newPDFNode(doc.r.Trailer().Key("Root"), nil, nil)
+	}
+	return newPDFNode(doc.r.Trailer().Key("Root"), doc.raw, doc.raw.trailer["Root"])
+}
+
+// pageNode pairs a page read by the library with its raw page object
+func (doc *pdfDocument) pageNode(pageNum int, page pdf.Page) pdfNode {
+	refs := doc.pageRefs()
+	if pageNum < 1 || pageNum > len(refs) {
+		return newPDFNode(page.V, nil, nil)
+	}
+	return newPDFNode(page.V, doc.raw, refs[pageNum-1])
+}
+
+// pageRefs returns the references of the page objects in page order, or nil
+// when the raw objects cannot be read
+func (doc *pdfDocument) pageRefs() []pdfRef {
+	if doc.pages == nil && doc.raw != nil {
+		doc.pages = doc.raw.pageRefs()
+	}
+	return doc.pages
+}
+
+// openPDF opens a PDF. The cross-reference data is read first to find the
+// encryption dictionary: RC4 documents are decrypted by the PDF library, AES
+// documents through a view of the file.
+func (e *PDFExtractor) openPDF(f io.ReaderAt, size int64, password string) (*pdfDocument, error) {
+	doc := &pdfDocument{}
+	raw, err := readPDFFile(f, size)
 	if err != nil {
-		return nil, NewExtractorError("failed to open file", "pdf", "open", err)
+		// Cross-reference data that cannot be read here is left to the PDF
+		// library, which also decrypts the document if it can
+		if doc.r, err = openPDFWithPassword(f, size, password); err != nil {
+			return nil, err
+		}
+		if encrypt := doc.r.Trailer().Key("Encrypt"); !encrypt.IsNull() {
+			doc.encryption = libraryEncryptionMethod(encrypt)
+			doc.rawEncrypted = true
+		}
+		return doc, nil
 	}
-	defer file.Close()
+	doc.raw = raw
 
-	return e.Extract(file, options)
+	if raw.trailer["Encrypt"] == nil {
+		if doc.r, err = pdf.NewReader(f, size); err != nil {
+			return nil, NewExtractorError("failed to open PDF", "pdf", "open", err)
+		}
+		return doc, nil
+	}
+
+	dict, ok := raw.resolve(raw.trailer["Encrypt"]).(pdfDict)
+	if !ok {
+		return nil, NewExtractorError("failed to decrypt PDF", "pdf", "decrypt",
+			fmt.Errorf("%w: encryption dictionary not found", ErrUnsupportedEncryption))
+	}
+	var id []byte
+	if ids, ok := raw.trailer["ID"].(pdfArray); ok && len(ids) > 0 {
+		id, _ = ids[0].(pdfString)
+	}
+	enc, err := readPDFEncryption(dict, id)
+	if err != nil {
+		return nil, NewExtractorError("failed to decrypt PDF", "pdf", "decrypt", err)
+	}
+	doc.encryption = enc.method()
+
+	if enc.v < 4 {
+		doc.rawEncrypted = true
+		if doc.r, err = openPDFWithPassword(f, size, password); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	}
+
+	view, err := decryptPDF(raw, enc, password)
+	if err != nil {
+		return nil, NewExtractorError("failed to decrypt PDF", "pdf", "decrypt", err)
+	}
+	if doc.r, err = pdf.NewReader(view, size); err != nil {
+		return nil, NewExtractorError("failed to open PDF", "pdf", "open", err)
+	}
+	return doc, nil
+}
+
+// openPDFWithPassword opens a PDF with the library, supplying the password
+// if the document is encrypted
+func openPDFWithPassword(f io.ReaderAt, size int64, password string) (*pdf.Reader, error) {
+	// The password callback is asked repeatedly until it returns an empty string
+	tried := false
+	r, err := pdf.NewReaderEncrypted(f, size, func() string {
+		if tried {
+			return ""
+		}
+		tried = true
+		return password
+	})
+
+	switch {
+	case err == nil:
+		return r, nil
+	case errors.Is(err, pdf.ErrInvalidPassword) && password == "":
+		return nil, NewExtractorError("failed to decrypt PDF", "pdf", "decrypt", ErrPasswordRequired)
+	case errors.Is(err, pdf.ErrInvalidPassword):
+		return nil, NewExtractorError("failed to decrypt PDF", "pdf", "decrypt", ErrWrongPassword)
+	default:
+		return nil, NewExtractorError("failed to open PDF", "pdf", "open", err)
+	}
+}
+
+// libraryEncryptionMethod describes the encryption of a document the library
+// decrypted: AES-128 for version 4, RC4 otherwise
+func libraryEncryptionMethod(encrypt pdf.Value) string {
+	if encrypt.Key("V").Int64() == 4 {
+		return "AES-128"
+	}
+
+	bits := encrypt.Key("Length").Int64()
+	if bits == 0 {
+		bits = 40
+	}
+	return fmt.Sprintf("RC4-%d", bits)
 }
 
 // SupportedTypes returns the file types supported by this extractor
@@ -117,22 +439,37 @@ func (e *PDFExtractor) SupportedTypes() []string {
 	return []string{"pdf"}
 }
 
-// extractPageText extracts text from a single PDF page
-func (e *PDFExtractor) extractPageText(page pdf.Page) (string, error) {
+// extractPageText extracts text from a single PDF page, rebuilding words and
+// lines from glyph positions. When table detection is enabled it also returns
+// the tables found on the page.
+func (e *PDFExtractor) extractPageText(page pdf.Page) (text string, tables []*Table, err error) {
+	// The PDF library panics on malformed content streams
+	defer func() {
+		if r := recover(); r != nil {
+			text, tables = "", nil
+			err = fmt.Errorf("failed to read page content: %v", r)
+		}
+	}()
+
 	// Get page content
 	content := page.Content()
 	if content.Text == nil {
-		return "", nil
+		return "", nil, nil
 	}
 
-	var textBuilder strings.Builder
-	for _, text := range content.Text {
-		// Simply concatenate all text elements without adding extra spaces
-		// The PDF library already provides proper spacing as separate elements
-		textBuilder.WriteString(text.S)
+	if !e.DetectTables {
+		return reconstructPageText(content.Text, e.DetectColumns), nil, nil
 	}
 
-	return strings.TrimSpace(textBuilder.String()), nil
+	regions, remaining := detectTables(content.Text, readRulings(page))
+	for _, region := range regions {
+		table, err := buildTable("", region.rows, DefaultTableOptions())
+		if err != nil {
+			return "", nil, err
+		}
+		tables = append(tables, table)
+	}
+	return renderPageWithTables(remaining, regions, tables, e.TableFormat, e.DetectColumns), tables, nil
 }
 
 // normalizeLineEndings converts different line ending formats to \n
@@ -0,0 +1,222 @@
+package extractor
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PDFCleanup selects optional post-processing steps for extracted PDF text.
+// All steps are off by default.
+type PDFCleanup struct {
+	// ExpandLigatures replaces ligature characters such as "ﬁ" with their letters
+	ExpandLigatures bool
+
+	// JoinHyphenation rejoins words that were hyphenated across a line break
+	JoinHyphenation bool
+
+	// RemoveHeadersFooters drops lines repeated at the top or bottom of most pages,
+	// such as running titles and page numbers
+	RemoveHeadersFooters bool
+}
+
+// DefaultPDFCleanup returns a cleanup configuration with every step enabled
+func DefaultPDFCleanup() PDFCleanup {
+	return PDFCleanup{
+		ExpandLigatures:      true,
+		JoinHyphenation:      true,
+		RemoveHeadersFooters: true,
+	}
+}
+
+// Header and footer detection parameters
+const (
+	// headerFooterZone is how many lines at the top and bottom of a page are examined
+	headerFooterZone = 2
+
+	// headerFooterRatio is the share of pages a line must repeat on to be removed
+	headerFooterRatio = 0.6
+)
+
+// pdfLigatures maps the Latin ligature presentation forms (U+FB00-U+FB06) to
+// the letters they stand for. Letters such as Œ and Ĳ are left alone.
+var pdfLigatures = strings.NewReplacer(
+	"ﬀ", "ff",
+	"ﬁ", "fi",
+	"ﬂ", "fl",
+	"ﬃ", "ffi",
+	"ﬄ", "ffl",
+	"ﬅ", "st",
+	"ﬆ", "st",
+)
+
+// apply runs the enabled cleanup steps on the page texts in place and
+// returns the number of header and footer lines removed
+func (c PDFCleanup) apply(pages []PageResult) int {
+	if c.ExpandLigatures {
+		for i := range pages {
+			pages[i].Text = pdfLigatures.Replace(pages[i].Text)
+		}
+	}
+
+	removed := 0
+	if c.RemoveHeadersFooters {
+		removed = removeHeadersFooters(pages)
+	}
+
+	if c.JoinHyphenation {
+		for i := range pages {
+			pages[i].Text = joinHyphenation(pages[i].Text)
+		}
+	}
+
+	return removed
+}
+
+// joinHyphenation rejoins words broken with a trailing hyphen at the end of a
+// line. The continuation is moved up to complete the word, and the rest of
+// the following line stays where it was.
+func joinHyphenation(text string) string {
+	lines := strings.Split(text, "\n")
+	emptied := make(map[int]bool)
+	for i := 0; i < len(lines)-1; i++ {
+		stem, ok := hyphenatedStem(strings.TrimRight(lines[i], " "))
+		if !ok {
+			continue
+		}
+
+		next := strings.TrimLeft(lines[i+1], " ")
+		first, _ := utf8.DecodeRuneInString(next)
+		if !unicode.IsLower(first) {
+			continue
+		}
+
+		end := strings.IndexAny(next, " \t")
+		if end < 0 {
+			end = len(next)
+		}
+		lines[i] = stem + next[:end]
+		lines[i+1] = strings.TrimLeft(next[end:], " \t")
+		emptied[i+1] = lines[i+1] == ""
+	}
+
+	// Drop lines whose only word was moved up, keeping genuine blank lines
+	cleaned := lines[:0]
+	for i, line := range lines {
+		if !emptied[i] {
+			cleaned = append(cleaned, line)
+		}
+	}
+	return strings.Join(cleaned, "\n")
+}
+
+// hyphenatedStem returns the line without its trailing hyphen when the line
+// ends in a letter followed by a hyphen, soft hyphen or Unicode hyphen
+func hyphenatedStem(line string) (string, bool) {
+	last, size := utf8.DecodeLastRuneInString(line)
+	if last != '-' && last != '\u00ad' && last != '\u2010' {
+		return "", false
+	}
+	stem := line[:len(line)-size]
+	before, _ := utf8.DecodeLastRuneInString(stem)
+	if !unicode.IsLetter(before) {
+		return "", false
+	}
+	return stem, true
+}
+
+// removeHeadersFooters removes lines that repeat in the first or last lines
+// of most pages. Digits are ignored when comparing lines, so page numbers and
+// "Page 3 of 10" style footers match across pages.
+func removeHeadersFooters(pages []PageResult) int {
+	withText := 0
+	for _, page := range pages {
+		if strings.TrimSpace(page.Text) != "" {
+			withText++
+		}
+	}
+	threshold := int(headerFooterRatio*float64(withText) + 0.999)
+	if withText < 2 || threshold < 2 {
+		return 0
+	}
+
+	// Count on how many pages each normalized line appears in the header or footer zone
+	headerCounts := make(map[string]int)
+	footerCounts := make(map[string]int)
+	for _, page := range pages {
+		lines := nonEmptyLines(page.Text)
+		seenHeader := make(map[string]bool)
+		seenFooter := make(map[string]bool)
+		for i := 0; i < len(lines) && i < headerFooterZone; i++ {
+			if key := normalizeRunningLine(lines[i]); !seenHeader[key] {
+				seenHeader[key] = true
+				headerCounts[key]++
+			}
+		}
+		for i := len(lines) - 1; i >= 0 && i >= len(lines)-headerFooterZone; i-- {
+			if key := normalizeRunningLine(lines[i]); !seenFooter[key] {
+				seenFooter[key] = true
+				footerCounts[key]++
+			}
+		}
+	}
+
+	removed := 0
+	for i := range pages {
+		lines := strings.Split(pages[i].Text, "\n")
+		var kept []string
+
+		// Line positions counted among non-empty lines, from the top and from the bottom
+		total := len(nonEmptyLines(pages[i].Text))
+		position := 0
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				kept = append(kept, line)
+				continue
+			}
+			key := normalizeRunningLine(line)
+			isHeader := position < headerFooterZone && headerCounts[key] >= threshold
+			isFooter := position >= total-headerFooterZone && footerCounts[key] >= threshold
+			position++
+			if isHeader || isFooter {
+				removed++
+				continue
+			}
+			kept = append(kept, line)
+		}
+
+		pages[i].Text = strings.TrimSpace(strings.Join(kept, "\n"))
+	}
+
+	return removed
+}
+
+// nonEmptyLines returns the lines of text that contain more than whitespace
+func nonEmptyLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// normalizeRunningLine reduces a line to a comparison key by collapsing
+// whitespace and replacing every run of digits with "#"
+func normalizeRunningLine(line string) string {
+	var builder strings.Builder
+	inDigits := false
+	for _, char := range strings.Join(strings.Fields(line), " ") {
+		if unicode.IsDigit(char) {
+			if !inDigits {
+				builder.WriteByte('#')
+			}
+			inDigits = true
+			continue
+		}
+		inDigits = false
+		builder.WriteRune(unicode.ToLower(char))
+	}
+	return builder.String()
+}
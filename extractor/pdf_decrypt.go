@@ -0,0 +1,513 @@
+package extractor
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// The PDF library decrypts RC4 documents itself. It decrypts AES-128 streams
+// but panics on AES-128 strings, and does not know AES-256 (revisions 5 and
+// 6), so AES documents are decrypted here: the document is presented to the
+// library through a view of the file in which encrypted strings and streams
+// are replaced by their plaintext at the same offsets.
+
+// Crypt filter methods (PDF 32000-1:2008, table 25)
+const (
+	cryptIdentity = "Identity"
+	cryptRC4      = "V2"
+	cryptAESV2    = "AESV2"
+	cryptAESV3    = "AESV3"
+)
+
+// pdfEncryption holds the standard security handler parameters of the
+// /Encrypt dictionary
+type pdfEncryption struct {
+	v, r int
+
+	// keyLength is the file key length in bytes
+	keyLength int
+
+	o, u, oe, ue []byte
+	permissions  uint32
+	id           []byte
+
+	encryptMetadata bool
+
+	// stmF and strF are the crypt filter methods for streams and strings;
+	// filters holds the methods of all named crypt filters
+	stmF, strF string
+	filters    map[pdfName]string
+}
+
+// readPDFEncryption reads the /Encrypt dictionary and the first element of
+// the trailer's /ID, rejecting security handlers that cannot be decrypted
+// with ErrUnsupportedEncryption
+func readPDFEncryption(dict pdfDict, id []byte) (*pdfEncryption, error) {
+	if filter := dict["Filter"]; filter != pdfName("Standard") {
+		return nil, fmt.Errorf("%w: security handler %v", ErrUnsupportedEncryption, filter)
+	}
+
+	v, _ := pdfInt(dict["V"])
+	r, _ := pdfInt(dict["R"])
+	enc := &pdfEncryption{v: int(v), r: int(r), id: id, encryptMetadata: dict["EncryptMetadata"] != pdfKeyword("false")}
+	switch {
+	case v != 1 && v != 2 && v != 4 && v != 5:
+		return nil, fmt.Errorf("%w: encryption version V=%d", ErrUnsupportedEncryption, v)
+	case v < 5 && (r < 2 || r > 4), v == 5 && r != 5 && r != 6:
+		return nil, fmt.Errorf("%w: revision R=%d for version V=%d", ErrUnsupportedEncryption, r, v)
+	}
+
+	// Versions 1 and 2 encrypt everything with RC4; later versions name
+	// crypt filters for streams and strings
+	switch v {
+	case 1, 2:
+		enc.stmF, enc.strF = cryptRC4, cryptRC4
+	default:
+		cf, _ := dict["CF"].(pdfDict)
+		enc.filters = make(map[pdfName]string, len(cf))
+		for name, filter := range cf {
+			filterDict, _ := filter.(pdfDict)
+			cfm, _ := filterDict["CFM"].(pdfName)
+			switch {
+			case cfm == cryptAESV2 && v == 4, cfm == cryptAESV3 && v == 5:
+				enc.filters[name] = string(cfm)
+			default:
+				// The PDF library only decrypts RC4 with versions 1 and 2
+				return nil, fmt.Errorf("%w: crypt filter method %v for version V=%d", ErrUnsupportedEncryption, filterDict["CFM"], v)
+			}
+		}
+		enc.filters[cryptIdentity] = cryptIdentity
+
+		var ok bool
+		stmF, _ := dict["StmF"].(pdfName)
+		strF, _ := dict["StrF"].(pdfName)
+		if enc.stmF, ok = enc.filter(stmF); !ok {
+			return nil, fmt.Errorf("%w: unknown crypt filter %s", ErrUnsupportedEncryption, stmF)
+		}
+		if enc.strF, ok = enc.filter(strF); !ok {
+			return nil, fmt.Errorf("%w: unknown crypt filter %s", ErrUnsupportedEncryption, strF)
+		}
+		if v == 4 && stmF != strF {
+			return nil, fmt.Errorf("%w: different crypt filters for streams and strings", ErrUnsupportedEncryption)
+		}
+	}
+
+	switch {
+	case v == 1:
+		enc.keyLength = 5
+	case v == 5:
+		enc.keyLength = 32
+	default:
+		bits, ok := pdfInt(dict["Length"])
+		if !ok {
+			bits = 40
+			if v == 4 {
+				bits = 128
+			}
+		}
+		if bits < 40 || bits > 128 || bits%8 != 0 {
+			return nil, fmt.Errorf("%w: %d-bit encryption key", ErrUnsupportedEncryption, bits)
+		}
+		enc.keyLength = int(bits / 8)
+	}
+	if enc.stmF == cryptRC4 && enc.keyLength < 11 {
+		// The PDF library derives RC4 object keys longer than the file key
+		// allows (algorithm 1), which only matches from 88 bits up
+		return nil, fmt.Errorf("%w: %d-bit RC4 key", ErrUnsupportedEncryption, enc.keyLength*8)
+	}
+
+	o, _ := dict["O"].(pdfString)
+	u, _ := dict["U"].(pdfString)
+	p, _ := pdfInt(dict["P"])
+	enc.o, enc.u, enc.permissions = o, u, uint32(int32(p))
+	if r == 4 && (len(o) < 32 || len(u) < 32) {
+		return nil, fmt.Errorf("%w: malformed O and U entries", ErrUnsupportedEncryption)
+	}
+	if r >= 5 {
+		enc.oe, _ = dict["OE"].(pdfString)
+		enc.ue, _ = dict["UE"].(pdfString)
+		if len(enc.o) < 48 || len(enc.u) < 48 || len(enc.oe) != 32 || len(enc.ue) != 32 {
+			return nil, fmt.Errorf("%w: malformed O, U, OE and UE entries", ErrUnsupportedEncryption)
+		}
+	}
+	return enc, nil
+}
+
+// filter returns the method of a named crypt filter; a missing name means Identity
+func (enc *pdfEncryption) filter(name pdfName) (string, bool) {
+	if name == "" {
+		return cryptIdentity, true
+	}
+	method, ok := enc.filters[name]
+	return method, ok
+}
+
+// method describes the encryption used for the document's streams
+func (enc *pdfEncryption) method() string {
+	method := enc.stmF
+	if method == cryptIdentity {
+		method = enc.strF
+	}
+	switch method {
+	case cryptAESV2:
+		return "AES-128"
+	case cryptAESV3:
+		return "AES-256"
+	}
+	return fmt.Sprintf("RC4-%d", enc.keyLength*8)
+}
+
+// pdfPasswordPad is the padding string from PDF 32000-1:2008, §7.6.3.3
+var pdfPasswordPad = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// fileKey derives the file key of an AES document from the password,
+// returning false when it does not open the document
+func (enc *pdfEncryption) fileKey(password string) ([]byte, bool) {
+	if enc.r >= 5 {
+		return enc.fileKeyAES256(password)
+	}
+	return enc.fileKeyAES128(password)
+}
+
+// fileKeyAES128 computes the file key of revision 4 from a user password
+// (algorithm 2) and checks it against the U entry (algorithm 5). Like the
+// PDF library, it does not accept the owner password.
+func (enc *pdfEncryption) fileKeyAES128(password string) ([]byte, bool) {
+	h := md5.New()
+	h.Write(append([]byte(password), pdfPasswordPad...)[:32])
+	h.Write(enc.o[:32])
+	h.Write([]byte{byte(enc.permissions), byte(enc.permissions >> 8), byte(enc.permissions >> 16), byte(enc.permissions >> 24)})
+	h.Write(enc.id)
+	if !enc.encryptMetadata {
+		h.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	}
+	key := h.Sum(nil)
+	for i := 0; i < 50; i++ {
+		next := md5.Sum(key[:enc.keyLength])
+		key = next[:]
+	}
+	key = key[:enc.keyLength]
+
+	check := md5.Sum(append(append([]byte(nil), pdfPasswordPad...), enc.id...))
+	u := check[:]
+	for i := 0; i <= 19; i++ {
+		round := make([]byte, len(key))
+		for j := range key {
+			round[j] = key[j] ^ byte(i)
+		}
+		c, _ := rc4.NewCipher(round)
+		c.XORKeyStream(u, u)
+	}
+	return key, bytes.Equal(u, enc.u[:16])
+}
+
+// fileKeyAES256 checks a user or owner password against the U and O entries
+// of revisions 5 and 6 and unwraps the file key from UE or OE
+// (ISO 32000-2, algorithm 2.A)
+func (enc *pdfEncryption) fileKeyAES256(password string) ([]byte, bool) {
+	pw := []byte(password)
+	if len(pw) > 127 {
+		pw = pw[:127]
+	}
+
+	var wrapped, intermediate []byte
+	switch userData := enc.u[:48]; {
+	case bytes.Equal(enc.hash(pw, enc.u[32:40], nil), enc.u[:32]):
+		wrapped, intermediate = enc.ue, enc.hash(pw, enc.u[40:48], nil)
+	case bytes.Equal(enc.hash(pw, enc.o[32:40], userData), enc.o[:32]):
+		wrapped, intermediate = enc.oe, enc.hash(pw, enc.o[40:48], userData)
+	default:
+		return nil, false
+	}
+
+	block, err := aes.NewCipher(intermediate)
+	if err != nil {
+		return nil, false
+	}
+	key := make([]byte, 32)
+	cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(key, wrapped)
+	return key, true
+}
+
+// hash computes the password hash of revision 5, or the iterated hash of
+// revision 6 (ISO 32000-2, algorithm 2.B)
+func (enc *pdfEncryption) hash(password, salt, userData []byte) []byte {
+	h := sha256.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(userData)
+	k := h.Sum(nil)
+	if enc.r == 5 {
+		return k
+	}
+
+	for round := 0; ; round++ {
+		seq := append(append(append([]byte(nil), password...), k...), userData...)
+		k1 := bytes.Repeat(seq, 64)
+		block, _ := aes.NewCipher(k[:16])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+
+		sum := 0
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		switch sum % 3 {
+		case 0:
+			next := sha256.Sum256(e)
+			k = next[:]
+		case 1:
+			next := sha512.Sum384(e)
+			k = next[:]
+		default:
+			next := sha512.Sum512(e)
+			k = next[:]
+		}
+
+		if round >= 63 && int(e[len(e)-1]) <= round-31 {
+			return k[:32]
+		}
+	}
+}
+
+// objectKey returns the key for the strings and streams of one object
+// (algorithm 1); AES-256 uses the file key directly
+func (enc *pdfEncryption) objectKey(key []byte, ref pdfRef) []byte {
+	if enc.r >= 5 {
+		return key
+	}
+	h := md5.New()
+	h.Write(key)
+	h.Write([]byte{byte(ref.num), byte(ref.num >> 8), byte(ref.num >> 16), byte(ref.gen), byte(ref.gen >> 8)})
+	h.Write([]byte("sAlT"))
+	return h.Sum(nil)
+}
+
+// decryptAES decrypts a string or stream: the initialization vector
+// followed by AES-CBC data with PKCS#7 padding. Malformed data decrypts to
+// nothing.
+func decryptAES(key, data []byte) []byte {
+	block, err := aes.NewCipher(key)
+	if err != nil || len(data) < 2*aes.BlockSize {
+		return nil
+	}
+	data = data[:len(data)-len(data)%aes.BlockSize]
+	out := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(out, data[aes.BlockSize:])
+	if padding := int(out[len(out)-1]); padding >= 1 && padding <= aes.BlockSize &&
+		bytes.Equal(out[len(out)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		out = out[:len(out)-padding]
+	}
+	return out
+}
+
+// pdfPatch replaces a byte range of the file. Strings are replaced by
+// their plaintext, written out when the file is scanned; stream data is
+// decrypted with key when it is read.
+type pdfPatch struct {
+	offset, length int64
+	data           []byte
+	key            []byte
+}
+
+// pdfDecryptedFile is a view of an AES encrypted file in which strings and
+// streams read as plaintext and the trailer's /Encrypt entry is hidden, so
+// the PDF library opens it as an unencrypted document. Plaintext never grows
+// beyond its ciphertext, so every object keeps its offset and the remainder
+// of each replaced range is filled with spaces.
+type pdfDecryptedFile struct {
+	f io.ReaderAt
+
+	// patches is sorted by offset
+	patches []pdfPatch
+
+	mu sync.Mutex
+	// cached holds the most recently decrypted stream
+	cached *pdfPatch
+}
+
+// ReadAt reads from the underlying file, replacing the patched ranges
+func (d *pdfDecryptedFile) ReadAt(b []byte, off int64) (int, error) {
+	n, err := d.f.ReadAt(b, off)
+	end := off + int64(n)
+
+	i := sort.Search(len(d.patches), func(i int) bool {
+		return d.patches[i].offset+d.patches[i].length > off
+	})
+	for ; i < len(d.patches) && d.patches[i].offset < end; i++ {
+		patch := &d.patches[i]
+		data := patch.data
+		if patch.key != nil {
+			data = d.stream(patch)
+		}
+		from, to := max(off, patch.offset), min(end, patch.offset+patch.length)
+		copy(b[from-off:to-off], data[from-patch.offset:to-patch.offset])
+	}
+	return n, err
+}
+
+// stream returns the padded plaintext of a stream patch
+func (d *pdfDecryptedFile) stream(patch *pdfPatch) []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cached != nil && d.cached.offset == patch.offset {
+		return d.cached.data
+	}
+
+	data := make([]byte, patch.length)
+	if _, err := d.f.ReadAt(data, patch.offset); err != nil && err != io.EOF {
+		data = nil
+	}
+	data = padPDFData(decryptAES(patch.key, data), patch.length)
+	d.cached = &pdfPatch{offset: patch.offset, length: patch.length, data: data}
+	return data
+}
+
+// padPDFData pads data with spaces to length bytes
+func padPDFData(data []byte, length int64) []byte {
+	out := bytes.Repeat([]byte{' '}, int(length))
+	copy(out, data)
+	return out
+}
+
+// pdfStringPatch encodes a decrypted string to replace a ciphertext string
+// token of length bytes, falling back to hexadecimal and then to an empty
+// string if the literal form does not fit
+func pdfStringPatch(s []byte, length int64) []byte {
+	literal := []byte{'('}
+	for _, b := range s {
+		switch b {
+		case '(', ')', '\\':
+			literal = append(literal, '\\', b)
+		case '\r':
+			literal = append(literal, '\\', 'r')
+		default:
+			literal = append(literal, b)
+		}
+	}
+	literal = append(literal, ')')
+
+	switch {
+	case int64(len(literal)) <= length:
+		return padPDFData(literal, length)
+	case int64(2*len(s)+2) <= length:
+		return padPDFData([]byte(fmt.Sprintf("<%x>", s)), length)
+	}
+	return padPDFData([]byte("()"), length)
+}
+
+// decryptPDF checks the password of an AES document and returns the
+// decrypted view of the file, which p reads from afterwards. Objects outside
+// object streams are scanned for their strings and stream data; object
+// streams are decrypted as a whole, so the objects inside them need no
+// patches.
+func decryptPDF(p *pdfFile, enc *pdfEncryption, password string) (*pdfDecryptedFile, error) {
+	key, ok := enc.fileKey(password)
+	switch {
+	case !ok && password == "":
+		return nil, ErrPasswordRequired
+	case !ok:
+		return nil, ErrWrongPassword
+	}
+	d := &pdfDecryptedFile{f: p.f}
+
+	// Tokens are collected while objects are read, with the byte ranges they
+	// were read from
+	type token struct {
+		tok        pdfObject
+		start, end int64
+	}
+	var tokens []token
+	p.scanned = func(tok pdfObject, start, end int64) {
+		tokens = append(tokens, token{tok, start, end})
+	}
+	defer func() { p.scanned = nil }()
+
+	// Hiding the /Encrypt key of the last trailer keeps the library from
+	// decrypting the document again
+	if _, err := p.readXrefSection(p.startxref); err != nil {
+		return nil, err
+	}
+	for _, t := range tokens {
+		if t.tok == pdfName("Encrypt") {
+			d.patches = append(d.patches, pdfPatch{offset: t.start, length: t.end - t.start, data: padPDFData([]byte("/NoCrypt"), t.end-t.start)})
+		}
+	}
+
+	encryptRef, _ := p.trailer["Encrypt"].(pdfRef)
+	for num, entry := range p.xref {
+		if entry.stream != 0 || num == encryptRef.num {
+			continue
+		}
+		tokens = tokens[:0]
+		ref, obj, err := p.readIndirectAt(entry.offset)
+		if err != nil {
+			continue
+		}
+		objectKey := enc.objectKey(key, ref)
+
+		dict, _ := obj.(pdfDict)
+		stream, isStream := obj.(*pdfStream)
+		if isStream {
+			dict = stream.dict
+		}
+		if dict["Type"] == pdfName("XRef") {
+			continue
+		}
+
+		if enc.strF != cryptIdentity {
+			for _, t := range tokens {
+				if s, ok := t.tok.(pdfString); ok {
+					d.patches = append(d.patches, pdfPatch{offset: t.start, length: t.end - t.start, data: pdfStringPatch(decryptAES(objectKey, s), t.end-t.start)})
+				}
+			}
+		}
+		if isStream && enc.streamMethod(stream.dict) != cryptIdentity {
+			d.patches = append(d.patches, pdfPatch{offset: stream.offset, length: stream.length, key: objectKey})
+		}
+	}
+
+	sort.Slice(d.patches, func(i, j int) bool { return d.patches[i].offset < d.patches[j].offset })
+
+	// Raw objects are read through the view from now on
+	p.f = d
+	p.objectStreams = make(map[int][]pdfObject)
+	return d, nil
+}
+
+// streamMethod returns the crypt filter method of a stream: Identity for
+// unencrypted metadata, otherwise the /Crypt filter's or /StmF's
+func (enc *pdfEncryption) streamMethod(dict pdfDict) string {
+	if dict["Type"] == pdfName("Metadata") && !enc.encryptMetadata {
+		return cryptIdentity
+	}
+
+	filters, isArray := dict["Filter"].(pdfArray)
+	if !isArray {
+		filters = pdfArray{dict["Filter"]}
+	}
+	params, isArray := dict["DecodeParms"].(pdfArray)
+	if !isArray {
+		params = pdfArray{dict["DecodeParms"]}
+	}
+	if filters[0] == pdfName("Crypt") && len(params) > 0 {
+		paramsDict, _ := params[0].(pdfDict)
+		name, _ := paramsDict["Name"].(pdfName)
+		if method, ok := enc.filter(name); ok {
+			return method
+		}
+	}
+	return enc.stmF
+}
@@ -0,0 +1,465 @@
+package extractor
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dslipak/pdf"
+)
+
+// PDFResult contains the extraction result for a PDF together with
+// document-level information that has no place in the generic result
+type PDFResult struct {
+	*ExtractResult
+
+	// Info holds the trailer /Info dictionary
+	Info PDFInfo
+
+	// XMP holds the catalog's XMP metadata stream, or nil if the document has none
+	XMP *XMPMetadata
+
+	// Outline holds the top-level bookmarks
+	Outline []PDFOutlineItem
+
+	// PageLabels holds the label of every page, indexed from page 1, or nil
+	// if the document does not define page labels
+	PageLabels []string
+
+	// Fields holds the terminal fields of the interactive form
+	Fields []PDFFormField
+
+	// Annotations holds the text-bearing annotations of the extracted pages
+	Annotations []PDFAnnotation
+
+	// Tables holds the tables detected on the extracted pages when table detection is enabled
+	Tables []PDFTable
+}
+
+// PDFInfo holds the fields of the document information dictionary
+type PDFInfo struct {
+	Title        string
+	Author       string
+	Subject      string
+	Keywords     string
+	Creator      string
+	Producer     string
+	CreationDate time.Time
+	ModDate      time.Time
+}
+
+// PDFOutlineItem is a bookmark in the document outline
+type PDFOutlineItem struct {
+	Title string
+
+	// Page is the destination page number, or 0 if it could not be resolved
+	Page int
+
+	Children []PDFOutlineItem
+}
+
+// readDocumentInfo fills in the document-level fields of the result. Missing
+// or malformed structures are skipped rather than failing the extraction.
+func (e *PDFExtractor) readDocumentInfo(doc *pdfDocument, result *PDFResult) {
+	r := doc.r
+	pageCount := r.NumPage()
+
+	recoverPDF(func() {
+		result.Info = readPDFInfo(r.Trailer().Key("Info"))
+	})
+
+	recoverPDF(func() {
+		if metadata := r.Trailer().Key("Root").Key("Metadata"); metadata.Kind() == pdf.Stream {
+			result.XMP = readPDFXMP(metadata)
+		}
+	})
+
+	recoverPDF(func() {
+		if labels := r.Trailer().Key("Root").Key("PageLabels"); !labels.IsNull() {
+			result.PageLabels = readPageLabels(labels, pageCount)
+		}
+	})
+
+	recoverPDF(func() {
+		root := doc.root()
+		if outlines := root.key("Outlines"); !outlines.IsNull() {
+			result.Outline = readOutline(outlines.key("First"), root, pageNumbers(doc.pageRefs()), 0)
+		}
+	})
+}
+
+// addDocumentMetadata copies document information into the flat metadata map,
+// falling back to XMP properties where the /Info dictionary is silent
+func (e *PDFExtractor) addDocumentMetadata(result *PDFResult, metadata map[string]interface{}) {
+	info := result.Info
+	if xmp := result.XMP; xmp != nil {
+		if info.Title == "" {
+			info.Title = xmp.Title
+		}
+		if info.Author == "" {
+			info.Author = strings.Join(xmp.Creators, ", ")
+		}
+		if info.Subject == "" {
+			info.Subject = xmp.Description
+		}
+		if info.Keywords == "" {
+			info.Keywords = xmp.Keywords
+		}
+		if info.Creator == "" {
+			info.Creator = xmp.CreatorTool
+		}
+		if info.Producer == "" {
+			info.Producer = xmp.Producer
+		}
+		if info.CreationDate.IsZero() {
+			info.CreationDate = xmp.CreateDate
+		}
+		if info.ModDate.IsZero() {
+			info.ModDate = xmp.ModifyDate
+		}
+	}
+
+	fields := map[string]string{
+		"title":    info.Title,
+		"author":   info.Author,
+		"subject":  info.Subject,
+		"keywords": info.Keywords,
+		"creator":  info.Creator,
+		"producer": info.Producer,
+	}
+	for key, value := range fields {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	if !info.CreationDate.IsZero() {
+		metadata["creation_date"] = info.CreationDate.Format(time.RFC3339)
+	}
+	if !info.ModDate.IsZero() {
+		metadata["mod_date"] = info.ModDate.Format(time.RFC3339)
+	}
+	if len(result.Outline) > 0 {
+		metadata["outline_items"] = countOutlineItems(result.Outline)
+	}
+}
+
+// countOutlineItems counts the bookmarks in an outline, including nested ones
+func countOutlineItems(items []PDFOutlineItem) int {
+	count := len(items)
+	for _, item := range items {
+		count += countOutlineItems(item.Children)
+	}
+	return count
+}
+
+// recoverPDF runs fn, discarding the panics the PDF library raises on
+// malformed objects and on AES-encrypted strings
+func recoverPDF(fn func()) {
+	defer func() {
+		recover()
+	}()
+	fn()
+}
+
+// readPDFInfo reads the document information dictionary
+func readPDFInfo(info pdf.Value) PDFInfo {
+	result := PDFInfo{
+		Title:    strings.TrimSpace(info.Key("Title").Text()),
+		Author:   strings.TrimSpace(info.Key("Author").Text()),
+		Subject:  strings.TrimSpace(info.Key("Subject").Text()),
+		Keywords: strings.TrimSpace(info.Key("Keywords").Text()),
+		Creator:  strings.TrimSpace(info.Key("Creator").Text()),
+		Producer: strings.TrimSpace(info.Key("Producer").Text()),
+	}
+	result.CreationDate, _ = parsePDFDate(info.Key("CreationDate").Text())
+	result.ModDate, _ = parsePDFDate(info.Key("ModDate").Text())
+	return result
+}
+
+// parsePDFDate parses a PDF date string of the form D:YYYYMMDDHHmmSSOHH'mm'.
+// Every component after the year is optional.
+func parsePDFDate(value string) (time.Time, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "D:")
+	if len(value) < 4 {
+		return time.Time{}, false
+	}
+
+	// Split the digits from the time zone designator
+	end := 0
+	for end < len(value) && end < 14 && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+	digits, zone := value[:end], value[end:]
+	if len(digits) < 4 || len(digits)%2 != 0 {
+		return time.Time{}, false
+	}
+
+	// Fill in missing components with their defaults
+	digits += "0101000000"[len(digits)-4:]
+	parsed, err := time.Parse("20060102150405", digits)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	zone = strings.ReplaceAll(zone, "'", "")
+	if zone == "" || zone[0] == 'Z' {
+		return parsed, true
+	}
+
+	sign := 1
+	switch zone[0] {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		return parsed, true
+	}
+	hours, minutes := 0, 0
+	if len(zone) >= 3 {
+		hours, _ = strconv.Atoi(zone[1:3])
+	}
+	if len(zone) >= 5 {
+		minutes, _ = strconv.Atoi(zone[3:5])
+	}
+	offset := sign * (hours*3600 + minutes*60)
+	location := time.FixedZone(fmt.Sprintf("%s%02d:%02d", zone[:1], hours, minutes), offset)
+
+	return time.Date(parsed.Year(), parsed.Month(), parsed.Day(),
+		parsed.Hour(), parsed.Minute(), parsed.Second(), 0, location), true
+}
+
+// readPDFXMP reads and parses an XMP metadata stream
+func readPDFXMP(stream pdf.Value) *XMPMetadata {
+	reader := stream.Reader()
+	defer reader.Close()
+
+	raw, err := io.ReadAll(reader)
+	if err != nil || len(raw) == 0 {
+		return nil
+	}
+
+	return parseXMP(raw)
+}
+
+// pageNumbers maps the reference of each page object to its page number
+func pageNumbers(refs []pdfRef) map[pdfRef]int {
+	pages := make(map[pdfRef]int, len(refs))
+	for i, ref := range refs {
+		if _, exists := pages[ref]; !exists && ref.num != 0 {
+			pages[ref] = i + 1
+		}
+	}
+	return pages
+}
+
+// maxPDFTreeDepth bounds recursion into malformed, cyclic outline, name and field trees
+const maxPDFTreeDepth = 32
+
+// maxPDFOutlineItems bounds a Next chain whose loops cannot be detected
+// because its object references are unknown
+const maxPDFOutlineItems = 10000
+
+// readOutline reads a linked list of outline items and their children
+func readOutline(item pdfNode, root pdfNode, pages map[pdfRef]int, depth int) []PDFOutlineItem {
+	if depth > maxPDFTreeDepth {
+		return nil
+	}
+
+	var items []PDFOutlineItem
+	seen := make(map[pdfRef]bool)
+	for ; item.Kind() == pdf.Dict && len(items) < maxPDFOutlineItems; item = item.key("Next") {
+		// Guard against Next chains that loop back on themselves
+		if item.ref.num != 0 {
+			if seen[item.ref] {
+				break
+			}
+			seen[item.ref] = true
+		}
+
+		destination := item.key("Dest")
+		if destination.IsNull() {
+			if action := item.key("A"); action.Key("S").Name() == "GoTo" {
+				destination = action.key("D")
+			}
+		}
+
+		items = append(items, PDFOutlineItem{
+			Title:    strings.TrimSpace(item.Key("Title").Text()),
+			Page:     destinationPage(destination, root, pages),
+			Children: readOutline(item.key("First"), root, pages, depth+1),
+		})
+	}
+	return items
+}
+
+// destinationPage resolves an explicit or named destination to a page number
+func destinationPage(destination pdfNode, root pdfNode, pages map[pdfRef]int) int {
+	// Named destinations are looked up in the catalog's name tree or /Dests dictionary
+	switch destination.Kind() {
+	case pdf.String, pdf.Name:
+		key := destination.RawString()
+		if destination.Kind() == pdf.Name {
+			key = destination.Name()
+		}
+		resolved := lookupNameTree(root.key("Names").key("Dests"), key, 0)
+		if resolved.IsNull() {
+			resolved = root.key("Dests").key(key)
+		}
+		destination = resolved
+	}
+
+	// A destination dictionary wraps the array in /D
+	if destination.Kind() == pdf.Dict {
+		destination = destination.key("D")
+	}
+	if destination.Kind() != pdf.Array || destination.Len() == 0 {
+		return 0
+	}
+
+	target := destination.index(0)
+	if target.Kind() == pdf.Integer {
+		// Remote destinations use a zero-based page index
+		return int(target.Int64()) + 1
+	}
+	return pages[target.ref]
+}
+
+// lookupNameTree finds a key in a name tree
+func lookupNameTree(node pdfNode, key string, depth int) pdfNode {
+	if node.IsNull() || depth > maxPDFTreeDepth {
+		return pdfNode{}
+	}
+
+	names := node.key("Names")
+	for i := 0; i+1 < names.Len(); i += 2 {
+		if names.Index(i).RawString() == key {
+			return names.index(i + 1)
+		}
+	}
+
+	kids := node.key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		kid := kids.index(i)
+		limits := kid.Key("Limits")
+		if limits.Len() == 2 && (key < limits.Index(0).RawString() || key > limits.Index(1).RawString()) {
+			continue
+		}
+		if found := lookupNameTree(kid, key, depth+1); !found.IsNull() {
+			return found
+		}
+	}
+	return pdfNode{}
+}
+
+// pageLabelRange is one entry of the /PageLabels number tree
+type pageLabelRange struct {
+	start  int
+	style  string
+	prefix string
+	first  int
+}
+
+// readPageLabels computes the label of every page from the /PageLabels number tree
+func readPageLabels(tree pdf.Value, pageCount int) []string {
+	var ranges []pageLabelRange
+	collectPageLabelRanges(tree, &ranges, 0)
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	labels := make([]string, pageCount)
+	for index := 0; index < pageCount; index++ {
+		// Ranges are ordered by starting page; find the last one that applies
+		current := -1
+		for i, labelRange := range ranges {
+			if labelRange.start <= index {
+				current = i
+			}
+		}
+		if current < 0 {
+			labels[index] = strconv.Itoa(index + 1)
+			continue
+		}
+
+		labelRange := ranges[current]
+		labels[index] = labelRange.prefix + formatPageNumber(labelRange.first+index-labelRange.start, labelRange.style)
+	}
+	return labels
+}
+
+// collectPageLabelRanges walks a number tree, collecting its entries in order
+func collectPageLabelRanges(node pdf.Value, ranges *[]pageLabelRange, depth int) {
+	if node.IsNull() || depth > maxPDFTreeDepth {
+		return
+	}
+
+	nums := node.Key("Nums")
+	for i := 0; i+1 < nums.Len(); i += 2 {
+		label := nums.Index(i + 1)
+		first := 1
+		if start := label.Key("St"); start.Kind() == pdf.Integer {
+			first = int(start.Int64())
+		}
+		*ranges = append(*ranges, pageLabelRange{
+			start:  int(nums.Index(i).Int64()),
+			style:  label.Key("S").Name(),
+			prefix: label.Key("P").Text(),
+			first:  first,
+		})
+	}
+
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		collectPageLabelRanges(kids.Index(i), ranges, depth+1)
+	}
+}
+
+// formatPageNumber formats a page number in a /PageLabels numbering style.
+// An empty style produces no number, leaving only the prefix.
+func formatPageNumber(number int, style string) string {
+	switch style {
+	case "D":
+		return strconv.Itoa(number)
+	case "R":
+		return toRoman(number)
+	case "r":
+		return strings.ToLower(toRoman(number))
+	case "A":
+		return toLetters(number)
+	case "a":
+		return strings.ToLower(toLetters(number))
+	default:
+		return ""
+	}
+}
+
+// toRoman converts a positive number to upper-case roman numerals
+func toRoman(number int) string {
+	if number <= 0 {
+		return strconv.Itoa(number)
+	}
+
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+
+	var builder strings.Builder
+	for i, value := range values {
+		for number >= value {
+			builder.WriteString(symbols[i])
+			number -= value
+		}
+	}
+	return builder.String()
+}
+
+// toLetters converts a positive number to letters as page labels do:
+// A to Z, then AA to ZZ, then AAA to ZZZ and so on
+func toLetters(number int) string {
+	if number <= 0 {
+		return strconv.Itoa(number)
+	}
+	letter := string(rune('A' + (number-1)%26))
+	return strings.Repeat(letter, (number-1)/26+1)
+}
@@ -0,0 +1,202 @@
+package extractor
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dslipak/pdf"
+)
+
+// PDFFormField is a terminal field of the document's interactive form
+type PDFFormField struct {
+	// Name is the fully qualified field name, with parent names joined by periods
+	Name string
+
+	// Type is "text", "button", "choice" or "signature"
+	Type string
+
+	// Value is the field value; multiple selections are joined with ", "
+	Value string
+
+	// Page is the number of the page showing the field, or 0 if it has no widget on an extracted page
+	Page int
+}
+
+// PDFAnnotation is a page annotation carrying text, such as a sticky note,
+// free text box or commented highlight
+type PDFAnnotation struct {
+	// Page is the number of the page the annotation is on
+	Page int
+
+	// Subtype is the annotation subtype, such as "Text", "FreeText" or "Highlight"
+	Subtype string
+
+	// Contents is the annotation text
+	Contents string
+
+	// Author is the annotation's /T entry, normally the author's name
+	Author string
+}
+
+// pdfFieldTypes maps /FT values to field type names
+var pdfFieldTypes = map[string]string{
+	"Tx":  "text",
+	"Btn": "button",
+	"Ch":  "choice",
+	"Sig": "signature",
+}
+
+// pdfFormReader walks the AcroForm field tree, remembering which widget
+// annotations belong to which field so page numbers can be filled in later
+type pdfFormReader struct {
+	fields []PDFFormField
+
+	// widgets maps the references of widget annotations to field indexes
+	widgets map[pdfRef]int
+}
+
+// readFormFields reads every terminal field of the document's AcroForm
+func readFormFields(root pdfNode) *pdfFormReader {
+	forms := &pdfFormReader{widgets: make(map[pdfRef]int)}
+	recoverPDF(func() {
+		fields := root.key("AcroForm").key("Fields")
+		for i := 0; i < fields.Len(); i++ {
+			forms.walk(fields.index(i), "", "", pdf.Value{}, 0)
+		}
+	})
+	return forms
+}
+
+// walk visits a field node, inheriting the name, type and value from its parents
+func (f *pdfFormReader) walk(node pdfNode, parentName, fieldType string, value pdf.Value, depth int) {
+	if node.Kind() != pdf.Dict || depth > maxPDFTreeDepth {
+		return
+	}
+
+	name := parentName
+	if partial := node.Key("T").Text(); partial != "" {
+		if name != "" {
+			name += "."
+		}
+		name += partial
+	}
+	if ft := node.Key("FT").Name(); ft != "" {
+		fieldType = ft
+	}
+	if v := node.Key("V"); !v.IsNull() {
+		value = v
+	}
+
+	// Kids are either child fields, which have their own partial name, or
+	// the widget annotations of this field
+	kids := node.key("Kids")
+	var widgets []pdfNode
+	hasChildFields := false
+	for i := 0; i < kids.Len(); i++ {
+		kid := kids.index(i)
+		if !kid.Key("T").IsNull() {
+			hasChildFields = true
+			f.walk(kid, name, fieldType, value, depth+1)
+		} else {
+			widgets = append(widgets, kid)
+		}
+	}
+	if hasChildFields && len(widgets) == 0 {
+		return
+	}
+
+	index := len(f.fields)
+	f.fields = append(f.fields, PDFFormField{
+		Name:  name,
+		Type:  pdfFieldTypes[fieldType],
+		Value: formatFieldValue(value),
+	})
+
+	// A field with a single widget may be merged with it into one dictionary
+	for _, widget := range append(widgets, node) {
+		if widget.ref.num != 0 {
+			f.widgets[widget.ref] = index
+		}
+	}
+}
+
+// formatFieldValue renders a field value as text
+func formatFieldValue(value pdf.Value) string {
+	switch value.Kind() {
+	case pdf.String:
+		return strings.TrimSpace(value.Text())
+	case pdf.Name:
+		return value.Name()
+	case pdf.Array:
+		var values []string
+		for i := 0; i < value.Len(); i++ {
+			if v := formatFieldValue(value.Index(i)); v != "" {
+				values = append(values, v)
+			}
+		}
+		return strings.Join(values, ", ")
+	case pdf.Integer:
+		return strconv.FormatInt(value.Int64(), 10)
+	case pdf.Real:
+		return strconv.FormatFloat(value.Float64(), 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// readPageAnnotations collects the text-bearing annotations of a page and
+// assigns the page number to the form fields whose widgets appear on it.
+// It returns the form fields shown on the page in annotation order.
+func (f *pdfFormReader) readPageAnnotations(page pdfNode, pageNum int) ([]PDFAnnotation, []PDFFormField) {
+	var annotations []PDFAnnotation
+	var fields []PDFFormField
+
+	recoverPDF(func() {
+		annots := page.key("Annots")
+		for i := 0; i < annots.Len(); i++ {
+			annot := annots.index(i)
+			subtype := annot.Key("Subtype").Name()
+
+			switch subtype {
+			case "Widget":
+				index, ok := f.widgets[annot.ref]
+				if !ok {
+					continue
+				}
+				if f.fields[index].Page == 0 {
+					f.fields[index].Page = pageNum
+					fields = append(fields, f.fields[index])
+				}
+			case "Link", "Popup":
+				// Links carry no text and popups repeat their parent's contents
+			default:
+				contents := strings.TrimSpace(annot.Key("Contents").Text())
+				if contents == "" {
+					continue
+				}
+				annotations = append(annotations, PDFAnnotation{
+					Page:     pageNum,
+					Subtype:  subtype,
+					Contents: contents,
+					Author:   strings.TrimSpace(annot.Key("T").Text()),
+				})
+			}
+		}
+	})
+
+	return annotations, fields
+}
+
+// formatFormText renders form fields and annotations for inclusion in the page text
+func formatFormText(fields []PDFFormField, annotations []PDFAnnotation) string {
+	var lines []string
+	for _, field := range fields {
+		if field.Value != "" {
+			lines = append(lines, field.Name+": "+field.Value)
+		}
+	}
+	for _, annotation := range annotations {
+		lines = append(lines, "["+annotation.Subtype+"] "+annotation.Contents)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,353 @@
+package extractor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"strings"
+
+	"github.com/dslipak/pdf"
+)
+
+// maxPDFImagePixels bounds the size of an embedded image decoded for OCR
+const maxPDFImagePixels = 1 << 26
+
+// pdfPageGraphics summarizes what a page's content streams draw
+type pdfPageGraphics struct {
+	// hasText reports whether any text-showing operator is used
+	hasText bool
+
+	// images holds the image XObjects drawn, in drawing order
+	images []pdfNode
+}
+
+// inspectPageGraphics looks for text-showing operators and drawn images in
+// the page content, including form XObjects the page draws
+func inspectPageGraphics(page pdfNode) (graphics pdfPageGraphics, err error) {
+	// The PDF library panics on malformed content streams
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to read page content: %v", r)
+		}
+	}()
+
+	resources := pageResources(page)
+	contents := page.Key("Contents")
+	if contents.Kind() == pdf.Array {
+		for i := 0; i < contents.Len(); i++ {
+			graphics.inspect(contents.Index(i), resources, 0)
+		}
+	} else {
+		graphics.inspect(contents, resources, 0)
+	}
+	return graphics, nil
+}
+
+// pageResources returns the resources of a page, which may be inherited from
+// its ancestors in the page tree
+func pageResources(page pdfNode) pdfNode {
+	for node, depth := page, 0; node.Kind() == pdf.Dict && depth <= maxPDFTreeDepth; node, depth = node.key("Parent"), depth+1 {
+		if resources := node.key("Resources"); !resources.IsNull() {
+			return resources
+		}
+	}
+	return pdfNode{}
+}
+
+// inspect interprets one content stream with the given resources
+func (g *pdfPageGraphics) inspect(stream pdf.Value, resources pdfNode, depth int) {
+	if stream.Kind() != pdf.Stream || depth > maxPDFTreeDepth {
+		return
+	}
+
+	pdf.Interpret(stream, func(stk *pdf.Stack, op string) {
+		args := make([]pdf.Value, stk.Len())
+		for i := len(args) - 1; i >= 0; i-- {
+			args[i] = stk.Pop()
+		}
+
+		switch op {
+		case "Tj", "TJ", "'", "\"":
+			g.hasText = true
+		case "Do":
+			if len(args) != 1 {
+				return
+			}
+			xobject := resources.key("XObject").key(args[0].Name())
+			switch xobject.Key("Subtype").Name() {
+			case "Image":
+				g.images = append(g.images, xobject)
+			case "Form":
+				formResources := xobject.key("Resources")
+				if formResources.IsNull() {
+					formResources = resources
+				}
+				g.inspect(xobject.Value, formResources, depth+1)
+			}
+		}
+	})
+}
+
+// recognizePage fills in the text of a scanned page by OCR when an engine is
+// configured, recording a failure as the page's error
+func (e *PDFExtractor) recognizePage(pageResult *PageResult, images []pdfNode, doc *pdfDocument, options ExtractOptions) {
+	if e.OCR == nil {
+		return
+	}
+
+	text, confidence, err := e.recognizePageImages(images, doc, options)
+	if err != nil {
+		pageResult.Err = fmt.Errorf("page %d: OCR failed: %w", pageResult.Number, err)
+		return
+	}
+	pageResult.Text = text
+	pageResult.Metadata["ocr"] = true
+	pageResult.Metadata["ocr_confidence"] = confidence
+}
+
+// recognizePageImages runs the OCR engine over the images of a scanned page
+// and returns their text in drawing order with the mean confidence
+func (e *PDFExtractor) recognizePageImages(images []pdfNode, doc *pdfDocument, options ExtractOptions) (string, float64, error) {
+	var texts []string
+	confidence := 0.0
+	for i, xobject := range images {
+		img, err := decodePDFImage(xobject, doc)
+		if err != nil {
+			return "", 0, fmt.Errorf("image %d: %w", i+1, err)
+		}
+
+		recognized, err := e.OCR.Recognize(img, OCROptions{Language: options.OCRLanguage, Timeout: options.Timeout})
+		if err != nil {
+			return "", 0, fmt.Errorf("image %d: %w", i+1, err)
+		}
+		if text := strings.TrimSpace(recognized.Text); text != "" {
+			texts = append(texts, text)
+		}
+		confidence += recognized.Confidence
+	}
+	return strings.Join(texts, "\n\n"), confidence / float64(len(images)), nil
+}
+
+// decodePDFImage decodes an image XObject. JPEG images are decoded from the
+// raw stream data; other images must hold uncompressed, Flate or ASCII85
+// encoded samples.
+func decodePDFImage(xobject pdfNode, doc *pdfDocument) (img image.Image, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			img = nil
+			err = fmt.Errorf("failed to read image: %v", r)
+		}
+	}()
+
+	var filters []string
+	switch filter := xobject.Key("Filter"); filter.Kind() {
+	case pdf.Name:
+		filters = []string{filter.Name()}
+	case pdf.Array:
+		for i := 0; i < filter.Len(); i++ {
+			filters = append(filters, filter.Index(i).Name())
+		}
+	}
+
+	if len(filters) == 1 && filters[0] == "DCTDecode" {
+		// The PDF library cannot decode JPEG streams, so the data is read directly
+		if doc.rawEncrypted {
+			return nil, errors.New("JPEG images in encrypted documents are not supported")
+		}
+		data, err := rawPDFStream(xobject)
+		if err != nil {
+			return nil, err
+		}
+		return jpeg.Decode(bytes.NewReader(data))
+	}
+
+	for _, filter := range filters {
+		if filter != "FlateDecode" && filter != "ASCII85Decode" {
+			return nil, fmt.Errorf("unsupported image filter %s", filter)
+		}
+	}
+
+	data, err := io.ReadAll(xobject.Reader())
+	if err != nil {
+		return nil, err
+	}
+	return sampledImage(xobject.Value, data)
+}
+
+// rawPDFStream reads the undecoded data of a stream through the file's
+// cross-reference data
+func rawPDFStream(stream pdfNode) ([]byte, error) {
+	raw, ok := stream.raw.(*pdfStream)
+	if !ok {
+		return nil, errors.New("stream data not found")
+	}
+	if raw.length <= 0 || raw.length > maxPDFImagePixels*4 {
+		return nil, fmt.Errorf("invalid stream length %d", raw.length)
+	}
+	data, err := stream.file.streamData(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream data: %w", err)
+	}
+	return data, nil
+}
+
+// pdfColorSpace describes how image samples map to colors
+type pdfColorSpace struct {
+	components int
+
+	// palette holds the base color space components of each index for Indexed color spaces
+	palette [][]byte
+}
+
+// readColorSpace interprets an image's /ColorSpace entry
+func readColorSpace(xobject pdf.Value) (pdfColorSpace, error) {
+	if xobject.Key("ImageMask").Bool() {
+		return pdfColorSpace{components: 1}, nil
+	}
+
+	cs := xobject.Key("ColorSpace")
+	if cs.Kind() == pdf.Array && cs.Index(0).Name() == "Indexed" {
+		base := colorSpaceComponents(cs.Index(1))
+		if base == 0 {
+			return pdfColorSpace{}, fmt.Errorf("unsupported indexed base color space %v", cs.Index(1))
+		}
+
+		var lookup []byte
+		if table := cs.Index(3); table.Kind() == pdf.Stream {
+			data, err := io.ReadAll(table.Reader())
+			if err != nil {
+				return pdfColorSpace{}, err
+			}
+			lookup = data
+		} else {
+			lookup = []byte(table.RawString())
+		}
+
+		entries := int(cs.Index(2).Int64()) + 1
+		palette := make([][]byte, 0, entries)
+		for i := 0; i < entries && (i+1)*base <= len(lookup); i++ {
+			palette = append(palette, lookup[i*base:(i+1)*base])
+		}
+		return pdfColorSpace{components: 1, palette: palette}, nil
+	}
+
+	components := colorSpaceComponents(cs)
+	if components == 0 {
+		return pdfColorSpace{}, fmt.Errorf("unsupported color space %v", cs)
+	}
+	return pdfColorSpace{components: components}, nil
+}
+
+// colorSpaceComponents returns the number of components of a device, calibrated
+// or ICC-based color space, or 0 if it is not one of those
+func colorSpaceComponents(cs pdf.Value) int {
+	name := cs.Name()
+	if cs.Kind() == pdf.Array {
+		name = cs.Index(0).Name()
+	}
+	switch name {
+	case "DeviceGray", "CalGray", "G":
+		return 1
+	case "DeviceRGB", "CalRGB", "RGB":
+		return 3
+	case "DeviceCMYK", "CMYK":
+		return 4
+	case "ICCBased":
+		return int(cs.Index(1).Key("N").Int64())
+	}
+	return 0
+}
+
+// sampledImage builds an image from decoded sample data
+func sampledImage(xobject pdf.Value, data []byte) (image.Image, error) {
+	width := int(xobject.Key("Width").Int64())
+	height := int(xobject.Key("Height").Int64())
+	if width <= 0 || height <= 0 || width*height > maxPDFImagePixels {
+		return nil, fmt.Errorf("invalid image size %dx%d", width, height)
+	}
+
+	cs, err := readColorSpace(xobject)
+	if err != nil {
+		return nil, err
+	}
+
+	bits := int(xobject.Key("BitsPerComponent").Int64())
+	if xobject.Key("ImageMask").Bool() {
+		bits = 1
+	}
+	if bits != 1 && bits != 2 && bits != 4 && bits != 8 {
+		return nil, fmt.Errorf("unsupported bits per component %d", bits)
+	}
+
+	rowBytes := (width*cs.components*bits + 7) / 8
+	if len(data) < rowBytes*height {
+		return nil, errors.New("image data is truncated")
+	}
+
+	// A /Decode array of [1 0] inverts single-component images
+	decode := xobject.Key("Decode")
+	invert := cs.palette == nil && cs.components == 1 && decode.Len() == 2 && decode.Index(0).Float64() > decode.Index(1).Float64()
+	maxValue := 1<<bits - 1
+
+	sample := func(row []byte, index int) int {
+		bit := index * bits
+		return int(row[bit/8]>>(8-bits-bit%8)) & maxValue
+	}
+	scale := func(value int) uint8 {
+		return uint8(value * 255 / maxValue)
+	}
+
+	if cs.components == 1 && cs.palette == nil {
+		img := image.NewGray(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			row := data[y*rowBytes : (y+1)*rowBytes]
+			for x := 0; x < width; x++ {
+				value := scale(sample(row, x))
+				if invert {
+					value = 255 - value
+				}
+				img.Pix[y*img.Stride+x] = value
+			}
+		}
+		return img, nil
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		row := data[y*rowBytes : (y+1)*rowBytes]
+		for x := 0; x < width; x++ {
+			var components []uint8
+			if cs.palette != nil {
+				index := sample(row, x)
+				if index >= len(cs.palette) {
+					continue
+				}
+				components = cs.palette[index]
+			} else {
+				components = make([]uint8, cs.components)
+				for c := range components {
+					components[c] = scale(sample(row, x*cs.components+c))
+				}
+			}
+			img.Set(x, y, componentsColor(components))
+		}
+	}
+	return img, nil
+}
+
+// componentsColor converts gray, RGB or CMYK components to a color
+func componentsColor(components []uint8) color.Color {
+	switch len(components) {
+	case 1:
+		return color.Gray{Y: components[0]}
+	case 3:
+		return color.RGBA{R: components[0], G: components[1], B: components[2], A: 255}
+	case 4:
+		return color.CMYK{C: components[0], M: components[1], Y: components[2], K: components[3]}
+	}
+	return color.Black
+}
@@ -0,0 +1,319 @@
+package extractor
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/dslipak/pdf"
+)
+
+// Layout thresholds, expressed as fractions of the font size
+const (
+	// layoutLineTolerance is how far apart two baselines may be and still form one line
+	layoutLineTolerance = 0.5
+
+	// layoutWordGap is the horizontal gap between glyphs that starts a new word
+	layoutWordGap = 0.15
+
+	// layoutParagraphGap is the line pitch, relative to the typical pitch, that starts a new paragraph
+	layoutParagraphGap = 1.4
+
+	// layoutGutterWidth is the minimum width of the empty band separating two columns
+	layoutGutterWidth = 1.5
+
+	// layoutDefaultFontSize is used when a glyph carries no usable font size
+	layoutDefaultFontSize = 10
+)
+
+// layoutLine is a run of glyphs sharing a baseline, ordered left to right
+type layoutLine struct {
+	glyphs []pdf.Text
+	y      float64
+	size   float64
+	minX   float64
+	maxX   float64
+}
+
+// reconstructPageText rebuilds words, lines and paragraphs from positioned
+// glyphs. Spaces are inserted where the gap between glyphs is wide enough,
+// glyphs are grouped into lines by baseline and lines are emitted top to
+// bottom. When detectColumns is set and the page has a clear vertical gutter,
+// each column is read in full before the next one.
+func reconstructPageText(glyphs []pdf.Text, detectColumns bool) string {
+	lines := groupLines(glyphs)
+	if len(lines) == 0 {
+		return ""
+	}
+
+	if detectColumns {
+		if gutter, ok := findGutter(lines); ok {
+			return renderLines(orderColumns(lines, gutter))
+		}
+	}
+
+	return renderLines([][]*layoutLine{lines})
+}
+
+// groupLines clusters glyphs into lines by baseline, top to bottom
+func groupLines(glyphs []pdf.Text) []*layoutLine {
+	sorted := make([]pdf.Text, 0, len(glyphs))
+	for _, glyph := range glyphs {
+		if glyph.S != "" {
+			sorted = append(sorted, glyph)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Y != sorted[j].Y {
+			return sorted[i].Y > sorted[j].Y
+		}
+		return sorted[i].X < sorted[j].X
+	})
+
+	var lines []*layoutLine
+	var current *layoutLine
+	for _, glyph := range sorted {
+		size := glyphSize(glyph)
+		if current == nil || current.y-glyph.Y > layoutLineTolerance*math.Max(size, current.size) {
+			current = &layoutLine{y: glyph.Y, size: size}
+			lines = append(lines, current)
+		}
+		current.glyphs = append(current.glyphs, glyph)
+		current.size = math.Max(current.size, size)
+	}
+
+	for _, line := range lines {
+		line.sortGlyphs()
+	}
+
+	return lines
+}
+
+// sortGlyphs orders the line's glyphs left to right and updates its extent
+func (l *layoutLine) sortGlyphs() {
+	sort.SliceStable(l.glyphs, func(i, j int) bool {
+		return l.glyphs[i].X < l.glyphs[j].X
+	})
+	l.minX = math.Inf(1)
+	l.maxX = math.Inf(-1)
+	for _, glyph := range l.glyphs {
+		l.minX = math.Min(l.minX, glyph.X)
+		l.maxX = math.Max(l.maxX, glyph.X+glyphWidth(glyph))
+	}
+}
+
+// text renders the line, inserting spaces at word gaps
+func (l *layoutLine) text() string {
+	var builder strings.Builder
+	lastSpace := true
+	prevEnd := 0.0
+	for i, glyph := range l.glyphs {
+		if i > 0 && !lastSpace && !strings.HasPrefix(glyph.S, " ") && glyph.X-prevEnd > layoutWordGap*glyphSize(glyph) {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(glyph.S)
+		lastSpace = strings.HasSuffix(glyph.S, " ")
+		prevEnd = glyph.X + glyphWidth(glyph)
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// split divides the line at the gutter into a left and a right part.
+// It reports false when a glyph straddles the gutter.
+func (l *layoutLine) split(gutter float64) (*layoutLine, *layoutLine, bool) {
+	left := &layoutLine{y: l.y, size: l.size}
+	right := &layoutLine{y: l.y, size: l.size}
+	for _, glyph := range l.glyphs {
+		switch {
+		case glyph.X+glyphWidth(glyph) <= gutter:
+			left.glyphs = append(left.glyphs, glyph)
+		case glyph.X >= gutter:
+			right.glyphs = append(right.glyphs, glyph)
+		case strings.TrimSpace(glyph.S) == "":
+			// Whitespace in the gutter belongs to neither column
+		default:
+			return nil, nil, false
+		}
+	}
+	left.sortGlyphs()
+	right.sortGlyphs()
+	return left, right, true
+}
+
+// findGutter looks for an empty vertical band in the middle of the text area
+// that separates two columns of roughly similar height
+func findGutter(lines []*layoutLine) (float64, bool) {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	sizes := make([]float64, 0, len(lines))
+	for _, line := range lines {
+		minX = math.Min(minX, line.minX)
+		maxX = math.Max(maxX, line.maxX)
+		sizes = append(sizes, line.size)
+	}
+	width := maxX - minX
+	if len(lines) < 4 || width <= 0 {
+		return 0, false
+	}
+
+	// Mark horizontal coverage in one-point bins, ignoring whitespace glyphs
+	bins := int(math.Ceil(width)) + 1
+	covered := make([]int, bins)
+	for _, line := range lines {
+		for _, glyph := range line.glyphs {
+			if strings.TrimSpace(glyph.S) == "" {
+				continue
+			}
+			start := int(glyph.X - minX)
+			end := int(math.Ceil(glyph.X + glyphWidth(glyph) - minX))
+			for bin := start; bin < end && bin < bins; bin++ {
+				if bin >= 0 {
+					covered[bin]++
+				}
+			}
+		}
+	}
+
+	// Allow a few spanning lines such as titles to cross the gutter
+	allowance := len(lines) / 10
+	if allowance < 1 {
+		allowance = 1
+	}
+	minGap := layoutGutterWidth * median(sizes)
+
+	bestStart, bestWidth := -1, 0
+	runStart := -1
+	for bin := bins / 4; bin <= 3*bins/4; bin++ {
+		if covered[bin] <= allowance {
+			if runStart < 0 {
+				runStart = bin
+			}
+			if run := bin - runStart + 1; run > bestWidth {
+				bestStart, bestWidth = runStart, run
+			}
+		} else {
+			runStart = -1
+		}
+	}
+	if bestStart < 0 || float64(bestWidth) < minGap {
+		return 0, false
+	}
+
+	gutter := minX + float64(bestStart) + float64(bestWidth)/2
+
+	// Both sides need enough lines to be considered columns
+	leftLines, rightLines := 0, 0
+	for _, line := range lines {
+		left, right, ok := line.split(gutter)
+		if !ok {
+			continue
+		}
+		if len(left.glyphs) > 0 {
+			leftLines++
+		}
+		if len(right.glyphs) > 0 {
+			rightLines++
+		}
+	}
+	if leftLines < 2 || rightLines < 2 {
+		return 0, false
+	}
+
+	return gutter, true
+}
+
+// orderColumns arranges lines into reading order for a two-column page.
+// Lines crossing the gutter act as section breaks: the left column of the
+// preceding section is emitted, then its right column, then the spanning line.
+func orderColumns(lines []*layoutLine, gutter float64) [][]*layoutLine {
+	var blocks [][]*layoutLine
+	var left, right []*layoutLine
+
+	flush := func() {
+		if len(left) > 0 {
+			blocks = append(blocks, left)
+		}
+		if len(right) > 0 {
+			blocks = append(blocks, right)
+		}
+		left, right = nil, nil
+	}
+
+	for _, line := range lines {
+		leftPart, rightPart, ok := line.split(gutter)
+		if !ok {
+			flush()
+			blocks = append(blocks, []*layoutLine{line})
+			continue
+		}
+		if len(leftPart.glyphs) > 0 {
+			left = append(left, leftPart)
+		}
+		if len(rightPart.glyphs) > 0 {
+			right = append(right, rightPart)
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// renderLines joins lines into text. Blocks are separated by blank lines, and
+// within a block a blank line is inserted where the vertical gap between lines
+// is noticeably larger than the typical line pitch.
+func renderLines(blocks [][]*layoutLine) string {
+	var paragraphs []string
+
+	for _, block := range blocks {
+		var pitches []float64
+		for i := 1; i < len(block); i++ {
+			pitches = append(pitches, block[i-1].y-block[i].y)
+		}
+		typical := median(pitches)
+
+		var current []string
+		for i, line := range block {
+			text := line.text()
+			if text == "" {
+				continue
+			}
+			if i > 0 && len(current) > 0 && typical > 0 && block[i-1].y-line.y > layoutParagraphGap*typical {
+				paragraphs = append(paragraphs, strings.Join(current, "\n"))
+				current = nil
+			}
+			current = append(current, text)
+		}
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, "\n"))
+		}
+	}
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// glyphSize returns the glyph's font size, falling back to a default
+func glyphSize(glyph pdf.Text) float64 {
+	size := math.Abs(glyph.FontSize)
+	if size == 0 {
+		return layoutDefaultFontSize
+	}
+	return size
+}
+
+// glyphWidth returns the glyph's advance width, estimating it from the font
+// size when the font does not declare widths
+func glyphWidth(glyph pdf.Text) float64 {
+	if glyph.W > 0 {
+		return glyph.W
+	}
+	return 0.5 * glyphSize(glyph) * float64(len([]rune(glyph.S)))
+}
+
+// median returns the median of the values, or 0 when there are none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
@@ -0,0 +1,943 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/dslipak/pdf"
+)
+
+// The PDF library hides object references and raw stream data, so objects
+// are also read here directly through the file's cross-reference data.
+
+// pdfObject is a raw PDF value: a pdfKeyword (numbers, booleans and null),
+// pdfName, pdfString, pdfArray, pdfDict, pdfRef or *pdfStream
+type pdfObject interface{}
+
+type (
+	pdfKeyword string
+	pdfName    string
+	pdfString  []byte
+	pdfArray   []pdfObject
+	pdfDict    map[pdfName]pdfObject
+)
+
+// pdfRef is an indirect object reference
+type pdfRef struct {
+	num, gen int
+}
+
+// pdfStream is a stream object; its undecoded data is read from the file
+// when needed
+type pdfStream struct {
+	dict           pdfDict
+	offset, length int64
+}
+
+// pdfInt returns the value of an integer object
+func pdfInt(obj pdfObject) (int64, bool) {
+	kw, ok := obj.(pdfKeyword)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(string(kw), 10, 64)
+	return n, err == nil
+}
+
+// isPDFSpace reports whether b is a PDF whitespace character
+func isPDFSpace(b byte) bool {
+	return b == 0 || b == '\t' || b == '\n' || b == '\f' || b == '\r' || b == ' '
+}
+
+// isPDFDelimiter reports whether b ends a keyword or name
+func isPDFDelimiter(b byte) bool {
+	switch b {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// pdfLexer reads tokens and objects sequentially from an offset in a file
+type pdfLexer struct {
+	r   *bufio.Reader
+	pos int64
+
+	// pending holds tokens read ahead while looking for references
+	pending []pdfObject
+
+	// scanned, when set, is called with each token read and the byte range
+	// it was read from
+	scanned func(tok pdfObject, start, end int64)
+}
+
+// newPDFLexer creates a lexer reading from offset to the end of the file
+func newPDFLexer(f io.ReaderAt, offset, size int64) *pdfLexer {
+	return &pdfLexer{r: bufio.NewReader(io.NewSectionReader(f, offset, size-offset)), pos: offset}
+}
+
+func (l *pdfLexer) readByte() (byte, error) {
+	b, err := l.r.ReadByte()
+	if err == nil {
+		l.pos++
+	}
+	return b, err
+}
+
+func (l *pdfLexer) unreadByte() {
+	if l.r.UnreadByte() == nil {
+		l.pos--
+	}
+}
+
+// skipSpace skips whitespace and comments
+func (l *pdfLexer) skipSpace() error {
+	for {
+		b, err := l.readByte()
+		if err != nil {
+			return err
+		}
+		switch {
+		case b == '%':
+			for b != '\n' && b != '\r' {
+				if b, err = l.readByte(); err != nil {
+					return err
+				}
+			}
+		case !isPDFSpace(b):
+			l.unreadByte()
+			return nil
+		}
+	}
+}
+
+// token reads the next token. Strings and names are returned decoded; every
+// other token, including delimiters such as "<<" and "[", is a pdfKeyword.
+func (l *pdfLexer) token() (pdfObject, error) {
+	if n := len(l.pending); n > 0 {
+		tok := l.pending[n-1]
+		l.pending = l.pending[:n-1]
+		return tok, nil
+	}
+
+	if err := l.skipSpace(); err != nil {
+		return nil, err
+	}
+	start := l.pos
+	tok, err := l.scan()
+	if err == nil && l.scanned != nil {
+		l.scanned(tok, start, l.pos)
+	}
+	return tok, err
+}
+
+// scan reads the token that starts at the current position
+func (l *pdfLexer) scan() (pdfObject, error) {
+	b, err := l.readByte()
+	if err != nil {
+		return nil, err
+	}
+	switch b {
+	case '(':
+		return l.literalString()
+	case '<':
+		next, err := l.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if next == '<' {
+			return pdfKeyword("<<"), nil
+		}
+		l.unreadByte()
+		return l.hexString()
+	case '>':
+		if next, err := l.readByte(); err != nil || next != '>' {
+			return nil, errors.New("malformed PDF: unexpected >")
+		}
+		return pdfKeyword(">>"), nil
+	case '[', ']', '{', '}':
+		return pdfKeyword(b), nil
+	case '/':
+		return l.name()
+	}
+
+	kw := []byte{b}
+	for {
+		b, err := l.readByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isPDFSpace(b) || isPDFDelimiter(b) {
+			l.unreadByte()
+			break
+		}
+		kw = append(kw, b)
+	}
+	return pdfKeyword(kw), nil
+}
+
+// literalString reads a string after its opening parenthesis
+func (l *pdfLexer) literalString() (pdfObject, error) {
+	var s []byte
+	depth := 0
+	for {
+		b, err := l.readByte()
+		if err != nil {
+			return nil, err
+		}
+		switch b {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return pdfString(s), nil
+			}
+			depth--
+		case '\\':
+			if b, err = l.readByte(); err != nil {
+				return nil, err
+			}
+			switch b {
+			case 'n':
+				b = '\n'
+			case 'r':
+				b = '\r'
+			case 't':
+				b = '\t'
+			case 'b':
+				b = '\b'
+			case 'f':
+				b = '\f'
+			case '\r':
+				// A backslash before a line break continues the string
+				if next, err := l.readByte(); err == nil && next != '\n' {
+					l.unreadByte()
+				}
+				continue
+			case '\n':
+				continue
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				value := b - '0'
+				for i := 0; i < 2; i++ {
+					next, err := l.readByte()
+					if err != nil {
+						return nil, err
+					}
+					if next < '0' || next > '7' {
+						l.unreadByte()
+						break
+					}
+					value = value*8 + next - '0'
+				}
+				b = value
+			}
+		}
+		s = append(s, b)
+	}
+}
+
+// hexString reads a hexadecimal string after its opening angle bracket
+func (l *pdfLexer) hexString() (pdfObject, error) {
+	var digits []byte
+	for {
+		b, err := l.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == '>' {
+			break
+		}
+		if isPDFSpace(b) {
+			continue
+		}
+		if _, err := strconv.ParseUint(string(b), 16, 8); err != nil {
+			return nil, fmt.Errorf("malformed PDF: invalid hex string character %q", b)
+		}
+		digits = append(digits, b)
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	s := make(pdfString, len(digits)/2)
+	for i := range s {
+		n, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		s[i] = byte(n)
+	}
+	return s, nil
+}
+
+// name reads a name after its slash, decoding #xx escapes
+func (l *pdfLexer) name() (pdfObject, error) {
+	var name []byte
+	for {
+		b, err := l.readByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isPDFSpace(b) || isPDFDelimiter(b) {
+			l.unreadByte()
+			break
+		}
+		name = append(name, b)
+	}
+
+	decoded := make([]byte, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		if name[i] == '#' && i+2 < len(name) {
+			if n, err := strconv.ParseUint(string(name[i+1:i+3]), 16, 8); err == nil {
+				decoded = append(decoded, byte(n))
+				i += 2
+				continue
+			}
+		}
+		decoded = append(decoded, name[i])
+	}
+	return pdfName(decoded), nil
+}
+
+// readObject reads a direct object, with references written as "num gen R"
+func (l *pdfLexer) readObject() (pdfObject, error) {
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	return l.parse(tok)
+}
+
+// parse completes the object that starts with tok
+func (l *pdfLexer) parse(tok pdfObject) (pdfObject, error) {
+	kw, ok := tok.(pdfKeyword)
+	if !ok {
+		return tok, nil
+	}
+
+	switch kw {
+	case "<<":
+		dict := pdfDict{}
+		for {
+			tok, err := l.token()
+			if err != nil {
+				return nil, err
+			}
+			if tok == pdfKeyword(">>") {
+				return dict, nil
+			}
+			key, ok := tok.(pdfName)
+			if !ok {
+				return nil, fmt.Errorf("malformed PDF: dictionary key %v is not a name", tok)
+			}
+			value, err := l.readObject()
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = value
+		}
+	case "[":
+		array := pdfArray{}
+		for {
+			tok, err := l.token()
+			if err != nil {
+				return nil, err
+			}
+			if tok == pdfKeyword("]") {
+				return array, nil
+			}
+			value, err := l.parse(tok)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+	case ">>", "]":
+		return nil, fmt.Errorf("malformed PDF: unexpected %s", kw)
+	}
+
+	// An integer may start a reference, which needs two more tokens to tell
+	num, ok := pdfInt(kw)
+	if !ok {
+		return kw, nil
+	}
+	genTok, err := l.token()
+	if err != nil {
+		return kw, nil
+	}
+	gen, ok := pdfInt(genTok)
+	if !ok {
+		l.pending = append(l.pending, genTok)
+		return kw, nil
+	}
+	rTok, err := l.token()
+	if err != nil {
+		l.pending = append(l.pending, genTok)
+		return kw, nil
+	}
+	if rTok != pdfKeyword("R") {
+		l.pending = append(l.pending, rTok, genTok)
+		return kw, nil
+	}
+	return pdfRef{num: int(num), gen: int(gen)}, nil
+}
+
+// pdfXrefEntry locates an object, either at an offset in the file or inside
+// an object stream
+type pdfXrefEntry struct {
+	offset int64
+	stream int
+	index  int
+}
+
+// pdfFile reads raw objects through the cross-reference data of a file
+type pdfFile struct {
+	f    io.ReaderAt
+	size int64
+	xref map[int]pdfXrefEntry
+
+	// startxref is the offset of the last cross-reference section, and
+	// trailer its dictionary
+	startxref int64
+	trailer   pdfDict
+
+	// objectStreams caches the objects of decoded object streams
+	objectStreams map[int][]pdfObject
+
+	// scanned, when set, is passed to every lexer reading from the file
+	scanned func(tok pdfObject, start, end int64)
+}
+
+// readPDFFile reads the cross-reference sections of a file and its trailer
+func readPDFFile(f io.ReaderAt, size int64) (*pdfFile, error) {
+	tailSize := int64(1024)
+	if size < tailSize {
+		tailSize = size
+	}
+	tail := make([]byte, tailSize)
+	if n, err := f.ReadAt(tail, size-tailSize); n < len(tail) {
+		return nil, fmt.Errorf("failed to read PDF trailer: %w", err)
+	}
+
+	at := bytes.LastIndex(tail, []byte("startxref"))
+	if at < 0 {
+		return nil, errors.New("malformed PDF: startxref not found")
+	}
+	fields := bytes.Fields(tail[at+len("startxref"):])
+	if len(fields) == 0 {
+		return nil, errors.New("malformed PDF: startxref offset not found")
+	}
+	offset, err := strconv.ParseInt(string(fields[0]), 10, 64)
+	if err != nil || offset < 0 || offset >= size {
+		return nil, fmt.Errorf("malformed PDF: invalid startxref offset %q", fields[0])
+	}
+
+	p := &pdfFile{f: f, size: size, xref: make(map[int]pdfXrefEntry), startxref: offset, objectStreams: make(map[int][]pdfObject)}
+	if p.trailer, err = p.readXrefSection(offset); err != nil {
+		return nil, err
+	}
+	if err := p.readXref(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newLexer creates a lexer reading the file from offset
+func (p *pdfFile) newLexer(offset int64) *pdfLexer {
+	lex := newPDFLexer(p.f, offset, p.size)
+	lex.scanned = p.scanned
+	return lex
+}
+
+// readXref reads the earlier cross-reference sections linked from the trailer
+func (p *pdfFile) readXref() error {
+	seen := make(map[int64]bool)
+	for trailer := p.trailer; trailer != nil; {
+		// Hybrid files list compressed objects in a separate stream
+		if offset, ok := pdfInt(trailer["XRefStm"]); ok && !seen[offset] {
+			seen[offset] = true
+			if _, err := p.readXrefSection(offset); err != nil {
+				return err
+			}
+		}
+
+		offset, ok := pdfInt(trailer["Prev"])
+		if !ok || seen[offset] {
+			return nil
+		}
+		seen[offset] = true
+
+		var err error
+		if trailer, err = p.readXrefSection(offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readXrefSection reads the cross-reference table or stream at offset and
+// returns its trailer. Entries already known from later sections are kept.
+func (p *pdfFile) readXrefSection(offset int64) (pdfDict, error) {
+	lex := p.newLexer(offset)
+	tok, err := lex.token()
+	if err != nil {
+		return nil, fmt.Errorf("malformed PDF: cross-reference section: %w", err)
+	}
+	if tok != pdfKeyword("xref") {
+		return p.readXrefStream(offset)
+	}
+
+	for {
+		tok, err := lex.token()
+		if err != nil {
+			return nil, fmt.Errorf("malformed PDF: cross-reference table: %w", err)
+		}
+		if tok == pdfKeyword("trailer") {
+			obj, err := lex.readObject()
+			if err != nil {
+				return nil, fmt.Errorf("malformed PDF: trailer: %w", err)
+			}
+			trailer, ok := obj.(pdfDict)
+			if !ok {
+				return nil, errors.New("malformed PDF: trailer is not a dictionary")
+			}
+			return trailer, nil
+		}
+
+		start, ok := pdfInt(tok)
+		countTok, err := lex.token()
+		count, countOK := pdfInt(countTok)
+		if !ok || err != nil || !countOK || start < 0 || count < 0 {
+			return nil, errors.New("malformed PDF: invalid cross-reference subsection")
+		}
+		for i := int64(0); i < count; i++ {
+			var fields [3]pdfObject
+			for j := range fields {
+				if fields[j], err = lex.token(); err != nil {
+					return nil, fmt.Errorf("malformed PDF: cross-reference table: %w", err)
+				}
+			}
+			entryOffset, ok := pdfInt(fields[0])
+			if ok && fields[2] == pdfKeyword("n") {
+				p.addXref(int(start+i), pdfXrefEntry{offset: entryOffset})
+			}
+		}
+	}
+}
+
+// readXrefStream reads a cross-reference stream and returns its dictionary
+func (p *pdfFile) readXrefStream(offset int64) (pdfDict, error) {
+	obj, err := p.readObjectAt(offset)
+	if err != nil {
+		return nil, fmt.Errorf("malformed PDF: cross-reference stream: %w", err)
+	}
+	stream, ok := obj.(*pdfStream)
+	if !ok || stream.dict["Type"] != pdfName("XRef") {
+		return nil, errors.New("malformed PDF: cross-reference data not found")
+	}
+	data, err := p.decodeStream(stream)
+	if err != nil {
+		return nil, fmt.Errorf("malformed PDF: cross-reference stream: %w", err)
+	}
+
+	var widths [3]int
+	w, _ := stream.dict["W"].(pdfArray)
+	if len(w) != 3 {
+		return nil, errors.New("malformed PDF: invalid cross-reference stream widths")
+	}
+	rowSize := 0
+	for i := range widths {
+		n, ok := pdfInt(w[i])
+		if !ok || n < 0 || n > 8 {
+			return nil, errors.New("malformed PDF: invalid cross-reference stream widths")
+		}
+		widths[i] = int(n)
+		rowSize += int(n)
+	}
+
+	index, _ := stream.dict["Index"].(pdfArray)
+	if index == nil {
+		index = pdfArray{pdfKeyword("0"), stream.dict["Size"]}
+	}
+	for i := 0; i+1 < len(index); i += 2 {
+		start, startOK := pdfInt(index[i])
+		count, countOK := pdfInt(index[i+1])
+		if !startOK || !countOK {
+			return nil, errors.New("malformed PDF: invalid cross-reference stream index")
+		}
+		for j := int64(0); j < count && len(data) >= rowSize; j++ {
+			var fields [3]int64
+			row := data[:rowSize]
+			data = data[rowSize:]
+			for k, width := range widths {
+				for _, b := range row[:width] {
+					fields[k] = fields[k]<<8 | int64(b)
+				}
+				row = row[width:]
+			}
+			if widths[0] == 0 {
+				fields[0] = 1
+			}
+
+			switch fields[0] {
+			case 1:
+				p.addXref(int(start+j), pdfXrefEntry{offset: fields[1]})
+			case 2:
+				p.addXref(int(start+j), pdfXrefEntry{stream: int(fields[1]), index: int(fields[2])})
+			}
+		}
+	}
+	return stream.dict, nil
+}
+
+// addXref records an entry unless a later section already located the object
+func (p *pdfFile) addXref(num int, entry pdfXrefEntry) {
+	if _, ok := p.xref[num]; !ok && num > 0 {
+		p.xref[num] = entry
+	}
+}
+
+// readObjectAt reads the indirect object at offset
+func (p *pdfFile) readObjectAt(offset int64) (pdfObject, error) {
+	_, obj, err := p.readIndirectAt(offset)
+	return obj, err
+}
+
+// readIndirectAt reads the indirect object at offset and its reference
+func (p *pdfFile) readIndirectAt(offset int64) (pdfRef, pdfObject, error) {
+	lex := p.newLexer(offset)
+	var header [3]pdfObject
+	for i := range header {
+		tok, err := lex.token()
+		if err != nil {
+			return pdfRef{}, nil, err
+		}
+		header[i] = tok
+	}
+	num, numOK := pdfInt(header[0])
+	gen, genOK := pdfInt(header[1])
+	if !numOK || !genOK || header[2] != pdfKeyword("obj") {
+		return pdfRef{}, nil, fmt.Errorf("malformed PDF: no object at offset %d", offset)
+	}
+	ref := pdfRef{num: int(num), gen: int(gen)}
+
+	obj, err := lex.readObject()
+	if err != nil {
+		return ref, nil, err
+	}
+	if tok, err := lex.token(); err != nil || tok != pdfKeyword("stream") {
+		return ref, obj, nil
+	}
+	dict, ok := obj.(pdfDict)
+	if !ok || len(lex.pending) > 0 {
+		return ref, nil, fmt.Errorf("malformed PDF: invalid stream in object %d", num)
+	}
+
+	// The stream keyword is followed by CRLF or LF
+	if b, err := lex.readByte(); err == nil && b == '\r' {
+		if b, err := lex.readByte(); err == nil && b != '\n' {
+			lex.unreadByte()
+		}
+	} else if err == nil && b != '\n' {
+		lex.unreadByte()
+	}
+
+	length, ok := pdfInt(p.resolve(dict["Length"]))
+	if !ok || length < 0 || lex.pos+length > p.size {
+		return ref, nil, fmt.Errorf("malformed PDF: invalid stream length in object %d", num)
+	}
+	return ref, &pdfStream{dict: dict, offset: lex.pos, length: length}, nil
+}
+
+// object reads an object by number, returning nil for unknown objects
+func (p *pdfFile) object(num int) (pdfObject, error) {
+	entry, ok := p.xref[num]
+	if !ok {
+		return nil, nil
+	}
+	if entry.stream == 0 {
+		ref, obj, err := p.readIndirectAt(entry.offset)
+		if err == nil && ref.num != num {
+			err = fmt.Errorf("malformed PDF: object %d not found at offset %d", num, entry.offset)
+		}
+		return obj, err
+	}
+
+	objects, err := p.objectStream(entry.stream)
+	if err != nil {
+		return nil, err
+	}
+	if entry.index < 0 || entry.index >= len(objects) {
+		return nil, fmt.Errorf("malformed PDF: object %d not found in object stream %d", num, entry.stream)
+	}
+	return objects[entry.index], nil
+}
+
+// resolve follows a reference to the object it points to
+func (p *pdfFile) resolve(obj pdfObject) pdfObject {
+	for depth := 0; depth < 8; depth++ {
+		ref, ok := obj.(pdfRef)
+		if !ok {
+			return obj
+		}
+		obj, _ = p.object(ref.num)
+	}
+	return nil
+}
+
+// objectStream decodes an object stream and parses its objects
+func (p *pdfFile) objectStream(num int) ([]pdfObject, error) {
+	if objects, ok := p.objectStreams[num]; ok {
+		return objects, nil
+	}
+	// A failed stream is remembered as empty so it is read only once
+	p.objectStreams[num] = nil
+
+	entry, ok := p.xref[num]
+	if !ok || entry.stream != 0 {
+		return nil, fmt.Errorf("malformed PDF: object stream %d not found", num)
+	}
+	obj, err := p.readObjectAt(entry.offset)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := obj.(*pdfStream)
+	if !ok {
+		return nil, fmt.Errorf("malformed PDF: object %d is not an object stream", num)
+	}
+	data, err := p.decodeStream(stream)
+	if err != nil {
+		return nil, fmt.Errorf("object stream %d: %w", num, err)
+	}
+
+	count, countOK := pdfInt(stream.dict["N"])
+	first, firstOK := pdfInt(stream.dict["First"])
+	if !countOK || !firstOK || count < 0 || first < 0 || first > int64(len(data)) {
+		return nil, fmt.Errorf("malformed PDF: invalid object stream %d", num)
+	}
+
+	src := bytes.NewReader(data)
+	header := newPDFLexer(src, 0, int64(len(data)))
+	objects := make([]pdfObject, 0, count)
+	for i := int64(0); i < count; i++ {
+		// The header pairs object numbers with offsets relative to First
+		if _, err := header.token(); err != nil {
+			break
+		}
+		offsetTok, err := header.token()
+		offset, ok := pdfInt(offsetTok)
+		if err != nil || !ok || first+offset >= int64(len(data)) {
+			break
+		}
+		obj, err := newPDFLexer(src, first+offset, int64(len(data))).readObject()
+		if err != nil {
+			obj = nil
+		}
+		objects = append(objects, obj)
+	}
+	p.objectStreams[num] = objects
+	return objects, nil
+}
+
+// streamData reads the undecoded data of a stream
+func (p *pdfFile) streamData(stream *pdfStream) ([]byte, error) {
+	data := make([]byte, stream.length)
+	if _, err := p.f.ReadAt(data, stream.offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+// decodeStream decodes stream data compressed with FlateDecode, the only
+// filter used for cross-reference and object streams in practice
+func (p *pdfFile) decodeStream(stream *pdfStream) ([]byte, error) {
+	filter := stream.dict["Filter"]
+	params := stream.dict["DecodeParms"]
+	if array, ok := filter.(pdfArray); ok && len(array) == 1 {
+		filter = array[0]
+		if paramsArray, ok := params.(pdfArray); ok && len(paramsArray) == 1 {
+			params = paramsArray[0]
+		}
+	}
+
+	switch filter {
+	case nil, pdfName("FlateDecode"):
+	default:
+		return nil, fmt.Errorf("unsupported stream filter %v", filter)
+	}
+
+	raw, err := p.streamData(stream)
+	if err != nil || filter == nil {
+		return raw, err
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil && len(data) == 0 {
+		return nil, err
+	}
+
+	dict, _ := params.(pdfDict)
+	predictor, _ := pdfInt(dict["Predictor"])
+	switch {
+	case predictor <= 1:
+		return data, nil
+	case predictor >= 10:
+		return undoPNGPredictor(data, dict)
+	default:
+		return nil, fmt.Errorf("unsupported predictor %d", predictor)
+	}
+}
+
+// undoPNGPredictor reverses PNG row filtering, where every row starts with
+// a byte naming its filter type
+func undoPNGPredictor(data []byte, params pdfDict) ([]byte, error) {
+	colors, bits, columns := int64(1), int64(8), int64(1)
+	if n, ok := pdfInt(params["Colors"]); ok && n > 0 {
+		colors = n
+	}
+	if n, ok := pdfInt(params["BitsPerComponent"]); ok && n > 0 {
+		bits = n
+	}
+	if n, ok := pdfInt(params["Columns"]); ok && n > 0 {
+		columns = n
+	}
+	bpp := int((colors*bits + 7) / 8)
+	rowSize := int((colors*bits*columns + 7) / 8)
+
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowSize)
+	for len(data) >= rowSize+1 {
+		filter := data[0]
+		row := append([]byte(nil), data[1:rowSize+1]...)
+		data = data[rowSize+1:]
+
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up := prev[i]
+			switch filter {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			default:
+				return nil, fmt.Errorf("unsupported PNG filter type %d", filter)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+// paeth is the PNG Paeth predictor
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := absInt(p-int(a)), absInt(p-int(b)), absInt(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// pageRefs returns the references of the page objects in page order
+func (p *pdfFile) pageRefs() []pdfRef {
+	var refs []pdfRef
+	root, _ := p.resolve(p.trailer["Root"]).(pdfDict)
+	p.collectPageRefs(root["Pages"], &refs, 0)
+	return refs
+}
+
+// collectPageRefs walks a page tree node, appending the references of its pages
+func (p *pdfFile) collectPageRefs(obj pdfObject, refs *[]pdfRef, depth int) {
+	node, ok := p.resolve(obj).(pdfDict)
+	if !ok || depth > maxPDFTreeDepth {
+		return
+	}
+	switch node["Type"] {
+	case pdfName("Pages"):
+		kids, _ := p.resolve(node["Kids"]).(pdfArray)
+		for _, kid := range kids {
+			p.collectPageRefs(kid, refs, depth+1)
+		}
+	case pdfName("Page"):
+		ref, _ := obj.(pdfRef)
+		*refs = append(*refs, ref)
+	}
+}
+
+// pdfNode pairs a value read by the PDF library with the raw object it was
+// read from. The library does not expose object references, so the raw
+// object identifies indirect objects such as pages and form fields.
+type pdfNode struct {
+	pdf.Value
+
+	// ref is the reference the object was reached through; it is zero for
+	// direct objects and when the raw objects cannot be read
+	ref  pdfRef
+	raw  pdfObject
+	file *pdfFile
+}
+
+// newPDFNode pairs a library value with the raw object obj, which may be a
+// reference. file may be nil when the raw objects cannot be read.
+func newPDFNode(v pdf.Value, file *pdfFile, obj pdfObject) pdfNode {
+	n := pdfNode{Value: v, file: file}
+	if file == nil {
+		return n
+	}
+	if ref, ok := obj.(pdfRef); ok {
+		n.ref = ref
+		obj = file.resolve(ref)
+	}
+	n.raw = obj
+	return n
+}
+
+// key returns the dictionary or stream entry name
+func (n pdfNode) key(name string) pdfNode {
+	dict, _ := n.raw.(pdfDict)
+	if stream, ok := n.raw.(*pdfStream); ok {
+		dict = stream.dict
+	}
+	return newPDFNode(n.Value.Key(name), n.file, dict[pdfName(name)])
+}
+
+// index returns the array element i
+func (n pdfNode) index(i int) pdfNode {
+	var obj pdfObject
+	if array, ok := n.raw.(pdfArray); ok && i >= 0 && i < len(array) {
+		obj = array[i]
+	}
+	return newPDFNode(n.Value.Index(i), n.file, obj)
+}
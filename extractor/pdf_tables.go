@@ -0,0 +1,496 @@
+package extractor
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/dslipak/pdf"
+)
+
+// Table detection thresholds. Distances are in points unless noted.
+const (
+	// tableRulingTolerance is how far apart ruling lines may be and still touch or line up
+	tableRulingTolerance = 2.0
+
+	// tableMinRulingLength is the shortest segment considered a ruling line
+	tableMinRulingLength = 5.0
+
+	// tableCellGap is the horizontal gap between glyphs, as a fraction of the
+	// font size, that separates two cells of an unruled table
+	tableCellGap = 1.0
+
+	// tableRowGap is the largest line pitch, as a fraction of the font size,
+	// between two rows of an unruled table
+	tableRowGap = 2.5
+
+	// tableMinRows is the fewest lines that form an unruled table
+	tableMinRows = 3
+
+	// tableMaxCellWords is the largest average number of words per cell in an
+	// unruled table; columns of running prose have more
+	tableMaxCellWords = 6.0
+)
+
+// PDFTable is a table detected on a PDF page
+type PDFTable struct {
+	*Table
+
+	// Page is the number of the page the table is on
+	Page int
+}
+
+// pdfRuling is a horizontal or vertical line segment drawn on the page.
+// Coordinates are ordered so that x1 <= x2 and y1 <= y2.
+type pdfRuling struct {
+	x1, y1, x2, y2 float64
+}
+
+// horizontal reports whether the ruling runs left to right
+func (r pdfRuling) horizontal() bool {
+	return r.y2-r.y1 < r.x2-r.x1
+}
+
+// touches reports whether two rulings intersect or meet within the tolerance
+func (r pdfRuling) touches(other pdfRuling) bool {
+	return r.x1 <= other.x2+tableRulingTolerance && other.x1 <= r.x2+tableRulingTolerance &&
+		r.y1 <= other.y2+tableRulingTolerance && other.y1 <= r.y2+tableRulingTolerance
+}
+
+// pdfTableRegion is a detected table together with the vertical extent it
+// occupies, used to place its rendering among the surrounding text
+type pdfTableRegion struct {
+	top  float64
+	rows [][]string
+}
+
+// pdfMatrix is an affine transformation [a b c d e f]
+type pdfMatrix [6]float64
+
+// pdfIdentity is the identity transformation
+var pdfIdentity = pdfMatrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns the transformation m followed by n
+func (m pdfMatrix) multiply(n pdfMatrix) pdfMatrix {
+	return pdfMatrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// apply transforms a point
+func (m pdfMatrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// readRulings collects the horizontal and vertical lines stroked or filled
+// on a page: straight path segments and the edges of rectangles. Paths used
+// only for clipping are ignored.
+func readRulings(page pdf.Page) []pdfRuling {
+	var rulings []pdfRuling
+
+	contents := page.V.Key("Contents")
+	streams := []pdf.Value{contents}
+	if contents.Kind() == pdf.Array {
+		streams = nil
+		for i := 0; i < contents.Len(); i++ {
+			streams = append(streams, contents.Index(i))
+		}
+	}
+
+	// The content streams of a page form one stream, so the graphics state
+	// carries over from one to the next
+	ctm := pdfIdentity
+	var stack []pdfMatrix
+	var path []pdfRuling
+	var currentX, currentY, startX, startY float64
+
+	// addSegment records a path segment in user space if it is axis-aligned
+	addSegment := func(x1, y1, x2, y2 float64) {
+		x1, y1 = ctm.apply(x1, y1)
+		x2, y2 = ctm.apply(x2, y2)
+		ruling := pdfRuling{math.Min(x1, x2), math.Min(y1, y2), math.Max(x1, x2), math.Max(y1, y2)}
+		width, height := ruling.x2-ruling.x1, ruling.y2-ruling.y1
+		if (width < tableRulingTolerance) != (height < tableRulingTolerance) &&
+			math.Max(width, height) >= tableMinRulingLength {
+			path = append(path, ruling)
+		}
+	}
+
+	interpret := func(stk *pdf.Stack, op string) {
+		args := make([]float64, stk.Len())
+		for i := len(args) - 1; i >= 0; i-- {
+			args[i] = stk.Pop().Float64()
+		}
+
+		switch op {
+		case "q":
+			stack = append(stack, ctm)
+		case "Q":
+			if n := len(stack); n > 0 {
+				ctm = stack[n-1]
+				stack = stack[:n-1]
+			}
+		case "cm":
+			if len(args) == 6 {
+				ctm = pdfMatrix{args[0], args[1], args[2], args[3], args[4], args[5]}.multiply(ctm)
+			}
+		case "m":
+			if len(args) == 2 {
+				currentX, currentY = args[0], args[1]
+				startX, startY = currentX, currentY
+			}
+		case "l":
+			if len(args) == 2 {
+				addSegment(currentX, currentY, args[0], args[1])
+				currentX, currentY = args[0], args[1]
+			}
+		case "h":
+			addSegment(currentX, currentY, startX, startY)
+			currentX, currentY = startX, startY
+		case "re":
+			if len(args) != 4 {
+				return
+			}
+			x, y, w, h := args[0], args[1], args[2], args[3]
+			x1, y1 := ctm.apply(x, y)
+			x2, y2 := ctm.apply(x+w, y+h)
+			if math.Abs(x2-x1) < tableRulingTolerance || math.Abs(y2-y1) < tableRulingTolerance {
+				// A thin filled rectangle is a common way to draw a line
+				addSegment(x, y+h/2, x+w, y+h/2)
+				addSegment(x+w/2, y, x+w/2, y+h)
+			} else {
+				addSegment(x, y, x+w, y)
+				addSegment(x+w, y, x+w, y+h)
+				addSegment(x+w, y+h, x, y+h)
+				addSegment(x, y+h, x, y)
+			}
+			currentX, currentY = x, y
+			startX, startY = x, y
+		case "S", "s", "f", "F", "f*", "B", "B*", "b", "b*":
+			rulings = append(rulings, path...)
+			path = nil
+		case "n":
+			path = nil
+		}
+	}
+
+	for _, stream := range streams {
+		pdf.Interpret(stream, interpret)
+	}
+
+	return rulings
+}
+
+// detectTables finds ruled tables, whose cells are outlined by ruling lines,
+// and unruled tables, whose cells line up in columns separated by wide gaps.
+// It returns the tables and the glyphs that are not part of any table.
+func detectTables(glyphs []pdf.Text, rulings []pdfRuling) ([]pdfTableRegion, []pdf.Text) {
+	regions, remaining := detectRuledTables(glyphs, rulings)
+
+	aligned, remaining := detectAlignedTables(remaining)
+	regions = append(regions, aligned...)
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].top > regions[j].top
+	})
+	return regions, remaining
+}
+
+// detectRuledTables groups touching ruling lines into grids and assigns the
+// glyphs inside each grid to its cells
+func detectRuledTables(glyphs []pdf.Text, rulings []pdfRuling) ([]pdfTableRegion, []pdf.Text) {
+	var regions []pdfTableRegion
+	inTable := make([]bool, len(glyphs))
+
+	for _, group := range groupRulings(rulings) {
+		minX, minY := math.Inf(1), math.Inf(1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		var xs, ys []float64
+		for _, ruling := range group {
+			minX, minY = math.Min(minX, ruling.x1), math.Min(minY, ruling.y1)
+			maxX, maxY = math.Max(maxX, ruling.x2), math.Max(maxY, ruling.y2)
+			if ruling.horizontal() {
+				ys = append(ys, (ruling.y1+ruling.y2)/2)
+			} else {
+				xs = append(xs, (ruling.x1+ruling.x2)/2)
+			}
+		}
+
+		// The outer edges bound the grid even when they are not drawn
+		xs = clusterPositions(append(xs, minX, maxX))
+		ys = clusterPositions(append(ys, minY, maxY))
+		if len(xs) < 3 || len(ys) < 3 {
+			continue
+		}
+
+		// Rows run top to bottom
+		sort.Sort(sort.Reverse(sort.Float64Slice(ys)))
+		cells := make([][][]pdf.Text, len(ys)-1)
+		for i := range cells {
+			cells[i] = make([][]pdf.Text, len(xs)-1)
+		}
+
+		found := false
+		for i, glyph := range glyphs {
+			x, y := glyphCenter(glyph)
+			if x < minX || x > maxX || y < minY || y > maxY {
+				continue
+			}
+			row := sort.Search(len(ys), func(k int) bool { return ys[k] < y }) - 1
+			col := sort.SearchFloat64s(xs, x) - 1
+			if row < 0 || row >= len(cells) || col < 0 || col >= len(xs)-1 {
+				continue
+			}
+			cells[row][col] = append(cells[row][col], glyph)
+			inTable[i] = true
+			found = true
+		}
+		if !found {
+			continue
+		}
+
+		rows := make([][]string, len(cells))
+		for i, row := range cells {
+			rows[i] = make([]string, len(row))
+			for j, cell := range row {
+				rows[i][j] = cellText(cell)
+			}
+		}
+		regions = append(regions, pdfTableRegion{top: maxY, rows: rows})
+	}
+
+	var remaining []pdf.Text
+	for i, glyph := range glyphs {
+		if !inTable[i] {
+			remaining = append(remaining, glyph)
+		}
+	}
+	return regions, remaining
+}
+
+// groupRulings splits rulings into sets of lines that touch each other
+func groupRulings(rulings []pdfRuling) [][]pdfRuling {
+	parent := make([]int, len(rulings))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range rulings {
+		for j := i + 1; j < len(rulings); j++ {
+			if rulings[i].touches(rulings[j]) {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	indexes := make(map[int]int)
+	var groups [][]pdfRuling
+	for i, ruling := range rulings {
+		root := find(i)
+		index, ok := indexes[root]
+		if !ok {
+			index = len(groups)
+			indexes[root] = index
+			groups = append(groups, nil)
+		}
+		groups[index] = append(groups[index], ruling)
+	}
+	return groups
+}
+
+// clusterPositions sorts positions and merges those closer than the ruling tolerance
+func clusterPositions(positions []float64) []float64 {
+	sort.Float64s(positions)
+	var clustered []float64
+	for _, position := range positions {
+		if n := len(clustered); n > 0 && position-clustered[n-1] < tableRulingTolerance {
+			continue
+		}
+		clustered = append(clustered, position)
+	}
+	return clustered
+}
+
+// glyphCenter returns the middle of the glyph's box, estimating the box height from the font size
+func glyphCenter(glyph pdf.Text) (float64, float64) {
+	return glyph.X + glyphWidth(glyph)/2, glyph.Y + 0.3*glyphSize(glyph)
+}
+
+// cellText rebuilds the text of a cell, joining its lines with spaces
+func cellText(glyphs []pdf.Text) string {
+	var lines []string
+	for _, line := range groupLines(glyphs) {
+		if text := line.text(); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// tableSegment is a run of glyphs on a line that belongs to one cell
+type tableSegment struct {
+	glyphs []pdf.Text
+	minX   float64
+	maxX   float64
+}
+
+// lineSegments splits a line where the gap between glyphs is wide enough to separate cells
+func lineSegments(line *layoutLine) []tableSegment {
+	var segments []tableSegment
+	prevEnd := math.Inf(-1)
+	for _, glyph := range line.glyphs {
+		if strings.TrimSpace(glyph.S) == "" {
+			continue
+		}
+		end := glyph.X + glyphWidth(glyph)
+		if n := len(segments); n > 0 && glyph.X-prevEnd <= tableCellGap*glyphSize(glyph) {
+			segments[n-1].maxX = end
+		} else {
+			segments = append(segments, tableSegment{minX: glyph.X, maxX: end})
+		}
+		prevEnd = end
+	}
+
+	// Hand the glyphs, including spaces, to the segment they fall in
+	for _, glyph := range line.glyphs {
+		for i := range segments {
+			if glyph.X >= segments[i].minX && glyph.X < segments[i].maxX {
+				segments[i].glyphs = append(segments[i].glyphs, glyph)
+				break
+			}
+		}
+	}
+	return segments
+}
+
+// detectAlignedTables finds runs of closely spaced lines that each split into
+// several cells, and whose cells line up in columns
+func detectAlignedTables(glyphs []pdf.Text) ([]pdfTableRegion, []pdf.Text) {
+	lines := groupLines(glyphs)
+	segments := make([][]tableSegment, len(lines))
+	for i, line := range lines {
+		segments[i] = lineSegments(line)
+	}
+
+	var regions []pdfTableRegion
+	inTable := make([]bool, len(lines))
+
+	for start := 0; start < len(lines); {
+		end := start
+		for end < len(lines) && len(segments[end]) >= 2 &&
+			(end == start || lines[end-1].y-lines[end].y <= tableRowGap*lines[end].size) {
+			end++
+		}
+		if end == start {
+			start++
+			continue
+		}
+
+		if rows, ok := alignedRows(segments[start:end]); ok {
+			regions = append(regions, pdfTableRegion{top: lines[start].y + lines[start].size, rows: rows})
+			for i := start; i < end; i++ {
+				inTable[i] = true
+			}
+		}
+		start = end
+	}
+
+	var remaining []pdf.Text
+	for i, line := range lines {
+		if !inTable[i] {
+			remaining = append(remaining, line.glyphs...)
+		}
+	}
+	return regions, remaining
+}
+
+// alignedRows arranges the segments of a run of lines into columns. Columns
+// are the horizontal bands covered by segments of any line; it reports false
+// when the run is too short, has a single column or reads like prose.
+func alignedRows(lines [][]tableSegment) ([][]string, bool) {
+	if len(lines) < tableMinRows {
+		return nil, false
+	}
+
+	type band struct{ minX, maxX float64 }
+	var bands []band
+	words, cells := 0, 0
+	for _, line := range lines {
+		for _, segment := range line {
+			bands = append(bands, band{segment.minX, segment.maxX})
+			words += len(strings.Fields(cellText(segment.glyphs)))
+			cells++
+		}
+	}
+	if float64(words)/float64(cells) > tableMaxCellWords {
+		return nil, false
+	}
+
+	sort.Slice(bands, func(i, j int) bool { return bands[i].minX < bands[j].minX })
+	var columns []band
+	for _, b := range bands {
+		if n := len(columns); n > 0 && b.minX <= columns[n-1].maxX {
+			columns[n-1].maxX = math.Max(columns[n-1].maxX, b.maxX)
+			continue
+		}
+		columns = append(columns, b)
+	}
+	if len(columns) < 2 {
+		return nil, false
+	}
+
+	rows := make([][]string, len(lines))
+	for i, line := range lines {
+		rows[i] = make([]string, len(columns))
+		for _, segment := range line {
+			for col, column := range columns {
+				if segment.minX >= column.minX && segment.maxX <= column.maxX {
+					if rows[i][col] != "" {
+						rows[i][col] += " "
+					}
+					rows[i][col] += cellText(segment.glyphs)
+					break
+				}
+			}
+		}
+	}
+	return rows, true
+}
+
+// renderPageWithTables emits the page text and tables top to bottom, each
+// table in place of the lines it was detected in
+func renderPageWithTables(glyphs []pdf.Text, regions []pdfTableRegion, tables []*Table, format TableFormat, detectColumns bool) string {
+	// Text above the first table goes in band 0, text between the first and
+	// second table in band 1 and so on
+	bands := make([][]pdf.Text, len(regions)+1)
+	for _, glyph := range glyphs {
+		band := 0
+		for band < len(regions) && regions[band].top > glyph.Y {
+			band++
+		}
+		bands[band] = append(bands[band], glyph)
+	}
+
+	var parts []string
+	for i, band := range bands {
+		if text := reconstructPageText(band, detectColumns); text != "" {
+			parts = append(parts, text)
+		}
+		if i < len(tables) {
+			parts = append(parts, tables[i].Render(format))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
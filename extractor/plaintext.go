@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -8,14 +9,18 @@ import (
 	"time"
 	"unicode/utf8"
 
-	"golang.org/x/text/encoding"
-	"golang.org/x/text/encoding/charmap"
-	"golang.org/x/text/encoding/unicode"
-	"golang.org/x/text/transform"
+	"golang.org/x/text/encoding/japanese"
 )
 
 // PlainTextExtractor handles extraction from plain text files
-type PlainTextExtractor struct{}
+type PlainTextExtractor struct {
+	// Strings extracts the printable runs of binary content, like the Unix
+	// strings tool, instead of failing with ErrBinaryContent
+	Strings bool
+
+	// MinStringLength is the shortest run reported in strings mode (default: 4)
+	MinStringLength int
+}
 
 // NewPlainTextExtractor creates a new plain text extractor
 func NewPlainTextExtractor() *PlainTextExtractor {
@@ -39,8 +44,17 @@ func (e *PlainTextExtractor) Extract(reader io.Reader, options ExtractOptions) (
 			"plaintext", "size_check", nil)
 	}
 
+	// Binary data is rejected rather than decoded into garbage, unless the
+	// caller names its encoding
+	if options.Encoding == "" && isBinaryContent(content) {
+		if !e.Strings {
+			return nil, NewExtractorError("content is binary", "plaintext", "binary_check", ErrBinaryContent)
+		}
+		return e.extractStrings(content, start), nil
+	}
+
 	// Detect and convert encoding
-	text, encoding, err := e.detectAndConvertEncoding(content)
+	text, encoding, err := e.detectAndConvertEncoding(content, options.Encoding)
 	if err != nil {
 		return nil, NewExtractorError("failed to convert encoding", "plaintext", "encoding", err)
 	}
@@ -51,10 +65,12 @@ func (e *PlainTextExtractor) Extract(reader io.Reader, options ExtractOptions) (
 	}
 
 	metadata := map[string]interface{}{
-		"encoding":   encoding,
-		"size_bytes": len(content),
-		"line_count": strings.Count(text, "\n") + 1,
-		"char_count": len([]rune(text)),
+		"encoding":            encoding.name,
+		"encoding_confidence": encoding.confidence,
+		"bom":                 encoding.bom,
+		"size_bytes":          len(content),
+		"line_count":          strings.Count(text, "\n") + 1,
+		"char_count":          len([]rune(text)),
 	}
 
 	return &ExtractResult{
@@ -65,6 +81,29 @@ func (e *PlainTextExtractor) Extract(reader io.Reader, options ExtractOptions) (
 	}, nil
 }
 
+// extractStrings returns the printable runs of binary content, one per line
+func (e *PlainTextExtractor) extractStrings(content []byte, start time.Time) *ExtractResult {
+	found := printableStrings(content, e.MinStringLength)
+	lines := make([]string, len(found))
+	offsets := make([]int, len(found))
+	for i, run := range found {
+		lines[i] = run.text
+		offsets[i] = run.offset
+	}
+
+	return &ExtractResult{
+		Text: strings.Join(lines, "\n"),
+		Metadata: map[string]interface{}{
+			"binary":         true,
+			"size_bytes":     len(content),
+			"string_count":   len(found),
+			"string_offsets": offsets,
+		},
+		FileType:       "plaintext",
+		ProcessingTime: time.Since(start),
+	}
+}
+
 // ExtractFromFile extracts text from a plain text file
 func (e *PlainTextExtractor) ExtractFromFile(filepath string, options ExtractOptions) (*ExtractResult, error) {
 	file, err := os.Open(filepath)
@@ -81,36 +120,58 @@ func (e *PlainTextExtractor) SupportedTypes() []string {
 	return []string{"txt", "csv", "yaml", "yml", "json", "xml", "md", "markdown", "log", "conf", "cfg", "ini"}
 }
 
-// detectAndConvertEncoding detects the encoding and converts to UTF-8
-func (e *PlainTextExtractor) detectAndConvertEncoding(content []byte) (string, string, error) {
-	// First check if it's already valid UTF-8
-	if utf8.Valid(content) {
-		return string(content), "UTF-8", nil
+// detectAndConvertEncoding detects the encoding and converts to UTF-8. A
+// forced encoding is used as is. A byte order mark is removed from the text.
+func (e *PlainTextExtractor) detectAndConvertEncoding(content []byte, forced string) (string, textEncoding, error) {
+	if forced != "" {
+		enc, name, err := lookupEncoding(forced)
+		if err != nil {
+			return "", textEncoding{}, err
+		}
+		result, err := enc.NewDecoder().Bytes(content)
+		if err != nil {
+			return "", textEncoding{}, err
+		}
+		text, bom := strings.CutPrefix(string(result), "\uFEFF")
+		return text, textEncoding{name: name, confidence: 1, bom: bom}, nil
 	}
 
-	// Try common encodings
-	encodings := []struct {
-		name string
-		enc  encoding.Encoding
-	}{
-		{"UTF-16LE", unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)},
-		{"UTF-16BE", unicode.UTF16(unicode.BigEndian, unicode.UseBOM)},
-		{"Windows-1252", charmap.Windows1252},
-		{"ISO-8859-1", charmap.ISO8859_1},
-		{"Windows-1251", charmap.Windows1251},
+	// A byte order mark names the Unicode encoding
+	if name, enc, size := sniffBOM(content); enc != nil {
+		result, err := enc.NewDecoder().Bytes(content[size:])
+		if err == nil {
+			return string(result), textEncoding{name: name, confidence: 1, bom: true}, nil
+		}
 	}
 
-	for _, enc := range encodings {
-		decoder := enc.enc.NewDecoder()
-		result, err := io.ReadAll(transform.NewReader(strings.NewReader(string(content)), decoder))
-		if err == nil && utf8.Valid(result) {
-			return string(result), enc.name, nil
+	// UTF-16 and UTF-32 without a byte order mark are recognized by their zero bytes,
+	// which would otherwise pass as valid UTF-8
+	if name, enc, confidence := sniffUnicodeNulls(content); enc != nil {
+		result, err := enc.NewDecoder().Bytes(content)
+		if err == nil {
+			return string(result), textEncoding{name: name, confidence: confidence}, nil
 		}
 	}
 
-	// If all else fails, replace invalid UTF-8 sequences
-	text := strings.ToValidUTF8(string(content), "�")
-	return text, "UTF-8 (with replacements)", nil
+	if isISO2022JP(content) {
+		if result, err := japanese.ISO2022JP.NewDecoder().Bytes(content); err == nil {
+			return string(result), textEncoding{name: "ISO-2022-JP", confidence: 1}, nil
+		}
+	}
+
+	// Then check if it's already valid UTF-8
+	if utf8.Valid(content) {
+		return string(content), textEncoding{name: "UTF-8", confidence: 1}, nil
+	}
+
+	match := detectCharset(content)
+	result, err := match.encoding.NewDecoder().Bytes(content)
+	if err != nil {
+		// If all else fails, replace invalid UTF-8 sequences
+		text := strings.ToValidUTF8(string(content), "�")
+		return text, textEncoding{name: "UTF-8 (with replacements)"}, nil
+	}
+	return string(result), textEncoding{name: match.name, confidence: match.confidence}, nil
 }
 
 // normalizeLineEndings converts all line endings to \n
@@ -121,9 +182,12 @@ func (e *PlainTextExtractor) normalizeLineEndings(text string) string {
 	return text
 }
 
-// CSVExtractor provides specialized CSV handling
+// CSVExtractor provides specialized CSV and TSV handling
 type CSVExtractor struct {
 	*PlainTextExtractor
+
+	// Dialect forces the delimiter and quote character; when nil they are sniffed from the content
+	Dialect *CSVDialect
 }
 
 // NewCSVExtractor creates a new CSV extractor
@@ -140,17 +204,40 @@ func (e *CSVExtractor) Extract(reader io.Reader, options ExtractOptions) (*Extra
 		return nil, err
 	}
 
+	dialect := e.dialectFor(result.Text, options)
+	records, parseErrors := parseCSV(result.Text, dialect)
+
+	var rows [][]string
+	columnCount := 0
+	for _, record := range records {
+		if isBlankRecord(record) {
+			continue
+		}
+		rows = append(rows, record)
+		if len(record) > columnCount {
+			columnCount = len(record)
+		}
+	}
+
 	// Add CSV-specific metadata
-	lines := strings.Split(result.Text, "\n")
-	if len(lines) > 0 {
-		firstLine := lines[0]
-		columnCount := strings.Count(firstLine, ",") + 1
-		result.Metadata["column_count"] = columnCount
-		result.Metadata["has_header"] = e.detectCSVHeader(lines)
+	result.Metadata["delimiter"] = string(dialect.Delimiter)
+	result.Metadata["quote_char"] = string(dialect.Quote)
+	result.Metadata["row_count"] = len(rows)
+	result.Metadata["column_count"] = columnCount
+	result.Metadata["has_header"] = e.detectCSVHeader(rows)
+	if len(parseErrors) > 0 {
+		messages := make([]string, 0, len(parseErrors))
+		for _, parseErr := range parseErrors {
+			messages = append(messages, parseErr.Error())
+		}
+		result.Metadata["parse_errors"] = messages
 	}
 
 	// Override the file type set by parent extractor
 	result.FileType = "csv"
+	if dialect.Delimiter == '\t' {
+		result.FileType = "tsv"
+	}
 	return result, nil
 }
 
@@ -165,159 +252,68 @@ func (e *CSVExtractor) ExtractFromFile(filepath string, options ExtractOptions)
 	return e.Extract(file, options)
 }
 
-// detectCSVHeader tries to determine if the first row is a header
-func (e *CSVExtractor) detectCSVHeader(lines []string) bool {
-	if len(lines) < 2 {
-		return false
-	}
-
-	// Simple heuristic: if first row has fewer numbers than second row, it's likely a header
-	firstRowNumbers := e.countNumbers(lines[0])
-	secondRowNumbers := e.countNumbers(lines[1])
-
-	return firstRowNumbers < secondRowNumbers
-}
-
-// countNumbers counts numeric values in a CSV row
-func (e *CSVExtractor) countNumbers(row string) int {
-	fields := strings.Split(row, ",")
-	numberCount := 0
-
-	for _, field := range fields {
-		field = strings.TrimSpace(field)
-		if field != "" {
-			// Simple check for numeric content
-			if strings.ContainsAny(field, "0123456789") {
-				numberCount++
-			}
-		}
-	}
-
-	return numberCount
-}
-
-// SupportedTypes returns CSV-specific supported types
-func (e *CSVExtractor) SupportedTypes() []string {
-	return []string{"csv", "tsv"}
-}
-
-// MarkdownExtractor provides specialized Markdown handling
-type MarkdownExtractor struct {
-	*PlainTextExtractor
-}
-
-// NewMarkdownExtractor creates a new Markdown extractor
-func NewMarkdownExtractor() *MarkdownExtractor {
-	return &MarkdownExtractor{
-		PlainTextExtractor: NewPlainTextExtractor(),
-	}
-}
-
-// Extract extracts text from Markdown with optional formatting removal
-func (e *MarkdownExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
+// ExtractTables parses CSV content into a header-keyed table. Malformed
+// records do not stop parsing: the table is returned together with an error
+// that wraps each *CSVParseError, so callers can inspect them with errors.As.
+func (e *CSVExtractor) ExtractTables(reader io.Reader, options ExtractOptions, tableOptions TableOptions) ([]*Table, error) {
 	result, err := e.PlainTextExtractor.Extract(reader, options)
 	if err != nil {
 		return nil, err
 	}
 
-	// If not preserving formatting, strip Markdown syntax
-	if !options.PreserveFormatting {
-		result.Text = e.stripMarkdownSyntax(result.Text)
-	}
-
-	// Add Markdown-specific metadata
-	result.Metadata["heading_count"] = e.countHeadings(result.Text)
-	result.Metadata["link_count"] = e.countLinks(result.Text)
+	rows, parseErrors := parseCSV(result.Text, e.dialectFor(result.Text, options))
 
-	// Override the file type set by parent extractor
-	result.FileType = "markdown"
+	table, err := buildTable("", rows, tableOptions)
+	if err != nil {
+		return nil, NewExtractorError("invalid table options", "csv", "table", err)
+	}
 
-	return result, nil
+	if len(parseErrors) > 0 {
+		errs := make([]error, len(parseErrors))
+		for i, parseErr := range parseErrors {
+			errs[i] = parseErr
+		}
+		return []*Table{table}, NewExtractorError(
+			fmt.Sprintf("%d malformed records", len(parseErrors)), "csv", "parse", errors.Join(errs...))
+	}
+	return []*Table{table}, nil
 }
 
-// ExtractFromFile extracts text from a Markdown file
-func (e *MarkdownExtractor) ExtractFromFile(filepath string, options ExtractOptions) (*ExtractResult, error) {
+// ExtractTablesFromFile parses a CSV file into a header-keyed table
+func (e *CSVExtractor) ExtractTablesFromFile(filepath string, options ExtractOptions, tableOptions TableOptions) ([]*Table, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
-		return nil, NewExtractorError("failed to open file", "markdown", "open", err)
+		return nil, NewExtractorError("failed to open file", "csv", "open", err)
 	}
 	defer file.Close()
 
-	return e.Extract(file, options)
+	return e.ExtractTables(file, options, tableOptions)
 }
 
-// stripMarkdownSyntax removes common Markdown formatting
-func (e *MarkdownExtractor) stripMarkdownSyntax(text string) string {
-	lines := strings.Split(text, "\n")
-	var cleanLines []string
-
-	for _, line := range lines {
-		// Remove heading markers
-		line = strings.TrimLeft(line, "# ")
-
-		// Remove bold and italic markers
-		line = strings.ReplaceAll(line, "**", "")
-		line = strings.ReplaceAll(line, "__", "")
-		line = strings.ReplaceAll(line, "*", "")
-		line = strings.ReplaceAll(line, "_", "")
-
-		// Remove code markers
-		line = strings.ReplaceAll(line, "`", "")
-
-		// Remove list markers (simple approach)
-		line = strings.TrimLeft(line, "- + ")
-
-		cleanLines = append(cleanLines, line)
+// dialectFor returns the configured dialect, or sniffs one from the text.
+// A "tsv" file type hint selects the tab delimiter.
+func (e *CSVExtractor) dialectFor(text string, options ExtractOptions) CSVDialect {
+	if e.Dialect != nil {
+		return *e.Dialect
 	}
 
-	return strings.Join(cleanLines, "\n")
-}
-
-// countHeadings counts the number of headings in Markdown
-func (e *MarkdownExtractor) countHeadings(text string) int {
-	lines := strings.Split(text, "\n")
-	count := 0
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "#") {
-			count++
-		}
+	dialect := sniffCSVDialect(text)
+	if strings.EqualFold(strings.TrimPrefix(options.FileType, "."), "tsv") {
+		dialect.Delimiter = '\t'
 	}
-
-	return count
+	return dialect
 }
 
-// countLinks counts the number of links in Markdown
-func (e *MarkdownExtractor) countLinks(text string) int {
-	// Simple count of [text](url) patterns
-	count := 0
-	inLink := false
-	inURL := false
-
-	for i, char := range text {
-		switch char {
-		case '[':
-			if !inLink && !inURL {
-				inLink = true
-			}
-		case ']':
-			if inLink && i+1 < len(text) && text[i+1] == '(' {
-				inLink = false
-				inURL = true
-			}
-		case ')':
-			if inURL {
-				inURL = false
-				count++
-			}
-		}
+// detectCSVHeader tries to determine if the first row is a header
+func (e *CSVExtractor) detectCSVHeader(rows [][]string) bool {
+	if len(rows) < 2 {
+		return false
 	}
 
-	return count
+	return detectHeader(rows[0], rows[1:])
 }
 
-// SupportedTypes returns Markdown-specific supported types
-func (e *MarkdownExtractor) SupportedTypes() []string {
-	return []string{"md", "markdown"}
+// SupportedTypes returns CSV-specific supported types
+func (e *CSVExtractor) SupportedTypes() []string {
+	return []string{"csv", "tsv"}
 }
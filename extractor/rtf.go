@@ -0,0 +1,542 @@
+package extractor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ErrNotRTF is returned for content that does not start with an RTF header
+var ErrNotRTF = errors.New("content is not RTF")
+
+// RTFExtractor handles Rich Text Format file text extraction
+type RTFExtractor struct{}
+
+// NewRTFExtractor creates a new RTF extractor
+func NewRTFExtractor() *RTFExtractor {
+	return &RTFExtractor{}
+}
+
+// Extract extracts text and metadata from an RTF file. Paragraphs are written
+// one per line and table rows as tab-separated cells. Text bytes are decoded
+// with the code page of \ansicpg unless options.Encoding forces one.
+func (e *RTFExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
+	start := time.Now()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RTF content: %w", err)
+	}
+
+	if options.MaxFileSize > 0 && int64(len(content)) > options.MaxFileSize {
+		return nil, NewExtractorError(
+			fmt.Sprintf("file size %d exceeds limit %d", len(content), options.MaxFileSize),
+			"rtf", "size_check", nil)
+	}
+
+	size := len(content)
+
+	// Content with a byte order mark is converted to UTF-8 before parsing
+	_, bom, bomSize := sniffBOM(content)
+	if bom != nil {
+		content, err = bom.NewDecoder().Bytes(content[bomSize:])
+		if err != nil {
+			return nil, NewExtractorError("failed to convert encoding", "rtf", "encoding", err)
+		}
+	}
+
+	if !bytes.HasPrefix(bytes.TrimLeft(content, " \t\r\n"), []byte(`{\rtf`)) {
+		return nil, NewExtractorError("missing RTF header", "rtf", "parse", ErrNotRTF)
+	}
+
+	parser := &rtfParser{
+		content:  content,
+		encoding: charmap.Windows1252,
+		codePage: "windows-1252",
+		info:     make(map[string]*strings.Builder),
+
+		// One fallback character follows each \uN unless \uc says otherwise
+		state: rtfState{ucSkip: 1},
+	}
+	if options.Encoding != "" {
+		enc, name, err := lookupEncoding(options.Encoding)
+		if err != nil {
+			return nil, NewExtractorError("unsupported encoding", "rtf", "decode", err)
+		}
+		parser.encoding, parser.codePage, parser.forced = enc, name, true
+	}
+	parser.parse()
+
+	result := &ExtractResult{
+		Text:     tidyRTFText(parser.text.String()),
+		FileType: "rtf",
+		Metadata: map[string]interface{}{
+			"size_bytes": size,
+			"encoding":   parser.codePage,
+			"bom":        bom != nil,
+		},
+	}
+	parser.addMetadata(result.Metadata)
+
+	result.Metadata["paragraphs"] = strconv.Itoa(parser.paragraphs)
+	result.Metadata["table_rows"] = strconv.Itoa(parser.rows)
+	result.Metadata["characters"] = strconv.Itoa(len(result.Text))
+	result.Metadata["line_count"] = strconv.Itoa(strings.Count(result.Text, "\n") + 1)
+
+	result.ProcessingTime = time.Since(start)
+
+	return result, nil
+}
+
+// ExtractFromFile extracts text from an RTF file
+func (e *RTFExtractor) ExtractFromFile(filePath string, options ExtractOptions) (*ExtractResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return e.Extract(file, options)
+}
+
+// SupportedTypes returns the file types supported by this extractor
+func (e *RTFExtractor) SupportedTypes() []string {
+	return []string{"rtf"}
+}
+
+// rtfSkippedDestinations are destinations whose content is not document text
+var rtfSkippedDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "listtable": true,
+	"listoverridetable": true, "revtbl": true, "rsidtbl": true, "generator": true,
+	"pict": true, "objdata": true, "themedata": true, "colorschememapping": true,
+	"latentstyles": true, "datastore": true, "xmlnstbl": true, "fldinst": true,
+	"filetbl": true, "bkmkstart": true, "bkmkend": true, "header": true,
+	"headerl": true, "headerr": true, "headerf": true, "footer": true,
+	"footerl": true, "footerr": true, "footerf": true, "private": true,
+	"annotation": true, "atnid": true, "atnauthor": true, "xe": true, "tc": true,
+}
+
+// rtfInfoFields maps the text fields of the \info group to metadata keys
+var rtfInfoFields = map[string]string{
+	"title": "title", "subject": "subject", "author": "author", "operator": "last_modified_by",
+	"keywords": "keywords", "doccomm": "description", "company": "company", "category": "category",
+}
+
+// rtfSymbols maps control words to the characters they stand for
+var rtfSymbols = map[string]string{
+	"par": "\n", "line": "\n", "sect": "\n", "page": "\n", "tab": "\t",
+	"emdash": "—", "endash": "–", "emspace": "\u2003", "enspace": "\u2002",
+	"qmspace": "\u2005", "bullet": "•", "lquote": "‘", "rquote": "’",
+	"ldblquote": "“", "rdblquote": "”", "zwj": "\u200d", "zwnj": "\u200c",
+}
+
+// rtfState is the formatting state of a group
+type rtfState struct {
+	// destination is where text goes: "" for the body, "skip" to drop it, or
+	// an \info field
+	destination string
+
+	// ucSkip is the number of fallback characters following a \uN character
+	ucSkip int
+
+	// hidden is set by \v for hidden text
+	hidden bool
+}
+
+// rtfParser tokenizes an RTF document into groups, control words and text
+type rtfParser struct {
+	content []byte
+	pos     int
+
+	state rtfState
+	stack []rtfState
+
+	encoding encoding.Encoding
+	codePage string
+	forced   bool
+
+	// pending holds text bytes until they are decoded together, so multibyte
+	// characters written as several \'hh escapes stay intact
+	pending []byte
+
+	// skip counts the fallback characters still to drop after a \uN character
+	skip int
+
+	// highSurrogate holds the first half of a surrogate pair until the second arrives
+	highSurrogate rune
+
+	text       strings.Builder
+	info       map[string]*strings.Builder
+	dates      map[string]time.Time
+	paragraphs int
+	rows       int
+}
+
+// parse reads the whole document
+func (p *rtfParser) parse() {
+	for p.pos < len(p.content) {
+		c := p.content[p.pos]
+		switch c {
+		case '{':
+			p.pos++
+			p.flush()
+			p.stack = append(p.stack, p.state)
+			p.skip = 0
+		case '}':
+			p.pos++
+			p.flush()
+			if len(p.stack) > 0 {
+				p.state = p.stack[len(p.stack)-1]
+				p.stack = p.stack[:len(p.stack)-1]
+			}
+			p.skip = 0
+		case '\\':
+			p.pos++
+			p.control()
+		case '\r', '\n':
+			// Line breaks in the source are not text
+			p.pos++
+		default:
+			p.pos++
+			p.writeByte(c)
+		}
+	}
+	p.flush()
+	if p.highSurrogate != 0 {
+		p.output("")
+	}
+}
+
+// control handles the control word or symbol after a backslash
+func (p *rtfParser) control() {
+	if p.pos >= len(p.content) {
+		return
+	}
+
+	c := p.content[p.pos]
+	if !isASCIILetter(c) {
+		p.pos++
+		switch c {
+		case '\'':
+			if p.pos+2 <= len(p.content) {
+				if b, err := strconv.ParseUint(string(p.content[p.pos:p.pos+2]), 16, 8); err == nil {
+					p.pos += 2
+					p.writeByte(byte(b))
+				}
+			}
+		case '\\', '{', '}':
+			p.writeByte(c)
+		case '~':
+			p.writeString("\u00a0")
+		case '_':
+			p.writeString("\u2011")
+		case '\r', '\n':
+			p.word("par", 0, false)
+		case '*':
+			// The group is an optional destination, dropped unless it is understood
+			if p.pos+1 < len(p.content) && p.content[p.pos] == '\\' && isASCIILetter(p.content[p.pos+1]) {
+				p.pos++
+				name, param, hasParam := p.readWord()
+				if _, info := rtfInfoFields[name]; !info && rtfSymbols[name] == "" {
+					p.flush()
+					p.state.destination = "skip"
+				}
+				p.word(name, param, hasParam)
+			}
+		default:
+			// Other symbols, such as the optional hyphen \-, have no text
+			if p.skip > 0 {
+				p.skip--
+			}
+		}
+		return
+	}
+
+	name, param, hasParam := p.readWord()
+	p.word(name, param, hasParam)
+}
+
+// readWord reads a control word with its optional numeric parameter and
+// delimiting space
+func (p *rtfParser) readWord() (string, int, bool) {
+	start := p.pos
+	for p.pos < len(p.content) && isASCIILetter(p.content[p.pos]) {
+		p.pos++
+	}
+	name := string(p.content[start:p.pos])
+
+	numStart := p.pos
+	if p.pos < len(p.content) && p.content[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.content) && p.content[p.pos] >= '0' && p.content[p.pos] <= '9' {
+		p.pos++
+	}
+	param, err := strconv.Atoi(string(p.content[numStart:p.pos]))
+	hasParam := err == nil
+	if !hasParam {
+		p.pos = numStart
+	}
+
+	if p.pos < len(p.content) && p.content[p.pos] == ' ' {
+		p.pos++
+	}
+	return name, param, hasParam
+}
+
+// word applies a control word
+func (p *rtfParser) word(name string, param int, hasParam bool) {
+	if symbol, ok := rtfSymbols[name]; ok {
+		if name == "par" && p.state.destination == "" {
+			p.paragraphs++
+		}
+		p.writeString(symbol)
+		return
+	}
+
+	switch name {
+	case "ansicpg":
+		if !p.forced {
+			p.setCodePage(rtfCodePageName(param))
+		}
+	case "mac":
+		if !p.forced {
+			p.setCodePage("macintosh")
+		}
+	case "pc":
+		if !p.forced {
+			p.setCodePage("IBM437")
+		}
+	case "pca":
+		if !p.forced {
+			p.setCodePage("IBM850")
+		}
+	case "uc":
+		if hasParam && param >= 0 {
+			p.state.ucSkip = param
+		}
+	case "u":
+		if hasParam {
+			if param < 0 {
+				param += 65536
+			}
+			r := rune(param)
+			p.skip = 0
+			if utf16.IsSurrogate(r) && r < 0xdc00 {
+				// A character outside the BMP is written as two \uN surrogate halves
+				p.flush()
+				if p.highSurrogate != 0 {
+					p.output("")
+				}
+				p.highSurrogate = r
+			} else {
+				if high := p.highSurrogate; high != 0 && utf16.IsSurrogate(r) {
+					p.highSurrogate = 0
+					r = utf16.DecodeRune(high, r)
+				}
+				p.writeString(string(r))
+			}
+			p.skip = p.state.ucSkip
+		}
+	case "cell", "nestcell":
+		p.writeString("\t")
+	case "row", "nestrow":
+		if p.state.destination == "" {
+			p.rows++
+		}
+		p.writeString("\n")
+	case "v":
+		p.flush()
+		p.state.hidden = !hasParam || param != 0
+	case "plain":
+		p.flush()
+		p.state.hidden = false
+	case "bin":
+		// Binary data is skipped byte for byte
+		if hasParam && param > 0 {
+			p.pos = min(p.pos+param, len(p.content))
+		}
+	case "info":
+		p.flush()
+		p.state.destination = "info"
+	case "creatim", "revtim":
+		p.flush()
+		p.state.destination = name
+		if p.dates == nil {
+			p.dates = make(map[string]time.Time)
+		}
+		p.dates[name] = time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	case "yr", "mo", "dy", "hr", "min":
+		if date, ok := p.dates[p.state.destination]; ok && hasParam {
+			year, month, day := date.Date()
+			hour, minute := date.Hour(), date.Minute()
+			switch name {
+			case "yr":
+				year = param
+			case "mo":
+				month = time.Month(param)
+			case "dy":
+				day = param
+			case "hr":
+				hour = param
+			case "min":
+				minute = param
+			}
+			p.dates[p.state.destination] = time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+		}
+	default:
+		if rtfSkippedDestinations[name] {
+			p.flush()
+			p.state.destination = "skip"
+		} else if _, ok := rtfInfoFields[name]; ok && p.state.destination == "info" {
+			p.flush()
+			p.state.destination = name
+		}
+	}
+}
+
+// setCodePage switches the encoding of text bytes
+func (p *rtfParser) setCodePage(name string) {
+	if enc, canonical, err := lookupEncoding(name); err == nil {
+		p.flush()
+		p.encoding, p.codePage = enc, canonical
+	}
+}
+
+// writeByte queues a text byte for decoding, or drops it as the fallback of
+// a \uN character
+func (p *rtfParser) writeByte(b byte) {
+	if p.skip > 0 {
+		p.skip--
+		return
+	}
+	p.pending = append(p.pending, b)
+}
+
+// writeString writes decoded text to the current destination
+func (p *rtfParser) writeString(s string) {
+	p.flush()
+	if p.skip > 0 {
+		p.skip--
+		return
+	}
+	p.output(s)
+}
+
+// flush decodes the queued text bytes
+func (p *rtfParser) flush() {
+	if len(p.pending) == 0 {
+		return
+	}
+	decoded, err := p.encoding.NewDecoder().Bytes(p.pending)
+	if err != nil {
+		decoded = bytes.ToValidUTF8(p.pending, []byte(string(utf8.RuneError)))
+	}
+	p.pending = p.pending[:0]
+	p.output(string(decoded))
+}
+
+// output appends text to the current destination
+func (p *rtfParser) output(s string) {
+	if p.highSurrogate != 0 {
+		// The high surrogate was not followed by its low half
+		s = string(utf8.RuneError) + s
+		p.highSurrogate = 0
+	}
+
+	switch p.state.destination {
+	case "":
+		if !p.state.hidden {
+			p.text.WriteString(s)
+		}
+	case "skip", "info", "creatim", "revtim":
+	default:
+		field, ok := p.info[p.state.destination]
+		if !ok {
+			field = &strings.Builder{}
+			p.info[p.state.destination] = field
+		}
+		field.WriteString(s)
+	}
+}
+
+// addMetadata records the document properties of the \info group
+func (p *rtfParser) addMetadata(metadata map[string]interface{}) {
+	for field, key := range rtfInfoFields {
+		builder, ok := p.info[field]
+		if !ok {
+			continue
+		}
+		value := collapseSpace(builder.String())
+		switch {
+		case value == "":
+		case key == "keywords":
+			var keywords []string
+			separator := " "
+			if strings.Contains(value, ",") {
+				separator = ","
+			}
+			for _, keyword := range strings.Split(value, separator) {
+				if keyword = strings.TrimSpace(keyword); keyword != "" {
+					keywords = append(keywords, keyword)
+				}
+			}
+			metadata["keywords"] = keywords
+		default:
+			metadata[key] = value
+		}
+	}
+	for field, key := range map[string]string{"creatim": "created", "revtim": "modified"} {
+		if date, ok := p.dates[field]; ok && date.Year() > 0 {
+			metadata[key] = date.Format("2006-01-02T15:04:05")
+		}
+	}
+}
+
+// rtfCodePageName maps an \ansicpg code page number to an encoding name
+func rtfCodePageName(codePage int) string {
+	switch codePage {
+	case 932:
+		return "shift_jis"
+	case 936:
+		return "gbk"
+	case 949:
+		return "euc-kr"
+	case 950:
+		return "big5"
+	case 10000:
+		return "macintosh"
+	case 20866:
+		return "koi8-r"
+	case 65001:
+		return "utf-8"
+	case 437, 850, 852, 855, 860, 862, 863, 865, 866:
+		return "IBM" + strconv.Itoa(codePage)
+	}
+	return "windows-" + strconv.Itoa(codePage)
+}
+
+// tidyRTFText trims trailing whitespace from each line, including the tab
+// left after the last cell of a row, and drops leading and trailing blank lines
+func tidyRTFText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// isASCIILetter reports whether c is an ASCII letter, which starts a control word
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,329 @@
+package extractor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SVGExtractor extracts the visible text of SVG drawings
+type SVGExtractor struct {
+	MaxFileSize int64 // Maximum file size in bytes (default: 50MB)
+
+	// IncludeDescriptions adds the contents of <title> and <desc> elements,
+	// which viewers show as tooltips or read to screen reader users
+	IncludeDescriptions bool
+}
+
+// NewSVGExtractor creates a new SVG extractor
+func NewSVGExtractor() *SVGExtractor {
+	return &SVGExtractor{
+		MaxFileSize: 50 * 1024 * 1024, // 50MB default
+	}
+}
+
+// svgNonRendered lists elements whose content is never drawn directly
+var svgNonRendered = map[string]bool{
+	"defs":     true,
+	"symbol":   true,
+	"clipPath": true,
+	"mask":     true,
+	"pattern":  true,
+	"marker":   true,
+	"metadata": true,
+	"script":   true,
+	"style":    true,
+}
+
+// svgBlockElements lists the HTML elements inside a foreignObject that end a line
+var svgBlockElements = map[string]bool{
+	"p": true, "div": true, "li": true, "tr": true, "br": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// Extract extracts the text of an SVG document
+func (e *SVGExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
+	start := time.Now()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SVG content: %w", err)
+	}
+
+	maxSize := e.MaxFileSize
+	if options.MaxFileSize > 0 {
+		maxSize = options.MaxFileSize
+	}
+	if maxSize > 0 && int64(len(content)) > maxSize {
+		return nil, NewExtractorError("file too large", "svg", "size_check", nil)
+	}
+
+	// Content with a byte order mark is converted to UTF-8 before parsing
+	_, enc, bomSize := sniffBOM(content)
+	if enc != nil {
+		content, err = enc.NewDecoder().Bytes(content[bomSize:])
+		if err != nil {
+			return nil, NewExtractorError("failed to convert encoding", "svg", "encoding", err)
+		}
+	}
+
+	document, err := e.parse(content, enc != nil)
+	if err != nil {
+		return nil, NewExtractorError("invalid SVG document", "svg", "parse", err)
+	}
+
+	text := strings.Join(document.lines, "\n")
+	metadata := map[string]interface{}{
+		"text_elements": document.textElements,
+		"line_count":    len(document.lines),
+		"word_count":    len(strings.Fields(text)),
+		"char_count":    len([]rune(text)),
+		"bom":           enc != nil,
+	}
+	if document.title != "" {
+		metadata["title"] = document.title
+	}
+	if document.width != "" {
+		metadata["width"] = document.width
+	}
+	if document.height != "" {
+		metadata["height"] = document.height
+	}
+	if box := strings.Fields(strings.ReplaceAll(document.viewBox, ",", " ")); len(box) == 4 {
+		values := make([]float64, 4)
+		for i, field := range box {
+			values[i], err = strconv.ParseFloat(field, 64)
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			metadata["viewbox"] = document.viewBox
+			metadata["viewbox_x"] = values[0]
+			metadata["viewbox_y"] = values[1]
+			metadata["viewbox_width"] = values[2]
+			metadata["viewbox_height"] = values[3]
+		}
+	}
+
+	return &ExtractResult{
+		Text:           text,
+		Metadata:       metadata,
+		FileType:       "svg",
+		ProcessingTime: time.Since(start),
+	}, nil
+}
+
+// ExtractFromFile extracts the text of an SVG file
+func (e *SVGExtractor) ExtractFromFile(filePath string, options ExtractOptions) (*ExtractResult, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return e.Extract(file, options)
+}
+
+// SupportedTypes returns the file types supported by this extractor
+func (e *SVGExtractor) SupportedTypes() []string {
+	return []string{"svg"}
+}
+
+// svgDocument is the text and properties read from an SVG document
+type svgDocument struct {
+	lines        []string
+	textElements int
+
+	// title is the title of the root element
+	title string
+
+	width, height, viewBox string
+}
+
+// svgElement tracks an open element while parsing
+type svgElement struct {
+	name string
+
+	// hidden is set inside display:none elements and non-rendered containers
+	hidden bool
+
+	// invisible is the inherited visibility:hidden state
+	invisible bool
+}
+
+// parse walks the document, collecting the text of <text> elements, foreign
+// objects and optionally titles and descriptions, in document order. Content
+// that is already converted to UTF-8 is read as such whatever encoding its XML
+// declaration names.
+func (e *SVGExtractor) parse(content []byte, converted bool) (*svgDocument, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if converted {
+			return input, nil
+		}
+		enc, _, err := lookupEncoding(label)
+		if err != nil {
+			return nil, err
+		}
+		return enc.NewDecoder().Reader(input), nil
+	}
+
+	document := &svgDocument{}
+	var stack []svgElement
+	var line strings.Builder
+
+	// collecting is the element whose text is being gathered, if any
+	collecting := ""
+	collectDepth := 0
+	flush := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			document.lines = append(document.lines, text)
+		}
+		line.Reset()
+	}
+
+	sawRoot := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			parent := svgElement{}
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			element := svgElement{name: t.Name.Local, hidden: parent.hidden || svgNonRendered[t.Name.Local], invisible: parent.invisible}
+			display, visibility := svgPresentation(t.Attr)
+			if display == "none" {
+				element.hidden = true
+			}
+			switch visibility {
+			case "hidden", "collapse":
+				element.invisible = true
+			case "visible":
+				element.invisible = false
+			}
+
+			if !sawRoot {
+				if t.Name.Local != "svg" {
+					return nil, fmt.Errorf("root element is <%s>, not <svg>", t.Name.Local)
+				}
+				sawRoot = true
+				document.width = svgAttr(t.Attr, "width")
+				document.height = svgAttr(t.Attr, "height")
+				document.viewBox = svgAttr(t.Attr, "viewBox")
+			}
+			stack = append(stack, element)
+
+			switch {
+			case collecting == "text" && (element.name == "tspan" || element.name == "textPath"):
+				// A tspan positioned on its own baseline starts a new line
+				if svgAttr(t.Attr, "y") != "" || svgNumber(svgAttr(t.Attr, "dy")) != 0 {
+					flush()
+				}
+			case collecting != "":
+			case element.hidden:
+			case element.name == "text" || element.name == "foreignObject":
+				collecting, collectDepth = element.name, len(stack)
+				document.textElements++
+			case element.name == "title" && len(stack) == 2:
+				collecting, collectDepth = element.name, len(stack)
+			case (element.name == "title" || element.name == "desc") && e.IncludeDescriptions:
+				collecting, collectDepth = element.name, len(stack)
+			}
+
+		case xml.CharData:
+			if collecting == "" || len(stack) == 0 {
+				continue
+			}
+			if current := stack[len(stack)-1]; !current.hidden && !current.invisible {
+				line.Write(t)
+			}
+
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			name := stack[len(stack)-1].name
+			if collecting == "foreignObject" && svgBlockElements[name] {
+				flush()
+			}
+			if collecting != "" && len(stack) == collectDepth {
+				if collecting == "title" && collectDepth == 2 {
+					document.title = strings.Join(strings.Fields(line.String()), " ")
+					if !e.IncludeDescriptions {
+						line.Reset()
+					}
+				}
+				flush()
+				collecting = ""
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if !sawRoot {
+		return nil, fmt.Errorf("no <svg> element found")
+	}
+	return document, nil
+}
+
+// svgAttr returns the value of an attribute by local name
+func svgAttr(attrs []xml.Attr, name string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == name {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
+
+// svgPresentation returns the display and visibility of an element, from its
+// style attribute or its presentation attributes
+func svgPresentation(attrs []xml.Attr) (display, visibility string) {
+	display = svgAttr(attrs, "display")
+	visibility = svgAttr(attrs, "visibility")
+	for _, declaration := range strings.Split(svgAttr(attrs, "style"), ";") {
+		property, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		switch strings.TrimSpace(property) {
+		case "display":
+			display = value
+		case "visibility":
+			visibility = value
+		}
+	}
+	return display, visibility
+}
+
+// svgNumber parses the leading number of a length such as "1.2em", or returns 0
+func svgNumber(value string) float64 {
+	end := 0
+	for end < len(value) && strings.ContainsRune("+-.0123456789eE", rune(value[end])) {
+		end++
+	}
+	for end > 0 {
+		if number, err := strconv.ParseFloat(value[:end], 64); err == nil {
+			return number
+		}
+		end--
+	}
+	return 0
+}
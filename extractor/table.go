@@ -0,0 +1,328 @@
+package extractor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Table represents a sheet or delimited file as header-keyed records
+type Table struct {
+	// Name is the sheet name (empty for CSV/TSV input)
+	Name string
+
+	// Columns contains the column names taken from the header row
+	Columns []string
+
+	// Rows contains the data rows, each aligned with Columns
+	Rows [][]string
+
+	// HasHeader reports whether Columns came from the data or were generated
+	HasHeader bool
+}
+
+// Records returns the table rows as maps keyed by column name
+func (t *Table) Records() []map[string]string {
+	records := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		record := make(map[string]string, len(t.Columns))
+		for i, column := range t.Columns {
+			if i < len(row) {
+				record[column] = row[i]
+			} else {
+				record[column] = ""
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// TableFormat selects how a table is rendered as text
+type TableFormat string
+
+const (
+	// TableFormatTSV renders one row per line with cells separated by tabs
+	TableFormatTSV TableFormat = "tsv"
+
+	// TableFormatMarkdown renders a Markdown pipe table
+	TableFormatMarkdown TableFormat = "markdown"
+)
+
+// Render formats the table as text. The header line is included for TSV
+// only when the table has a header; Markdown always needs one and falls back
+// to the generated column names. An empty format renders TSV.
+func (t *Table) Render(format TableFormat) string {
+	var lines []string
+
+	if format == TableFormatMarkdown {
+		separators := make([]string, len(t.Columns))
+		for i := range separators {
+			separators[i] = "---"
+		}
+		lines = append(lines, markdownRow(t.Columns), markdownRow(separators))
+		for _, row := range t.Rows {
+			lines = append(lines, markdownRow(row))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if t.HasHeader {
+		lines = append(lines, tsvRow(t.Columns))
+	}
+	for _, row := range t.Rows {
+		lines = append(lines, tsvRow(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tsvRow joins cells with tabs, replacing tabs and line breaks inside cells with spaces
+func tsvRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.Join(strings.Fields(cell), " ")
+	}
+	return strings.Join(escaped, "\t")
+}
+
+// markdownRow formats cells as a Markdown table row, escaping pipes
+func markdownRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.ReplaceAll(strings.Join(strings.Fields(cell), " "), "|", "\\|")
+	}
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
+
+// TableOptions contains configuration options for table extraction
+type TableOptions struct {
+	// HeaderRow is the zero-based index of the header row; rows above it are dropped.
+	// A negative value means the data has no header and column names are generated.
+	HeaderRow int
+
+	// DetectHeader checks whether HeaderRow looks like a header and treats it
+	// as data with generated column names when it does not
+	DetectHeader bool
+
+	// SkipBlankRows drops rows where every cell is empty
+	SkipBlankRows bool
+
+	// TrimTrailingEmptyColumns drops columns at the right edge that have no
+	// header and no values
+	TrimTrailingEmptyColumns bool
+}
+
+// DefaultTableOptions returns default table extraction options
+func DefaultTableOptions() TableOptions {
+	return TableOptions{
+		HeaderRow:                0,
+		DetectHeader:             true,
+		SkipBlankRows:            true,
+		TrimTrailingEmptyColumns: true,
+	}
+}
+
+// buildTable turns raw rows into a Table according to the options. HeaderRow
+// indexes the raw rows, before blank rows are dropped; when it points at a
+// blank row and blank rows are skipped, the next row with content is used.
+func buildTable(name string, rows [][]string, options TableOptions) (*Table, error) {
+	table := &Table{Name: name}
+
+	if options.HeaderRow > 0 && options.HeaderRow >= len(rows) {
+		return nil, fmt.Errorf("header row %d is beyond the last row %d", options.HeaderRow, len(rows)-1)
+	}
+
+	var header []string
+	if options.HeaderRow >= 0 && len(rows) > 0 {
+		headerRow := options.HeaderRow
+		if options.SkipBlankRows {
+			for headerRow < len(rows)-1 && isBlankRecord(rows[headerRow]) {
+				headerRow++
+			}
+		}
+
+		// Rows above the header are dropped
+		rows = rows[headerRow:]
+		rest := rows[1:]
+		if options.SkipBlankRows {
+			rest = dropBlankRows(rest)
+		}
+		if !options.DetectHeader || detectHeader(rows[0], rest) {
+			header = rows[0]
+			rows = rest
+			table.HasHeader = true
+		}
+	}
+
+	if options.SkipBlankRows {
+		rows = dropBlankRows(rows)
+	}
+
+	width := len(header)
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	if options.TrimTrailingEmptyColumns {
+		width = usedWidth(header, rows, width)
+	}
+
+	table.Columns = columnNames(header, width)
+	table.Rows = make([][]string, 0, len(rows))
+	for _, row := range rows {
+		aligned := make([]string, width)
+		copy(aligned, row)
+		table.Rows = append(table.Rows, aligned)
+	}
+
+	return table, nil
+}
+
+// dropBlankRows removes rows whose cells are all empty or whitespace
+func dropBlankRows(rows [][]string) [][]string {
+	kept := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		for _, cell := range row {
+			if strings.TrimSpace(cell) != "" {
+				kept = append(kept, row)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// usedWidth returns the number of columns up to the last one holding any value
+func usedWidth(header []string, rows [][]string, width int) int {
+	used := 0
+	for col := 0; col < width; col++ {
+		if col < len(header) && strings.TrimSpace(header[col]) != "" {
+			used = col + 1
+			continue
+		}
+		for _, row := range rows {
+			if col < len(row) && strings.TrimSpace(row[col]) != "" {
+				used = col + 1
+				break
+			}
+		}
+	}
+	return used
+}
+
+// columnNames builds unique column names from a header row, generating names
+// for missing or blank cells and suffixing duplicates
+func columnNames(header []string, width int) []string {
+	names := make([]string, width)
+	seen := make(map[string]bool, width)
+	for i := 0; i < width; i++ {
+		name := ""
+		if i < len(header) {
+			name = strings.TrimSpace(header[i])
+		}
+		if name == "" {
+			name = fmt.Sprintf("Column%d", i+1)
+		}
+		// The suffix must not collide with a header that already has that form
+		base := name
+		for suffix := 2; seen[name]; suffix++ {
+			name = fmt.Sprintf("%s_%d", base, suffix)
+		}
+		seen[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// detectHeader tries to determine if the first row is a header by comparing it
+// against the rows below it. Each column votes: a column whose data cells are
+// consistently numeric, or consistently of one length, votes for a header when
+// the first row's cell breaks that pattern and against it otherwise.
+func detectHeader(first []string, rest [][]string) bool {
+	if len(first) == 0 || len(rest) == 0 {
+		return false
+	}
+
+	// A header is made of distinct, non-numeric labels
+	labels := make(map[string]bool, len(first))
+	nonEmpty := 0
+	for _, cell := range first {
+		cell = strings.TrimSpace(cell)
+		if cell == "" {
+			continue
+		}
+		if isNumericCell(cell) || labels[cell] {
+			return false
+		}
+		labels[cell] = true
+		nonEmpty++
+	}
+	if nonEmpty == 0 {
+		return false
+	}
+
+	// Only sample a bounded number of data rows
+	if len(rest) > 20 {
+		rest = rest[:20]
+	}
+
+	votes := 0
+	for col, label := range first {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+
+		allNumeric := true
+		length := -1
+		sameLength := true
+		values := 0
+		for _, row := range rest {
+			if col >= len(row) {
+				continue
+			}
+			value := strings.TrimSpace(row[col])
+			if value == "" {
+				continue
+			}
+			values++
+			if !isNumericCell(value) {
+				allNumeric = false
+			}
+			if length < 0 {
+				length = len([]rune(value))
+			} else if length != len([]rune(value)) {
+				sameLength = false
+			}
+		}
+		if values == 0 {
+			continue
+		}
+
+		switch {
+		case allNumeric:
+			votes++
+		case sameLength && len([]rune(label)) != length:
+			votes++
+		case sameLength:
+			votes--
+		}
+	}
+
+	return votes > 0
+}
+
+// isNumericCell reports whether a cell holds a number, allowing for common
+// currency, percent and thousands-separator decoration
+func isNumericCell(cell string) bool {
+	cell = strings.TrimSpace(cell)
+	cell = strings.TrimLeft(cell, "$€£¥")
+	cell = strings.TrimSuffix(cell, "%")
+	cell = strings.ReplaceAll(cell, ",", "")
+	if cell == "" {
+		return false
+	}
+	_, err := strconv.ParseFloat(cell, 64)
+	return err == nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tealeg/xlsx/v3"
 )
@@ -22,16 +23,25 @@ func NewXLSXExtractor() *XLSXExtractor {
 
 // Extract extracts text and metadata from an XLSX file
 func (e *XLSXExtractor) Extract(reader io.Reader, options ExtractOptions) (*ExtractResult, error) {
+	start := time.Now()
+
 	// Read all content first
 	content, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read XLSX content: %w", err)
 	}
 
-	// Get basic text extraction from content
-	result, err := e.PlainTextExtractor.Extract(strings.NewReader(string(content)), options)
-	if err != nil {
-		return nil, err
+	// Check file size limit
+	if options.MaxFileSize > 0 && int64(len(content)) > options.MaxFileSize {
+		return nil, NewExtractorError(
+			fmt.Sprintf("file size %d exceeds limit %d", len(content), options.MaxFileSize),
+			"xlsx", "size_check", nil)
+	}
+
+	result := &ExtractResult{
+		Metadata: map[string]interface{}{
+			"size_bytes": len(content),
+		},
 	}
 
 	// Create a temporary file
@@ -89,6 +99,8 @@ func (e *XLSXExtractor) Extract(reader io.Reader, options ExtractOptions) (*Extr
 	result.Metadata["character_count"] = strconv.Itoa(len(result.Text))
 	result.Metadata["line_count"] = strconv.Itoa(strings.Count(result.Text, "\n") + 1)
 
+	result.ProcessingTime = time.Since(start)
+
 	return result, nil
 }
 
@@ -102,3 +114,58 @@ func (e *XLSXExtractor) ExtractFromFile(filePath string, options ExtractOptions)
 
 	return e.Extract(file, options)
 }
+
+// ExtractTables extracts every sheet of an XLSX file as a header-keyed table
+func (e *XLSXExtractor) ExtractTables(reader io.Reader, options ExtractOptions, tableOptions TableOptions) ([]*Table, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX content: %w", err)
+	}
+
+	if options.MaxFileSize > 0 && int64(len(content)) > options.MaxFileSize {
+		return nil, NewExtractorError(
+			fmt.Sprintf("file size %d exceeds limit %d", len(content), options.MaxFileSize),
+			"xlsx", "size_check", nil)
+	}
+
+	xlsxFile, err := xlsx.OpenBinary(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX file: %w", err)
+	}
+
+	tables := make([]*Table, 0, len(xlsxFile.Sheets))
+	for _, sheet := range xlsxFile.Sheets {
+		var rows [][]string
+		err := sheet.ForEachRow(func(row *xlsx.Row) error {
+			var cells []string
+			row.ForEachCell(func(cell *xlsx.Cell) error {
+				cells = append(cells, strings.TrimSpace(cell.String()))
+				return nil
+			})
+			rows = append(rows, cells)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %s: %w", sheet.Name, err)
+		}
+
+		table, err := buildTable(sheet.Name, rows, tableOptions)
+		if err != nil {
+			return nil, NewExtractorError(fmt.Sprintf("invalid table options for sheet %s", sheet.Name), "xlsx", "table", err)
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+// ExtractTablesFromFile extracts every sheet of an XLSX file as a header-keyed table
+func (e *XLSXExtractor) ExtractTablesFromFile(filePath string, options ExtractOptions, tableOptions TableOptions) ([]*Table, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return e.ExtractTables(file, options, tableOptions)
+}
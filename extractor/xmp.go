@@ -0,0 +1,143 @@
+package extractor
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// XMPMetadata holds commonly used properties of an XMP metadata packet
+type XMPMetadata struct {
+	Title       string
+	Creators    []string
+	Description string
+	Subjects    []string
+	Keywords    string
+	Rights      string
+	CreatorTool string
+	Producer    string
+	CreateDate  time.Time
+	ModifyDate  time.Time
+
+	// Raw is the complete XMP packet
+	Raw string
+}
+
+// parseXMP parses the properties of interest from an XMP packet
+func parseXMP(raw []byte) *XMPMetadata {
+	result := &XMPMetadata{Raw: string(raw)}
+	assign := func(property, value string) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		switch property {
+		case "dc:title":
+			if result.Title == "" {
+				result.Title = value
+			}
+		case "dc:creator":
+			result.Creators = append(result.Creators, value)
+		case "dc:description":
+			if result.Description == "" {
+				result.Description = value
+			}
+		case "dc:subject":
+			result.Subjects = append(result.Subjects, value)
+		case "pdf:Keywords":
+			result.Keywords = value
+		case "dc:rights":
+			if result.Rights == "" {
+				result.Rights = value
+			}
+		case "xmp:CreatorTool":
+			result.CreatorTool = value
+		case "pdf:Producer":
+			result.Producer = value
+		case "xmp:CreateDate":
+			result.CreateDate, _ = parseXMPDate(value)
+		case "xmp:ModifyDate":
+			result.ModifyDate, _ = parseXMPDate(value)
+		}
+	}
+
+	// XMP properties are written either as elements, whose values may be
+	// wrapped in rdf:Seq/rdf:Bag/rdf:Alt lists, or as attributes of rdf:Description
+	decoder := xml.NewDecoder(strings.NewReader(result.Raw))
+	decoder.Strict = false
+	var property string
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := xmpName(t.Name)
+			if name == "rdf:Description" {
+				for _, attr := range t.Attr {
+					assign(xmpName(attr.Name), attr.Value)
+				}
+				continue
+			}
+			if property == "" && strings.Contains(name, ":") && !strings.HasPrefix(name, "rdf:") && !strings.HasPrefix(name, "x:") {
+				property = name
+			}
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			name := xmpName(t.Name)
+			switch {
+			case name == "rdf:li" && property != "":
+				assign(property, text.String())
+			case name == property:
+				assign(property, text.String())
+				property = ""
+			}
+			text.Reset()
+		}
+	}
+
+	return result
+}
+
+// xmpNamespaces maps XMP namespace URIs to their conventional prefixes
+var xmpNamespaces = map[string]string{
+	"http://purl.org/dc/elements/1.1/":            "dc",
+	"http://ns.adobe.com/xap/1.0/":                "xmp",
+	"http://ns.adobe.com/pdf/1.3/":                "pdf",
+	"http://www.w3.org/1999/02/22-rdf-syntax-ns#": "rdf",
+	"adobe:ns:meta/":                              "x",
+}
+
+// xmpName returns a qualified name using the conventional prefix for its namespace
+func xmpName(name xml.Name) string {
+	if prefix, ok := xmpNamespaces[name.Space]; ok {
+		return prefix + ":" + name.Local
+	}
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+// parseXMPDate parses the ISO 8601 subset used by XMP dates
+func parseXMPDate(value string) (time.Time, bool) {
+	layouts := []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02",
+		"2006-01",
+		"2006",
+	}
+	for _, layout := range layouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
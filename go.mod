@@ -3,10 +3,15 @@ module github.com/Puhan-Zhou/go-filetext
 go 1.24.6
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/dslipak/pdf v0.0.2
 	github.com/gabriel-vasile/mimetype v1.4.9
 	github.com/tealeg/xlsx/v3 v3.0.0
+	github.com/yuin/goldmark v1.8.2
+	golang.org/x/image v0.25.0
+	golang.org/x/net v0.39.0
 	golang.org/x/text v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -18,6 +23,5 @@ require (
 	github.com/peterbourgon/diskv v2.0.1+incompatible // indirect
 	github.com/rogpeppe/fastuuid v1.2.0 // indirect
 	github.com/shabbyrobe/xmlwriter v0.0.0-20200208144257-9fca06d00ffa // indirect
-	golang.org/x/net v0.39.0 // indirect
 	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
 )
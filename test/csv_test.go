@@ -0,0 +1,164 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Puhan-Zhou/go-filetext/extractor"
+)
+
+func TestCSVExtraction(t *testing.T) {
+	csvExtractor := extractor.NewCSVExtractor()
+	options := extractor.DefaultExtractOptions()
+
+	result, err := csvExtractor.ExtractFromFile("testdata/sample.csv", options)
+	if err != nil {
+		t.Fatalf("CSV extraction failed: %v", err)
+	}
+
+	if result.FileType != "csv" {
+		t.Errorf("Expected file type 'csv', got '%s'", result.FileType)
+	}
+
+	if result.Metadata["delimiter"] != "," {
+		t.Errorf("Expected ',' delimiter, got %v", result.Metadata["delimiter"])
+	}
+
+	if result.Metadata["column_count"] != 5 {
+		t.Errorf("Expected 5 columns, got %v", result.Metadata["column_count"])
+	}
+
+	if result.Metadata["row_count"] != 6 {
+		t.Errorf("Expected 6 rows, got %v", result.Metadata["row_count"])
+	}
+
+	if result.Metadata["has_header"] != true {
+		t.Error("Expected header to be detected")
+	}
+
+	t.Logf("Metadata: %+v", result.Metadata)
+}
+
+func TestCSVDialectSniffing(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		fileType  string
+		delimiter string
+		quote     string
+		columns   int
+		rows      int
+	}{
+		{
+			name:      "quoted commas and embedded newlines",
+			input:     "name,notes\n\"Doe, John\",\"line one\nline two\"\n\"Smith, Jane\",plain\n",
+			delimiter: ",",
+			quote:     "\"",
+			columns:   2,
+			rows:      3,
+		},
+		{
+			name:      "semicolon separated",
+			input:     "name;amount;city\nJohn;1,50;Paris\nJane;2,75;Lyon\n",
+			delimiter: ";",
+			quote:     "\"",
+			columns:   3,
+			rows:      3,
+		},
+		{
+			name:      "tab separated",
+			input:     "name\tage\nJohn, Jr.\t30\nJane\t25\n",
+			delimiter: "\t",
+			quote:     "\"",
+			columns:   2,
+			rows:      3,
+		},
+		{
+			name:      "pipe separated with single quotes",
+			input:     "'id'|'label'\n1|'a|b'\n2|'c'\n",
+			delimiter: "|",
+			quote:     "'",
+			columns:   2,
+			rows:      3,
+		},
+		{
+			name:      "tsv hint",
+			input:     "single\nvalue\n",
+			fileType:  "tsv",
+			delimiter: "\t",
+			quote:     "\"",
+			columns:   1,
+			rows:      2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := extractor.DefaultExtractOptions()
+			options.FileType = tt.fileType
+
+			result, err := extractor.NewCSVExtractor().Extract(strings.NewReader(tt.input), options)
+			if err != nil {
+				t.Fatalf("CSV extraction failed: %v", err)
+			}
+
+			if result.Metadata["delimiter"] != tt.delimiter {
+				t.Errorf("Expected delimiter %q, got %q", tt.delimiter, result.Metadata["delimiter"])
+			}
+			if result.Metadata["quote_char"] != tt.quote {
+				t.Errorf("Expected quote %q, got %q", tt.quote, result.Metadata["quote_char"])
+			}
+			if result.Metadata["column_count"] != tt.columns {
+				t.Errorf("Expected %d columns, got %v", tt.columns, result.Metadata["column_count"])
+			}
+			if result.Metadata["row_count"] != tt.rows {
+				t.Errorf("Expected %d rows, got %v", tt.rows, result.Metadata["row_count"])
+			}
+			if _, exists := result.Metadata["parse_errors"]; exists {
+				t.Errorf("Unexpected parse errors: %v", result.Metadata["parse_errors"])
+			}
+		})
+	}
+}
+
+func TestCSVParseErrors(t *testing.T) {
+	input := "a,b\n1,\"unterminated\n2,3\n"
+
+	result, err := extractor.NewCSVExtractor().Extract(strings.NewReader(input), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("CSV extraction failed: %v", err)
+	}
+
+	parseErrors, ok := result.Metadata["parse_errors"].([]string)
+	if !ok || len(parseErrors) != 1 {
+		t.Fatalf("Expected one parse error, got %v", result.Metadata["parse_errors"])
+	}
+
+	if !strings.HasPrefix(parseErrors[0], "line 2, column 3") {
+		t.Errorf("Expected error at line 2, column 3, got %s", parseErrors[0])
+	}
+	// Table extraction returns the table along with the typed errors
+	tables, err := extractor.NewCSVExtractor().ExtractTables(strings.NewReader(input), extractor.DefaultExtractOptions(), extractor.DefaultTableOptions())
+	var parseErr *extractor.CSVParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a *CSVParseError, got %v", err)
+	}
+	if parseErr.Line != 2 || parseErr.Column != 3 {
+		t.Errorf("Expected error at line 2, column 3, got line %d, column %d", parseErr.Line, parseErr.Column)
+	}
+	if len(tables) != 1 || len(tables[0].Columns) != 2 {
+		t.Errorf("Expected the parsed table with the error, got %v", tables)
+	}
+}
+
+func TestCSVFactory(t *testing.T) {
+	extractorInstance, err := extractor.CreateExtractorFromPath("testdata/sample.csv")
+	if err != nil {
+		t.Fatalf("CreateExtractorFromPath failed: %v", err)
+	}
+
+	if _, ok := extractorInstance.(*extractor.CSVExtractor); !ok {
+		t.Errorf("Expected *extractor.CSVExtractor, got %T", extractorInstance)
+	}
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Puhan-Zhou/go-filetext/extractor"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestHTMLExtraction(t *testing.T) {
+	htmlExtractor := extractor.NewHTMLExtractor()
+	result, err := htmlExtractor.ExtractHTMLFromFile("testdata/sample.html", extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("HTML extraction failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"Caching strategies\n\nOur API served most requests from the database, so latency grew with traffic.",
+		"Layer\tHit rate\tp99 latency\nIn-process\t61%\t4 ms\nRedis\t27%\t11 ms",
+		"cache.Get(key)\n  .OrLoad(load)",
+		"Read the Redis documentation for eviction policies.\nQuestions are welcome.",
+		"© 2024 Example Corp",
+	} {
+		if !strings.Contains(result.Text, expected) {
+			t.Errorf("Expected text to contain %q, got %q", expected, result.Text)
+		}
+	}
+	for _, dropped := range []string{"font-family", "analytics", "Archive", "Enable JavaScript", "Draft note", "<p>"} {
+		if strings.Contains(result.Text, dropped) {
+			t.Errorf("Expected %q to be dropped, got %q", dropped, result.Text)
+		}
+	}
+
+	expectedMetadata := map[string]interface{}{
+		"title":       "Caching strategies – Engineering Blog",
+		"description": "How we cut API latency with layered caches.",
+		"author":      "Sam Rivera",
+		"language":    "en",
+		"encoding":    "utf-8",
+		"link_count":  7,
+	}
+	for key, value := range expectedMetadata {
+		if result.Metadata[key] != value {
+			t.Errorf("Metadata[%q] = %v, expected %v", key, result.Metadata[key], value)
+		}
+	}
+	if keywords := result.Metadata["keywords"]; !reflect.DeepEqual(keywords, []string{"caching", "latency", "redis"}) {
+		t.Errorf("Expected keywords [caching latency redis], got %v", keywords)
+	}
+	if len(result.Links) != 7 || result.Links[3] != (extractor.HTMLLink{URL: "https://redis.io/docs/", Text: "Redis documentation"}) {
+		t.Errorf("Unexpected links: %v", result.Links)
+	}
+
+	// The main content mode keeps the article only
+	htmlExtractor.MainContent = true
+	result, err = htmlExtractor.ExtractHTMLFromFile("testdata/sample.html", extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("HTML extraction failed: %v", err)
+	}
+	if !strings.HasPrefix(result.Text, "Caching strategies\n\n") || !strings.HasSuffix(result.Text, "Questions are welcome.") {
+		t.Errorf("Expected the article text, got %q", result.Text)
+	}
+	for _, dropped := range []string{"Engineering Blog", "Share", "Related posts", "Queues at scale", "Example Corp"} {
+		if strings.Contains(result.Text, dropped) {
+			t.Errorf("Expected boilerplate %q to be dropped, got %q", dropped, result.Text)
+		}
+	}
+	if result.Metadata["main_content"] != true || len(result.Links) != 7 {
+		t.Errorf("Expected main content with all 7 links, got %v with %d links", result.Metadata["main_content"], len(result.Links))
+	}
+
+	// The factory routes HTML files to the HTML extractor
+	created, err := extractor.CreateExtractorFromPath("testdata/sample.html")
+	if err != nil {
+		t.Fatalf("CreateExtractorFromPath failed: %v", err)
+	}
+	if _, ok := created.(*extractor.HTMLExtractor); !ok {
+		t.Errorf("Expected *extractor.HTMLExtractor, got %T", created)
+	}
+}
+
+func TestHTMLEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		encode   func(string) []byte
+		encoding string
+		text     string
+	}{
+		{
+			name:     "meta charset",
+			document: `<html><head><meta charset="Shift_JIS"><title>お知らせ</title></head><body><p>本日は晴天なり</p></body></html>`,
+			encode: func(s string) []byte {
+				b, _ := japanese.ShiftJIS.NewEncoder().Bytes([]byte(s))
+				return b
+			},
+			encoding: "shift_jis",
+			text:     "本日は晴天なり",
+		},
+		{
+			name:     "http-equiv",
+			document: `<html><head><meta http-equiv="Content-Type" content="text/html; charset=windows-1251"></head><body><div>Привет</div></body></html>`,
+			encode: func(s string) []byte {
+				b, _ := charmap.Windows1251.NewEncoder().Bytes([]byte(s))
+				return b
+			},
+			encoding: "windows-1251",
+			text:     "Привет",
+		},
+		{
+			name:     "XHTML declaration",
+			document: `<?xml version="1.0" encoding="ISO-8859-1"?><html xmlns="http://www.w3.org/1999/xhtml"><body><p>Größe</p><br/><p>Ende</p></body></html>`,
+			encode: func(s string) []byte {
+				b, _ := charmap.ISO8859_1.NewEncoder().Bytes([]byte(s))
+				return b
+			},
+			encoding: "windows-1252",
+			text:     "Größe\n\nEnde",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := extractor.NewHTMLExtractor().Extract(bytes.NewReader(tt.encode(tt.document)), extractor.DefaultExtractOptions())
+			if err != nil {
+				t.Fatalf("HTML extraction failed: %v", err)
+			}
+			if result.Text != tt.text {
+				t.Errorf("Expected %q, got %q", tt.text, result.Text)
+			}
+			if result.Metadata["encoding"] != tt.encoding {
+				t.Errorf("Expected encoding %s, got %v", tt.encoding, result.Metadata["encoding"])
+			}
+		})
+	}
+
+	// Inline elements stay on the line and hidden elements are dropped
+	document := `<p>A <b>bold</b><i>claim</i>, <span style="display: none">secret</span><span aria-hidden="true">★</span>then&#32;more&hellip;<br><br>After</p>`
+	result, err := extractor.NewHTMLExtractor().Extract(strings.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("HTML extraction failed: %v", err)
+	}
+	if expected := "A boldclaim, then more…\n\nAfter"; result.Text != expected {
+		t.Errorf("Expected %q, got %q", expected, result.Text)
+	}
+}
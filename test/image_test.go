@@ -1,12 +1,75 @@
 package test
 
 import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Puhan-Zhou/go-filetext/extractor"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/tiff"
 )
 
+// renderTestText draws lines of dark text on a light background with the Go
+// font, rotated clockwise by the given angle in degrees
+func renderTestText(t *testing.T, lines []string, size float64, angle float64) *image.Gray {
+	t.Helper()
+	parsed, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatalf("failed to parse font: %v", err)
+	}
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		t.Fatalf("failed to load font: %v", err)
+	}
+
+	lineHeight := int(size * 1.6)
+	img := image.NewGray(image.Rect(0, 0, 40*int(size), lineHeight*len(lines)+2*int(size)))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for i, line := range lines {
+		drawer := font.Drawer{Dst: img, Src: image.Black, Face: face, Dot: fixed.P(int(size), lineHeight*(i+1))}
+		drawer.DrawString(line)
+	}
+	if angle == 0 {
+		return img
+	}
+
+	rotated := image.NewGray(img.Bounds())
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	cx, cy := float64(img.Bounds().Dx())/2, float64(img.Bounds().Dy())/2
+	for y := 0; y < rotated.Bounds().Dy(); y++ {
+		for x := 0; x < rotated.Bounds().Dx(); x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			source := image.Pt(int(cos*dx+sin*dy+cx), int(-sin*dx+cos*dy+cy))
+			value := uint8(255)
+			if source.In(img.Bounds()) {
+				value = img.GrayAt(source.X, source.Y).Y
+			}
+			rotated.SetGray(x, y, color.Gray{Y: value})
+		}
+	}
+	return rotated
+}
+
 func TestImageExtraction(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -28,6 +91,7 @@ func TestImageExtraction(t *testing.T) {
 			options := extractor.DefaultExtractOptions()
 
 			extractorInstance := extractor.NewImageExtractor()
+			extractorInstance.OCR = &fakeOCREngine{text: tt.expected}
 			result, err := extractorInstance.ExtractFromFile(filePath, options)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ImageExtractor.ExtractFromFile() error = %v, wantErr %v", err, tt.wantErr)
@@ -54,11 +118,661 @@ func TestImageExtraction(t *testing.T) {
 	}
 }
 
+func TestImageOCR(t *testing.T) {
+	filePath := filepath.Join("testdata", "sample.png")
+	options := extractor.DefaultExtractOptions()
+	options.OCRLanguage = "fra"
+
+	// Without an engine only the image properties are extracted
+	extractorInstance := extractor.NewImageExtractor()
+	extractorInstance.OCR = nil
+	result, err := extractorInstance.ExtractImageFromFile(filePath, options)
+	if err != nil {
+		t.Fatalf("ImageExtractor.ExtractImageFromFile() error = %v", err)
+	}
+	if result.Text != "" || result.OCR != nil || result.Metadata["ocr"] != false {
+		t.Errorf("Expected no text without an OCR engine, got %q", result.Text)
+	}
+	if result.Metadata["width"] == nil || result.Metadata["height"] == nil {
+		t.Errorf("Expected image dimensions in metadata: %v", result.Metadata)
+	}
+
+	engine := &fakeOCREngine{text: "A picture sample"}
+	extractorInstance.OCR = engine
+	result, err = extractorInstance.ExtractImageFromFile(filePath, options)
+	if err != nil {
+		t.Fatalf("ImageExtractor.ExtractImageFromFile() error = %v", err)
+	}
+	if len(engine.languages) != 1 || engine.languages[0] != "fra" {
+		t.Errorf("Expected OCR language fra, got %v", engine.languages)
+	}
+	if result.OCR == nil || len(result.OCR.Words) != 3 || result.OCR.Words[1].Text != "picture" {
+		t.Fatalf("Expected word-level OCR output, got %+v", result.OCR)
+	}
+	if result.Metadata["ocr_confidence"] != 0.9 || result.Metadata["word_count"] != 3 || result.Metadata["ocr_language"] != "fra" {
+		t.Errorf("Unexpected OCR metadata: %v", result.Metadata)
+	}
+}
+
+func TestBuiltinOCR(t *testing.T) {
+	engine := extractor.NewBuiltinOCREngine()
+	lines := []string{"Hello World 42", "The quick brown fox jumps over", "sum new war"}
+	expected := "Hello World 42\nThe quick brown fox jumps over\nsum new war"
+
+	result, err := engine.Recognize(renderTestText(t, lines, 24, 0), extractor.OCROptions{Language: "eng"})
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	if result.Text != expected {
+		t.Errorf("Recognize() text = %q, expected %q", result.Text, expected)
+	}
+	if len(result.Words) != 12 || result.Words[1].Text != "World" || result.Confidence < 0.8 {
+		t.Errorf("Unexpected words or confidence: %d words, confidence %.2f", len(result.Words), result.Confidence)
+	}
+	if first, second := result.Words[0].Bounds, result.Words[1].Bounds; first.Max.X > second.Min.X || first.Min.Y > second.Max.Y {
+		t.Errorf("Unexpected word bounds %v and %v", first, second)
+	}
+
+	// Slightly rotated scans are straightened before segmentation
+	result, err = engine.Recognize(renderTestText(t, lines[1:], 24, 2), extractor.OCROptions{Language: "eng"})
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	if result.Text != "The quick brown fox jumps over\nsum new war" {
+		t.Errorf("Recognize() on rotated image text = %q", result.Text)
+	}
+
+	// Light text on a dark background
+	inverted := renderTestText(t, lines[:1], 24, 0)
+	for i := range inverted.Pix {
+		inverted.Pix[i] = 255 - inverted.Pix[i]
+	}
+	result, err = engine.Recognize(inverted, extractor.OCROptions{Language: "eng"})
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	if result.Text != "Hello World 42" {
+		t.Errorf("Recognize() on inverted image text = %q", result.Text)
+	}
+
+	// The digits glyph set only produces numbers
+	result, err = engine.Recognize(renderTestText(t, []string{"$1,234.50"}, 24, 0), extractor.OCROptions{Language: "digits"})
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	if result.Text != "$1,234.50" {
+		t.Errorf("Recognize() with digits text = %q", result.Text)
+	}
+
+	if _, err := engine.Recognize(inverted, extractor.OCROptions{Language: "klingon"}); !errors.Is(err, extractor.ErrUnsupportedOCRLanguage) {
+		t.Errorf("Expected ErrUnsupportedOCRLanguage, got %v", err)
+	}
+
+	// Through the image extractor
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, renderTestText(t, lines[:1], 24, 0)); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	imageExtractor := extractor.NewImageExtractor()
+	imageExtractor.OCR = engine
+	extracted, err := imageExtractor.Extract(&encoded, extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ImageExtractor.Extract() error = %v", err)
+	}
+	if extracted.Text != "Hello World 42" {
+		t.Errorf("ImageExtractor.Extract() text = %q", extracted.Text)
+	}
+}
+
+func TestBuiltinOCRSample(t *testing.T) {
+	// The sample is set in a sans-serif font the templates are not rendered
+	// from, with a dotted spell-check underline below the second word
+	imageExtractor := extractor.NewImageExtractor()
+	imageExtractor.OCR = extractor.NewBuiltinOCREngine()
+	result, err := imageExtractor.ExtractImageFromFile(filepath.Join("testdata", "sample.png"), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ImageExtractor.ExtractImageFromFile() error = %v", err)
+	}
+	if result.Text != "A png sample" {
+		t.Errorf("Expected %q, got %q", "A png sample", result.Text)
+	}
+	if result.OCR == nil || len(result.OCR.Words) != 3 || result.OCR.Words[2].Text != "sample" {
+		t.Errorf("Unexpected words %+v", result.OCR)
+	}
+}
+
+// withPNGChunk inserts a chunk into a PNG file after the IHDR chunk
+func withPNGChunk(data []byte, kind string, payload []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, payload...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	const ihdrEnd = 8 + 25 // the signature and the IHDR chunk
+	return append(append(append([]byte{}, data[:ihdrEnd]...), chunk...), data[ihdrEnd:]...)
+}
+
+// exifTag is a tag written by buildEXIF
+type exifTag struct {
+	tag, kind uint16
+	count     uint32
+	data      []byte
+}
+
+func exifASCII(tag uint16, value string) exifTag {
+	return exifTag{tag, 2, uint32(len(value) + 1), append([]byte(value), 0)}
+}
+
+func exifShort(tag uint16, value uint16) exifTag {
+	return exifTag{tag, 3, 1, binary.BigEndian.AppendUint16(nil, value)}
+}
+
+func exifRationals(tag uint16, values ...uint32) exifTag {
+	var data []byte
+	for _, value := range values {
+		data = binary.BigEndian.AppendUint32(data, value)
+	}
+	return exifTag{tag, 5, uint32(len(values) / 2), data}
+}
+
+// exifXP encodes a Windows Explorer property as little-endian UCS-2
+func exifXP(tag uint16, value string) exifTag {
+	var data []byte
+	for _, r := range value + "\x00" {
+		data = binary.LittleEndian.AppendUint16(data, uint16(r))
+	}
+	return exifTag{tag, 1, uint32(len(data)), data}
+}
+
+// buildEXIF writes big-endian EXIF data with a main directory and optional
+// EXIF and GPS directories, adding the pointers to them
+func buildEXIF(main, exif, gps []exifTag) []byte {
+	size := func(tags []exifTag) int {
+		total := 2 + len(tags)*12 + 4
+		for _, tag := range tags {
+			if len(tag.data) > 4 {
+				total += len(tag.data) + len(tag.data)%2
+			}
+		}
+		return total
+	}
+
+	main = append([]exifTag{}, main...)
+	if len(exif) > 0 {
+		main = append(main, exifTag{0x8769, 4, 1, make([]byte, 4)})
+	}
+	if len(gps) > 0 {
+		main = append(main, exifTag{0x8825, 4, 1, make([]byte, 4)})
+	}
+	exifOffset := 8 + size(main)
+	gpsOffset := exifOffset + size(exif)
+	for i := range main {
+		switch main[i].tag {
+		case 0x8769:
+			main[i].data = binary.BigEndian.AppendUint32(nil, uint32(exifOffset))
+		case 0x8825:
+			main[i].data = binary.BigEndian.AppendUint32(nil, uint32(gpsOffset))
+		}
+	}
+
+	data := []byte{'M', 'M', 0, 42, 0, 0, 0, 8}
+	for _, tags := range [][]exifTag{main, exif, gps} {
+		if len(tags) == 0 {
+			continue
+		}
+		extra := len(data) + 2 + len(tags)*12 + 4
+		var values []byte
+		data = binary.BigEndian.AppendUint16(data, uint16(len(tags)))
+		for _, tag := range tags {
+			data = binary.BigEndian.AppendUint16(data, tag.tag)
+			data = binary.BigEndian.AppendUint16(data, tag.kind)
+			data = binary.BigEndian.AppendUint32(data, tag.count)
+			if len(tag.data) <= 4 {
+				data = append(data, append(tag.data, make([]byte, 4-len(tag.data))...)...)
+				continue
+			}
+			data = binary.BigEndian.AppendUint32(data, uint32(extra+len(values)))
+			values = append(values, tag.data...)
+			if len(tag.data)%2 == 1 {
+				values = append(values, 0)
+			}
+		}
+		data = binary.BigEndian.AppendUint32(data, 0)
+		data = append(data, values...)
+	}
+	return data
+}
+
+func TestImagePreprocessing(t *testing.T) {
+	// A faded, small scan stored rotated counter-clockwise inside a dark frame
+	text := renderTestText(t, []string{"Hello World 42"}, 12, 0)
+	bounds := text.Bounds()
+	scan := image.NewGray(image.Rect(0, 0, bounds.Dy()+40, bounds.Dx()+40))
+	draw.Draw(scan, scan.Bounds(), image.NewUniform(color.Gray{Y: 40}), image.Point{}, draw.Src)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			scan.SetGray(20+y, 20+bounds.Dx()-1-x, color.Gray{Y: 90 + text.GrayAt(x, y).Y/2})
+		}
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, scan); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	content := withPNGChunk(encoded.Bytes(), "eXIf", buildEXIF([]exifTag{exifShort(0x0112, 6)}, nil, nil))
+
+	imageExtractor := extractor.NewImageExtractor()
+	imageExtractor.OCR = extractor.NewBuiltinOCREngine()
+	imageExtractor.Preprocessing = extractor.DefaultImagePreprocessing()
+	result, err := imageExtractor.ExtractImage(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ExtractImage() error = %v", err)
+	}
+
+	if result.Text != "Hello World 42" {
+		t.Errorf("ExtractImage() text = %q", result.Text)
+	}
+	steps, _ := result.Metadata["preprocessing"].([]string)
+	expected := []string{"exif_orientation", "crop_borders", "grayscale", "contrast_stretch", "upscale", "otsu_threshold"}
+	if strings.Join(steps, ",") != strings.Join(expected, ",") {
+		t.Errorf("preprocessing = %v, expected %v", steps, expected)
+	}
+	width, _ := result.Metadata["preprocessed_width"].(int)
+	height, _ := result.Metadata["preprocessed_height"].(int)
+	if width <= height || width > 1000 {
+		t.Errorf("Unexpected preprocessed size %dx%d", width, height)
+	}
+
+	// Adaptive thresholding of light text on a dark background
+	inverted := renderTestText(t, []string{"Hello World 42"}, 24, 0)
+	for i := range inverted.Pix {
+		inverted.Pix[i] = 255 - inverted.Pix[i]
+	}
+	encoded.Reset()
+	if err := png.Encode(&encoded, inverted); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	imageExtractor.Preprocessing = extractor.ImagePreprocessing{Threshold: extractor.ThresholdAdaptive}
+	result, err = imageExtractor.ExtractImage(&encoded, extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ExtractImage() error = %v", err)
+	}
+	if result.Text != "Hello World 42" {
+		t.Errorf("ExtractImage() with adaptive threshold text = %q", result.Text)
+	}
+	if steps, _ := result.Metadata["preprocessing"].([]string); len(steps) != 1 || steps[0] != "adaptive_threshold" {
+		t.Errorf("preprocessing = %v", steps)
+	}
+
+	// Without preprocessing the image reaches the engine unchanged
+	engine := &fakeOCREngine{}
+	imageExtractor = extractor.NewImageExtractor()
+	imageExtractor.OCR = engine
+	result, err = imageExtractor.ExtractImage(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ExtractImage() error = %v", err)
+	}
+	if want := fmt.Sprintf("scanned %dx%d starting 40", scan.Bounds().Dx(), scan.Bounds().Dy()); result.Text != want {
+		t.Errorf("ExtractImage() text = %q, expected %q", result.Text, want)
+	}
+	if steps, ok := result.Metadata["preprocessing"].([]string); !ok || len(steps) != 0 {
+		t.Errorf("preprocessing = %v", result.Metadata["preprocessing"])
+	}
+}
+
+// webpSample is a 1x1 lossless WebP image
+const webpSample = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+// uniformGray returns an image of the given size filled with one gray level
+func uniformGray(width, height int, level uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = level
+	}
+	return img
+}
+
+// buildMultiPageTIFF writes uncompressed grayscale pages to a TIFF file,
+// labelled with the given compression schemes
+func buildMultiPageTIFF(pages []*image.Gray, compression []uint16) []byte {
+	data := []byte{'I', 'I', 42, 0, 0, 0, 0, 0}
+	next := 4 // where the offset of the next directory is written
+	for i, page := range pages {
+		width, height := page.Bounds().Dx(), page.Bounds().Dy()
+		strip := len(data)
+		data = append(data, page.Pix...)
+		if len(data)%2 == 1 {
+			data = append(data, 0) // directories start on a word boundary
+		}
+
+		binary.LittleEndian.PutUint32(data[next:], uint32(len(data)))
+		entries := [][3]uint32{
+			{256, 3, uint32(width)},
+			{257, 3, uint32(height)},
+			{258, 3, 8},
+			{259, 3, uint32(compression[i])},
+			{262, 3, 1},
+			{273, 4, uint32(strip)},
+			{277, 3, 1},
+			{278, 3, uint32(height)},
+			{279, 4, uint32(width * height)},
+		}
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(entries)))
+		for _, entry := range entries {
+			data = binary.LittleEndian.AppendUint16(data, uint16(entry[0]))
+			data = binary.LittleEndian.AppendUint16(data, uint16(entry[1]))
+			data = binary.LittleEndian.AppendUint32(data, 1)
+			data = binary.LittleEndian.AppendUint32(data, entry[2])
+		}
+		next = len(data)
+		data = binary.LittleEndian.AppendUint32(data, 0)
+	}
+	return data
+}
+
+func TestImageFormats(t *testing.T) {
+	img := uniformGray(30, 20, 200)
+	var bmpData, tiffData bytes.Buffer
+	if err := bmp.Encode(&bmpData, img); err != nil {
+		t.Fatalf("failed to encode BMP: %v", err)
+	}
+	if err := tiff.Encode(&tiffData, img, nil); err != nil {
+		t.Fatalf("failed to encode TIFF: %v", err)
+	}
+	webpData, _ := base64.StdEncoding.DecodeString(webpSample)
+
+	tests := []struct {
+		name     string
+		format   string
+		data     []byte
+		expected string
+	}{
+		{"BMP", "bmp", bmpData.Bytes(), "scanned 30x20 starting 200"},
+		{"TIFF", "tiff", tiffData.Bytes(), "scanned 30x20 starting 200"},
+		{"WebP", "webp", webpData, "scanned 1x1 starting 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imageExtractor := extractor.NewImageExtractor()
+			imageExtractor.OCR = &fakeOCREngine{}
+			result, err := imageExtractor.ExtractImage(bytes.NewReader(tt.data), extractor.DefaultExtractOptions())
+			if err != nil {
+				t.Fatalf("ExtractImage() error = %v", err)
+			}
+			if result.Metadata["image_format"] != tt.format {
+				t.Errorf("image_format = %v, expected %s", result.Metadata["image_format"], tt.format)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("ExtractImage() text = %q, expected %q", result.Text, tt.expected)
+			}
+			if len(result.Pages) != 0 {
+				t.Errorf("Expected no pages for a single image, got %d", len(result.Pages))
+			}
+
+			// The factory routes every image type to the image extractor
+			path := filepath.Join(t.TempDir(), "image."+tt.format)
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatalf("failed to write image: %v", err)
+			}
+			created, err := extractor.CreateExtractorFromPath(path)
+			if err != nil {
+				t.Fatalf("CreateExtractorFromPath() error = %v", err)
+			}
+			if _, ok := created.(*extractor.ImageExtractor); !ok {
+				t.Errorf("CreateExtractorFromPath() = %T, expected *extractor.ImageExtractor", created)
+			}
+		})
+	}
+}
+
+func TestMultiPageTIFF(t *testing.T) {
+	pages := []*image.Gray{uniformGray(30, 20, 10), uniformGray(40, 25, 20), uniformGray(50, 30, 30)}
+	content := buildMultiPageTIFF(pages, []uint16{1, 1, 1})
+
+	imageExtractor := extractor.NewImageExtractor()
+	imageExtractor.OCR = &fakeOCREngine{}
+	result, err := imageExtractor.ExtractImage(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ExtractImage() error = %v", err)
+	}
+
+	expected := "scanned 30x20 starting 10\n\nscanned 40x25 starting 20\n\nscanned 50x30 starting 30"
+	if result.Text != expected {
+		t.Errorf("ExtractImage() text = %q, expected %q", result.Text, expected)
+	}
+	if len(result.Pages) != 3 || len(result.PageOCR) != 3 {
+		t.Fatalf("Expected 3 pages, got %d pages and %d OCR results", len(result.Pages), len(result.PageOCR))
+	}
+	for i, page := range result.Pages {
+		if page.Number != i+1 || page.Err != nil || page.Metadata["width"] != pages[i].Bounds().Dx() {
+			t.Errorf("Unexpected page %+v", page)
+		}
+		if got := string([]rune(result.Text)[page.Offset : page.Offset+len([]rune(page.Text))]); got != page.Text {
+			t.Errorf("Page %d offset %d does not point at its text", page.Number, page.Offset)
+		}
+	}
+	if result.PageOCR[1].Text != "scanned 40x25 starting 20" || result.OCR != nil {
+		t.Errorf("Unexpected OCR results %+v, %+v", result.PageOCR[1], result.OCR)
+	}
+	if result.Metadata["page_count"] != 3 || result.Metadata["pages_extracted"] != 3 {
+		t.Errorf("Unexpected metadata %v", result.Metadata)
+	}
+
+	// A page range, with a page the decoder cannot read
+	content = buildMultiPageTIFF(pages, []uint16{1, 99, 1})
+	options := extractor.DefaultExtractOptions()
+	options.FirstPage = 2
+	result, err = imageExtractor.ExtractImage(bytes.NewReader(content), options)
+	if err != nil {
+		t.Fatalf("ExtractImage() error = %v", err)
+	}
+	if len(result.Pages) != 2 || result.Pages[0].Number != 2 || result.Pages[0].Err == nil || result.Pages[1].Err != nil {
+		t.Fatalf("Unexpected pages %+v", result.Pages)
+	}
+	if result.Text != "\n\nscanned 50x30 starting 30" {
+		t.Errorf("ExtractImage() text = %q", result.Text)
+	}
+	if failed, _ := result.Metadata["failed_pages"].([]int); len(failed) != 1 || failed[0] != 2 {
+		t.Errorf("failed_pages = %v", result.Metadata["failed_pages"])
+	}
+
+	options.FirstPage = 4
+	if _, err := imageExtractor.ExtractImage(bytes.NewReader(content), options); err == nil {
+		t.Error("Expected an error for a page range beyond the last page")
+	}
+}
+
+// sampleXMP is an XMP packet describing a photo
+const sampleXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:CreateDate="2023-06-01T09:00:00Z">
+   <dc:title><rdf:Alt><rdf:li xml:lang="x-default">Shop receipt</rdf:li></rdf:Alt></dc:title>
+   <dc:subject><rdf:Bag><rdf:li>Receipt</rdf:li><rdf:li>food</rdf:li></rdf:Bag></dc:subject>
+   <dc:rights><rdf:Alt><rdf:li xml:lang="x-default">CC BY 4.0</rdf:li></rdf:Alt></dc:rights>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+
+// sampleEXIF is EXIF data of a photo taken in Sydney
+func sampleEXIF() []byte {
+	return buildEXIF(
+		[]exifTag{
+			exifASCII(0x010E, "Receipt from the corner shop"),
+			exifASCII(0x010F, "Canon"),
+			exifASCII(0x0110, "Canon EOS R5"),
+			exifXP(0x9C9C, "Paid in cash"),
+			exifXP(0x9C9E, "receipt;expenses"),
+		},
+		[]exifTag{
+			exifASCII(0x9003, "2023:06:01 14:30:00"),
+			exifASCII(0x9011, "+10:00"),
+		},
+		[]exifTag{
+			exifASCII(1, "S"),
+			exifRationals(2, 33, 1, 51, 1, 54, 1),
+			exifASCII(3, "E"),
+			exifRationals(4, 151, 1, 12, 1, 36, 1),
+			exifRationals(6, 58, 1),
+		},
+	)
+}
+
+// sampleIPTC is IPTC-IIM data with a caption, keywords and a byline
+func sampleIPTC() []byte {
+	var data []byte
+	for _, dataset := range []struct {
+		number byte
+		value  string
+	}{
+		{25, "receipt"},
+		{25, "groceries"},
+		{80, "Ana Lima"},
+		{90, "Sydney"},
+		{120, "Weekly shopping"},
+	} {
+		data = append(data, 0x1C, 2, dataset.number)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(dataset.value)))
+		data = append(data, dataset.value...)
+	}
+	return data
+}
+
+// jpegSegment encodes a JPEG marker segment
+func jpegSegment(marker byte, payload ...[]byte) []byte {
+	data := bytes.Join(payload, nil)
+	return append(binary.BigEndian.AppendUint16([]byte{0xFF, marker}, uint16(len(data)+2)), data...)
+}
+
+func TestImageMetadata(t *testing.T) {
+	img := uniformGray(30, 20, 200)
+
+	// A JPEG file with EXIF, XMP and IPTC in a Photoshop resource block
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, nil); err != nil {
+		t.Fatalf("failed to encode JPEG: %v", err)
+	}
+	iptc := sampleIPTC()
+	resource := append([]byte("8BIM\x04\x04\x00\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(iptc)))...)
+	var jpegData []byte
+	jpegData = append(jpegData, encoded.Bytes()[:2]...)
+	jpegData = append(jpegData, jpegSegment(0xE1, []byte("Exif\x00\x00"), sampleEXIF())...)
+	jpegData = append(jpegData, jpegSegment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00"), []byte(sampleXMP))...)
+	jpegData = append(jpegData, jpegSegment(0xED, []byte("Photoshop 3.0\x00"), resource, iptc)...)
+	jpegData = append(jpegData, encoded.Bytes()[2:]...)
+
+	imageExtractor := extractor.NewImageExtractor()
+	imageExtractor.OCR = &fakeOCREngine{text: "TOTAL 12.50"}
+	result, err := imageExtractor.ExtractImage(bytes.NewReader(jpegData), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ExtractImage() error = %v", err)
+	}
+
+	if result.EXIF == nil || result.IPTC == nil || result.XMP == nil {
+		t.Fatalf("Expected EXIF, IPTC and XMP metadata, got %+v, %+v, %+v", result.EXIF, result.IPTC, result.XMP)
+	}
+	if result.EXIF.Model != "Canon EOS R5" || result.EXIF.XPComment != "Paid in cash" {
+		t.Errorf("Unexpected EXIF metadata %+v", result.EXIF)
+	}
+	if want := time.Date(2023, 6, 1, 4, 30, 0, 0, time.UTC); !result.EXIF.DateTimeOriginal.Equal(want) {
+		t.Errorf("DateTimeOriginal = %v, expected %v", result.EXIF.DateTimeOriginal, want)
+	}
+	if gps := result.EXIF.GPS; gps == nil || gps.Latitude != -33.865 || gps.Longitude != 151.21 || gps.Altitude != 58 {
+		t.Errorf("Unexpected GPS position %+v", gps)
+	}
+	if strings.Join(result.IPTC.Keywords, ",") != "receipt,groceries" || result.IPTC.Caption != "Weekly shopping" {
+		t.Errorf("Unexpected IPTC metadata %+v", result.IPTC)
+	}
+	if result.XMP.Title != "Shop receipt" || result.XMP.Rights != "CC BY 4.0" {
+		t.Errorf("Unexpected XMP metadata %+v", result.XMP)
+	}
+
+	expected := map[string]interface{}{
+		"camera_make":   "Canon",
+		"title":         "Shop receipt",
+		"description":   "Receipt from the corner shop",
+		"comment":       "Paid in cash",
+		"author":        "Ana Lima",
+		"copyright":     "CC BY 4.0",
+		"city":          "Sydney",
+		"date_taken":    "2023-06-01T14:30:00+10:00",
+		"gps_latitude":  -33.865,
+		"gps_longitude": 151.21,
+	}
+	for key, value := range expected {
+		if result.Metadata[key] != value {
+			t.Errorf("Metadata[%q] = %v, expected %v", key, result.Metadata[key], value)
+		}
+	}
+	if keywords, _ := result.Metadata["keywords"].([]string); strings.Join(keywords, ",") != "receipt,expenses,groceries,food" {
+		t.Errorf("keywords = %v", result.Metadata["keywords"])
+	}
+	if result.Text != "TOTAL 12.50" {
+		t.Errorf("ExtractImage() text = %q", result.Text)
+	}
+
+	// Text-bearing properties are appended to the text on request
+	imageExtractor.IncludeMetadataText = true
+	result, err = imageExtractor.ExtractImage(bytes.NewReader(jpegData), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ExtractImage() error = %v", err)
+	}
+	want := "TOTAL 12.50\n\nTitle: Shop receipt\nDescription: Receipt from the corner shop\nComment: Paid in cash\nKeywords: receipt, expenses, groceries, food"
+	if result.Text != want {
+		t.Errorf("ExtractImage() text = %q, expected %q", result.Text, want)
+	}
+
+	// PNG keeps EXIF in an eXIf chunk and XMP in a compressed iTXt chunk
+	encoded.Reset()
+	if err := png.Encode(&encoded, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	var xmp bytes.Buffer
+	writer := zlib.NewWriter(&xmp)
+	writer.Write([]byte(sampleXMP))
+	writer.Close()
+	itxt := append([]byte("XML:com.adobe.xmp\x00\x01\x00\x00\x00"), xmp.Bytes()...)
+	pngData := withPNGChunk(withPNGChunk(encoded.Bytes(), "iTXt", itxt), "eXIf", sampleEXIF())
+
+	// WebP keeps them in EXIF and XMP chunks
+	webpData, _ := base64.StdEncoding.DecodeString(webpSample)
+	for _, chunk := range []struct{ kind, data string }{{"EXIF", string(sampleEXIF())}, {"XMP ", sampleXMP}} {
+		webpData = append(webpData, chunk.kind...)
+		webpData = binary.LittleEndian.AppendUint32(webpData, uint32(len(chunk.data)))
+		webpData = append(webpData, chunk.data...)
+		if len(chunk.data)%2 == 1 {
+			webpData = append(webpData, 0)
+		}
+	}
+	binary.LittleEndian.PutUint32(webpData[4:], uint32(len(webpData)-8))
+
+	for name, data := range map[string][]byte{"PNG": pngData, "WebP": webpData} {
+		result, err := extractor.NewImageExtractor().ExtractImage(bytes.NewReader(data), extractor.DefaultExtractOptions())
+		if err != nil {
+			t.Fatalf("%s: ExtractImage() error = %v", name, err)
+		}
+		if result.Metadata["image_format"] == nil || result.Metadata["error"] != nil {
+			t.Errorf("%s: image was not decoded: %v", name, result.Metadata)
+		}
+		if result.EXIF == nil || result.EXIF.Make != "Canon" || result.XMP == nil || result.XMP.Title != "Shop receipt" || result.IPTC != nil {
+			t.Errorf("%s: unexpected metadata %+v, %+v, %+v", name, result.EXIF, result.XMP, result.IPTC)
+		}
+	}
+
+	// Images without metadata
+	result, err = extractor.NewImageExtractor().ExtractImageFromFile(filepath.Join("testdata", "sample.png"), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ExtractImageFromFile() error = %v", err)
+	}
+	if result.EXIF != nil || result.Metadata["exif"] != false || result.Metadata["keywords"] != nil {
+		t.Errorf("Unexpected metadata %v", result.Metadata)
+	}
+}
+
 func TestImageExtractorSupportedTypes(t *testing.T) {
 	extractorInstance := extractor.NewImageExtractor()
 
 	supportedTypes := extractorInstance.SupportedTypes()
-	expectedTypes := []string{"png", "jpg", "jpeg", "gif", "bmp", "tiff"}
+	expectedTypes := []string{"png", "jpg", "jpeg", "gif", "bmp", "tiff", "tif", "webp"}
 
 	if len(supportedTypes) != len(expectedTypes) {
 		t.Errorf("Expected %d supported types, got %d", len(expectedTypes), len(supportedTypes))
@@ -0,0 +1,191 @@
+package test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Puhan-Zhou/go-filetext/extractor"
+)
+
+func TestMarkdownExtraction(t *testing.T) {
+	markdownExtractor := extractor.NewMarkdownExtractor()
+	result, err := markdownExtractor.ExtractFromFile("testdata/sample.md", extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("Markdown extraction failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"Go Plaintext Getter Test Document\n\nThis is a sample Markdown file for testing",
+		"Italic text and bold text\nCode snippets and code blocks\nLinks and email links",
+		"func main() {\n    fmt.Println(\"Hello, World!\")\n}",
+		"1. Numbered list item 1\n2. Numbered list item 2",
+		"Bullet point 2\n  Nested bullet point\n  Another nested point",
+	} {
+		if !strings.Contains(result.Text, expected) {
+			t.Errorf("Expected text to contain %q, got %q", expected, result.Text)
+		}
+	}
+	for _, syntax := range []string{"**", "```", "](", "> ", "# "} {
+		if strings.Contains(result.Text, syntax) {
+			t.Errorf("Expected %q to be stripped, got %q", syntax, result.Text)
+		}
+	}
+
+	expectedMetadata := map[string]interface{}{
+		"heading_count":    5,
+		"link_count":       4, // Two links plus the email address and URL linkified by GFM
+		"image_count":      0,
+		"code_block_count": 1,
+		"title":            "Go Plaintext Getter Test Document",
+	}
+	for key, value := range expectedMetadata {
+		if result.Metadata[key] != value {
+			t.Errorf("Metadata[%q] = %v, expected %v", key, result.Metadata[key], value)
+		}
+	}
+	if result.FileType != "markdown" {
+		t.Errorf("Expected file type 'markdown', got '%s'", result.FileType)
+	}
+
+	// Preserving formatting returns the source
+	options := extractor.DefaultExtractOptions()
+	options.PreserveFormatting = true
+	result, err = markdownExtractor.ExtractFromFile("testdata/sample.md", options)
+	if err != nil {
+		t.Fatalf("Markdown extraction failed: %v", err)
+	}
+	if !strings.Contains(result.Text, "This is a **sample Markdown file**") || result.Metadata["heading_count"] != 5 {
+		t.Errorf("Expected the Markdown source with 5 headings, got %v headings", result.Metadata["heading_count"])
+	}
+
+	// The factory routes .md files to the Markdown extractor
+	created, err := extractor.CreateExtractorFromPath("testdata/sample.md")
+	if err != nil {
+		t.Fatalf("CreateExtractorFromPath failed: %v", err)
+	}
+	if _, ok := created.(*extractor.MarkdownExtractor); !ok {
+		t.Errorf("Expected *extractor.MarkdownExtractor, got %T", created)
+	}
+}
+
+func TestMarkdownSyntax(t *testing.T) {
+	source := strings.Join([]string{
+		"---",
+		"title: Release notes",
+		"author: Dana",
+		"date: 2024-03-01",
+		"tags: [release, changelog]",
+		"---",
+		"# Version 2.0 #",
+		"",
+		"Set `max_retries` in config_file.yaml; 2 * 3 * 4 = 24 and a_b_c stays.",
+		"Escaped \\*stars\\* &amp; entities &copy;.",
+		"",
+		"![Architecture diagram](diagram.png) and ~~old~~ **new** _design_.",
+		"",
+		"| Option | Default |",
+		"| ------ | ------- |",
+		"| `retries` | 3 |",
+		"| `timeout` | 30s |",
+		"",
+		"    indented code",
+		"",
+		"- [x] Ship it",
+		"- [ ] Announce it",
+		"",
+		"<div>Raw <b>HTML</b> block</div>",
+	}, "\n")
+
+	result, err := extractor.NewMarkdownExtractor().Extract(strings.NewReader(source), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("Markdown extraction failed: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"Version 2.0",
+		"",
+		"Set max_retries in config_file.yaml; 2 * 3 * 4 = 24 and a_b_c stays.",
+		"Escaped *stars* & entities ©.",
+		"",
+		"Architecture diagram and old new design.",
+		"",
+		"Option\tDefault",
+		"retries\t3",
+		"timeout\t30s",
+		"",
+		"indented code",
+		"",
+		"[x] Ship it",
+		"[ ] Announce it",
+		"",
+		"Raw HTML block",
+	}, "\n")
+	if result.Text != expected {
+		t.Errorf("Expected text:\n%s\ngot:\n%s", expected, result.Text)
+	}
+
+	expectedMetadata := map[string]interface{}{
+		"title":               "Release notes",
+		"author":              "Dana",
+		"date":                "2024-03-01",
+		"front_matter_format": "yaml",
+		"heading_count":       1,
+		"image_count":         1,
+		"table_count":         1,
+		"code_block_count":    1,
+	}
+	for key, value := range expectedMetadata {
+		if result.Metadata[key] != value {
+			t.Errorf("Metadata[%q] = %v, expected %v", key, result.Metadata[key], value)
+		}
+	}
+	if keywords := result.Metadata["keywords"]; !reflect.DeepEqual(keywords, []string{"release", "changelog"}) {
+		t.Errorf("Expected keywords [release changelog], got %v", keywords)
+	}
+
+	// Tables can be rendered as Markdown instead
+	markdownExtractor := extractor.NewMarkdownExtractor()
+	markdownExtractor.TableFormat = extractor.TableFormatMarkdown
+	result, err = markdownExtractor.Extract(strings.NewReader(source), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("Markdown extraction failed: %v", err)
+	}
+	if !strings.Contains(result.Text, "| Option | Default |\n| --- | --- |\n| retries | 3 |") {
+		t.Errorf("Expected a Markdown table, got %q", result.Text)
+	}
+}
+
+func TestMarkdownFrontMatter(t *testing.T) {
+	source := "+++\ntitle = \"TOML page\"\nkeywords = \"go, text\"\n[params]\nweight = 3\n+++\nBody text.\n"
+	result, err := extractor.NewMarkdownExtractor().Extract(strings.NewReader(source), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("Markdown extraction failed: %v", err)
+	}
+	if result.Text != "Body text." {
+		t.Errorf("Expected %q, got %q", "Body text.", result.Text)
+	}
+	if result.Metadata["title"] != "TOML page" || result.Metadata["front_matter_format"] != "toml" {
+		t.Errorf("Expected TOML front matter title, got %v (%v)", result.Metadata["title"], result.Metadata["front_matter_format"])
+	}
+	if keywords := result.Metadata["keywords"]; !reflect.DeepEqual(keywords, []string{"go", "text"}) {
+		t.Errorf("Expected keywords [go text], got %v", keywords)
+	}
+	frontMatter, _ := result.Metadata["front_matter"].(map[string]interface{})
+	if params, _ := frontMatter["params"].(map[string]interface{}); params["weight"] != int64(3) {
+		t.Errorf("Expected nested front matter params, got %v", result.Metadata["front_matter"])
+	}
+
+	// A thematic break at the start is not front matter
+	source = "---\nJust a paragraph between rules\n---\nMore text.\n"
+	result, err = extractor.NewMarkdownExtractor().Extract(strings.NewReader(source), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("Markdown extraction failed: %v", err)
+	}
+	if _, ok := result.Metadata["front_matter"]; ok {
+		t.Errorf("Expected no front matter, got %v", result.Metadata["front_matter"])
+	}
+	if result.Text != "Just a paragraph between rules\n\nMore text." {
+		t.Errorf("Expected the document text, got %q", result.Text)
+	}
+}
@@ -0,0 +1,45 @@
+package test
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/Puhan-Zhou/go-filetext/extractor"
+)
+
+// fakeOCREngine is a deterministic OCR engine. It returns its configured text,
+// split into words laid out left to right, or when no text is configured it
+// describes the size and top-left pixel of the image it is given.
+type fakeOCREngine struct {
+	text string
+
+	// languages records the language of every recognition request
+	languages []string
+}
+
+func (f *fakeOCREngine) Recognize(img image.Image, options extractor.OCROptions) (*extractor.OCRResult, error) {
+	f.languages = append(f.languages, options.Language)
+
+	bounds := img.Bounds()
+	text := f.text
+	if text == "" {
+		gray := color.GrayModel.Convert(img.At(bounds.Min.X, bounds.Min.Y)).(color.Gray)
+		text = fmt.Sprintf("scanned %dx%d starting %d", bounds.Dx(), bounds.Dy(), gray.Y)
+	}
+
+	var words []extractor.OCRWord
+	x := bounds.Min.X
+	for _, word := range strings.Fields(text) {
+		width := 10 * len(word)
+		words = append(words, extractor.OCRWord{
+			Text:       word,
+			Bounds:     image.Rect(x, bounds.Min.Y, x+width, bounds.Min.Y+12),
+			Confidence: 0.9,
+		})
+		x += width + 5
+	}
+
+	return &extractor.OCRResult{Text: text, Words: words, Confidence: 0.9}, nil
+}
@@ -0,0 +1,286 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Puhan-Zhou/go-filetext/extractor"
+)
+
+const odfNamespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" ` +
+	`xmlns:presentation="urn:oasis:names:tc:opendocument:xmlns:presentation:1.0" ` +
+	`xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" ` +
+	`xmlns:dc="http://purl.org/dc/elements/1.1/"`
+
+const odfMeta = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta ` + odfNamespaces + `><office:meta>
+<meta:generator>LibreOffice/7.6</meta:generator>
+<dc:title>Permit application</dc:title>
+<dc:subject>Zoning</dc:subject>
+<meta:initial-creator>Alex Moreau</meta:initial-creator>
+<dc:creator>Robin Lee</dc:creator>
+<meta:creation-date>2024-02-01T09:30:00</meta:creation-date>
+<dc:date>2024-02-03T16:00:00</dc:date>
+<dc:language>fr-FR</dc:language>
+<meta:keyword>permit</meta:keyword>
+<meta:keyword>zoning</meta:keyword>
+</office:meta></office:document-meta>`
+
+// buildODF packages an OpenDocument file with the mimetype entry stored first
+func buildODF(t *testing.T, mimeType, body string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	parts := []struct {
+		name    string
+		content string
+		method  uint16
+	}{
+		{"mimetype", mimeType, zip.Store},
+		{"content.xml", `<?xml version="1.0" encoding="UTF-8"?><office:document-content ` + odfNamespaces + `><office:body>` + body + `</office:body></office:document-content>`, zip.Deflate},
+		{"meta.xml", odfMeta, zip.Deflate},
+	}
+	for _, part := range parts {
+		w, err := writer.CreateHeader(&zip.FileHeader{Name: part.name, Method: part.method})
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", part.name, err)
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			t.Fatalf("Failed to write %s: %v", part.name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close package: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// writeODF writes an OpenDocument file to a temporary directory
+func writeODF(t *testing.T, name string, content []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestODTExtraction(t *testing.T) {
+	body := `<office:text>
+<text:sequence-decls><text:sequence-decl text:name="Table"/></text:sequence-decls>
+<text:h text:outline-level="1">Permit application</text:h>
+<text:p>Submitted by<text:s text:c="2"/>Alex<text:tab/>Moreau<text:line-break/>Lyon office.</text:p>
+<text:p>The site is zoned residential<text:note text:id="ftn1" text:note-class="footnote"><text:note-citation>1</text:note-citation><text:note-body><text:p>See the 2023 zoning map.</text:p></text:note-body></text:note> and
+   needs a variance.<office:annotation><dc:creator>Robin</dc:creator><text:p>Check this</text:p></office:annotation></text:p>
+<text:list><text:list-item><text:p>Site plan</text:p></text:list-item><text:list-item><text:p>Elevations</text:p><text:list><text:list-item><text:p>North</text:p></text:list-item></text:list></text:list-item></text:list>
+<table:table table:name="Fees"><table:table-column table:number-columns-repeated="3"/>
+<table:table-header-rows><table:table-row><table:table-cell><text:p>Item</text:p></table:table-cell><table:table-cell><text:p>Amount</text:p></table:table-cell><table:table-cell table:number-columns-repeated="1020"/></table:table-row></table:table-header-rows>
+<table:table-row><table:table-cell><text:p>Filing</text:p></table:table-cell><table:table-cell office:value-type="float" office:value="120"><text:p>120.00</text:p></table:table-cell></table:table-row>
+<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="3"/></table:table-row>
+</table:table>
+<text:tracked-changes><text:changed-region><text:deletion><text:p>Deleted text</text:p></text:deletion></text:changed-region></text:tracked-changes>
+</office:text>`
+
+	content := buildODF(t, "application/vnd.oasis.opendocument.text", body)
+	result, err := extractor.NewODTExtractor().Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ODT extraction failed: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"Permit application",
+		"Submitted by  Alex\tMoreau\nLyon office.",
+		"The site is zoned residential[1] and needs a variance.",
+		"Site plan",
+		"Elevations",
+		"North",
+		"Item\tAmount",
+		"Filing\t120.00",
+		"",
+		"[1] See the 2023 zoning map.",
+	}, "\n")
+	if result.Text != expected {
+		t.Errorf("Expected text:\n%q\ngot:\n%q", expected, result.Text)
+	}
+
+	expectedMetadata := map[string]interface{}{
+		"title":            "Permit application",
+		"subject":          "Zoning",
+		"author":           "Alex Moreau",
+		"last_modified_by": "Robin Lee",
+		"created":          "2024-02-01T09:30:00",
+		"modified":         "2024-02-03T16:00:00",
+		"language":         "fr-FR",
+		"generator":        "LibreOffice/7.6",
+		"headings":         "1",
+		"table_count":      "1",
+		"notes":            "1",
+		"comments":         "1",
+	}
+	for key, value := range expectedMetadata {
+		if result.Metadata[key] != value {
+			t.Errorf("Metadata[%q] = %v, expected %v", key, result.Metadata[key], value)
+		}
+	}
+	if keywords := result.Metadata["keywords"]; !reflect.DeepEqual(keywords, []string{"permit", "zoning"}) {
+		t.Errorf("Expected keywords [permit zoning], got %v", keywords)
+	}
+	if result.FileType != "odt" {
+		t.Errorf("Expected file type 'odt', got '%s'", result.FileType)
+	}
+
+	// The factory routes ODT files to the ODT extractor
+	created, err := extractor.CreateExtractorFromPath(writeODF(t, "application.odt", content))
+	if err != nil {
+		t.Fatalf("CreateExtractorFromPath failed: %v", err)
+	}
+	if _, ok := created.(*extractor.ODTExtractor); !ok {
+		t.Errorf("Expected *extractor.ODTExtractor, got %T", created)
+	}
+
+	// Files that are not ZIP packages are rejected
+	if _, err := extractor.NewODTExtractor().Extract(strings.NewReader("not a package"), extractor.DefaultExtractOptions()); err == nil {
+		t.Error("Expected an error for content that is not an OpenDocument package")
+	}
+}
+
+func TestODSExtraction(t *testing.T) {
+	body := `<office:spreadsheet>
+<table:table table:name="Budget">
+<table:table-column table:number-columns-repeated="16384"/>
+<table:table-row><table:table-cell office:value-type="string"><text:p>Item</text:p></table:table-cell><table:table-cell office:value-type="string"><text:p>Cost</text:p></table:table-cell><table:table-cell table:number-columns-repeated="16382"/></table:table-row>
+<table:table-row><table:table-cell office:value-type="string"><text:p>Paper</text:p></table:table-cell><table:table-cell office:value-type="float" office:value="12.5"/></table:table-row>
+<table:table-row table:number-rows-repeated="2"><table:table-cell office:value-type="string"><text:p>Ink</text:p></table:table-cell><table:table-cell office:value-type="float" office:value="30"><text:p>30</text:p></table:table-cell></table:table-row>
+<table:table-row table:number-rows-repeated="1048571"><table:table-cell table:number-columns-repeated="16384"/></table:table-row>
+</table:table>
+<table:table table:name="Notes">
+<table:table-row><table:table-cell/><table:table-cell office:value-type="string"><text:p>Approved</text:p></table:table-cell></table:table-row>
+</table:table>
+</office:spreadsheet>`
+
+	content := buildODF(t, "application/vnd.oasis.opendocument.spreadsheet", body)
+	odsExtractor := extractor.NewODSExtractor()
+	result, err := odsExtractor.Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ODS extraction failed: %v", err)
+	}
+
+	// As with XLSX, cell text is concatenated without separators
+	if expected := "ItemCostPaper12.5Ink30Ink30Approved"; result.Text != expected {
+		t.Errorf("Expected %q, got %q", expected, result.Text)
+	}
+	expectedMetadata := map[string]interface{}{
+		"sheets": "2",
+		"rows":   "5",
+		"cells":  "10",
+		"title":  "Permit application",
+	}
+	for key, value := range expectedMetadata {
+		if result.Metadata[key] != value {
+			t.Errorf("Metadata[%q] = %v, expected %v", key, result.Metadata[key], value)
+		}
+	}
+
+	tables, err := odsExtractor.ExtractTables(bytes.NewReader(content), extractor.DefaultExtractOptions(), extractor.TableOptions{})
+	if err != nil {
+		t.Fatalf("ODS table extraction failed: %v", err)
+	}
+	if len(tables) != 2 || tables[0].Name != "Budget" || tables[1].Name != "Notes" {
+		t.Fatalf("Expected the Budget and Notes tables, got %d tables", len(tables))
+	}
+	if !reflect.DeepEqual(tables[0].Columns, []string{"Item", "Cost"}) {
+		t.Errorf("Expected columns [Item Cost], got %v", tables[0].Columns)
+	}
+	if !reflect.DeepEqual(tables[0].Rows, [][]string{{"Paper", "12.5"}, {"Ink", "30"}, {"Ink", "30"}}) {
+		t.Errorf("Unexpected rows: %v", tables[0].Rows)
+	}
+
+	created, err := extractor.CreateExtractorFromPath(writeODF(t, "budget.ods", content))
+	if err != nil {
+		t.Fatalf("CreateExtractorFromPath failed: %v", err)
+	}
+	if _, ok := created.(*extractor.ODSExtractor); !ok {
+		t.Errorf("Expected *extractor.ODSExtractor, got %T", created)
+	}
+}
+
+func TestODPExtraction(t *testing.T) {
+	body := `<office:presentation>
+<draw:page draw:name="Overview">
+<draw:frame presentation:class="title"><draw:text-box><text:p>Quarterly review</text:p></draw:text-box></draw:frame>
+<draw:frame presentation:class="outline"><draw:text-box><text:list><text:list-item><text:p>Revenue up</text:p></text:list-item><text:list-item><text:p>Costs flat</text:p></text:list-item></text:list></draw:text-box></draw:frame>
+<draw:frame presentation:class="subtitle" presentation:placeholder="true"><draw:text-box/></draw:frame>
+<presentation:notes><draw:page-thumbnail/><draw:frame presentation:class="notes"><draw:text-box><text:p>Mention the new office.</text:p></draw:text-box></draw:frame></presentation:notes>
+</draw:page>
+<draw:page draw:name="Next steps">
+<draw:custom-shape><text:p>Hire two engineers</text:p></draw:custom-shape>
+</draw:page>
+</office:presentation>`
+
+	content := buildODF(t, "application/vnd.oasis.opendocument.presentation", body)
+	result, err := extractor.NewODPExtractor().Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ODP extraction failed: %v", err)
+	}
+
+	expected := "Quarterly review\nRevenue up\nCosts flat\n\nMention the new office.\n\nHire two engineers"
+	if result.Text != expected {
+		t.Errorf("Expected %q, got %q", expected, result.Text)
+	}
+	if len(result.Pages) != 2 {
+		t.Fatalf("Expected 2 pages, got %d", len(result.Pages))
+	}
+	if page := result.Pages[0]; page.Label != "Overview" || page.Metadata["notes"] != "Mention the new office." {
+		t.Errorf("Unexpected first page: label %q, notes %v", page.Label, page.Metadata["notes"])
+	}
+	if page := result.Pages[1]; page.Number != 2 || page.Label != "Next steps" || !strings.HasPrefix(result.Text[page.Offset:], page.Text) {
+		t.Errorf("Unexpected second page: %+v", page)
+	}
+	if result.Metadata["slides"] != "2" || result.Metadata["author"] != "Alex Moreau" {
+		t.Errorf("Unexpected metadata: %v", result.Metadata)
+	}
+
+	// A page range selects slides
+	options := extractor.DefaultExtractOptions()
+	options.FirstPage = 2
+	result, err = extractor.NewODPExtractor().Extract(bytes.NewReader(content), options)
+	if err != nil {
+		t.Fatalf("ODP extraction failed: %v", err)
+	}
+	if result.Text != "Hire two engineers" || result.Metadata["pages_extracted"] != 1 || result.Metadata["page_count"] != 2 {
+		t.Errorf("Expected the second slide only, got %q (%v)", result.Text, result.Metadata["pages_extracted"])
+	}
+
+	options.FirstPage = 3
+	if _, err := extractor.NewODPExtractor().Extract(bytes.NewReader(content), options); err == nil {
+		t.Error("Expected an error for a page range beyond the last slide")
+	}
+
+	// A presentation without slides has no pages
+	empty := buildODF(t, "application/vnd.oasis.opendocument.presentation", "<office:presentation/>")
+	result, err = extractor.NewODPExtractor().Extract(bytes.NewReader(empty), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ODP extraction without slides failed: %v", err)
+	}
+	if result.Text != "" || len(result.Pages) != 0 || result.Metadata["slides"] != "0" {
+		t.Errorf("Expected no slides, got %q (%v)", result.Text, result.Metadata["slides"])
+	}
+
+	created, err := extractor.CreateExtractorFromPath(writeODF(t, "review.odp", content))
+	if err != nil {
+		t.Fatalf("CreateExtractorFromPath failed: %v", err)
+	}
+	if _, ok := created.(*extractor.ODPExtractor); !ok {
+		t.Errorf("Expected *extractor.ODPExtractor, got %T", created)
+	}
+}
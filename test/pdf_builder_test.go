@@ -0,0 +1,410 @@
+package test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"strings"
+)
+
+// testPDF builds small uncompressed PDF documents for extractor tests
+type testPDF struct {
+	objects []testObject
+
+	// encryption, when set, encrypts every stream with the standard security handler
+	encryption *testEncryption
+}
+
+// testObject is an indirect object; stream objects keep their data separately
+// so it can be encrypted when the document is serialized
+type testObject struct {
+	body   string
+	stream []byte
+
+	// text, when set, makes the object a string, encrypted like stream data
+	text []byte
+}
+
+// testEncryption describes standard security handler parameters
+type testEncryption struct {
+	userPassword  string
+	ownerPassword string
+	revision      int // 2 and 3 use RC4, 4 uses AES-128, 5 and 6 use AES-256
+}
+
+// add appends an object body and returns its object number
+func (p *testPDF) add(body string) int {
+	p.objects = append(p.objects, testObject{body: body})
+	return len(p.objects)
+}
+
+// reserve allocates an object number whose body is set later
+func (p *testPDF) reserve() int {
+	return p.add("null")
+}
+
+// set replaces the body of a previously reserved object
+func (p *testPDF) set(id int, body string) {
+	p.objects[id-1] = testObject{body: body}
+}
+
+// addStream appends a stream object with the given extra dictionary entries
+func (p *testPDF) addStream(dict string, data string) int {
+	p.objects = append(p.objects, testObject{body: dict, stream: []byte(data)})
+	return len(p.objects)
+}
+
+// addString appends a string object and returns its object number
+func (p *testPDF) addString(text string) int {
+	p.objects = append(p.objects, testObject{text: []byte(text)})
+	return len(p.objects)
+}
+
+// bytes serializes the document with a cross-reference table and trailer
+func (p *testPDF) bytes(root int, trailerExtra string) []byte {
+	var key []byte
+	if p.encryption != nil {
+		var dict string
+		key, dict = p.encryption.setup()
+		trailerExtra += fmt.Sprintf(" /Encrypt %s /ID [<%x> <%x>]", dict, testDocumentID, testDocumentID)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+
+	offsets := make([]int, len(p.objects))
+	for i, object := range p.objects {
+		offsets[i] = buf.Len()
+		if object.text != nil {
+			text := object.text
+			if key != nil {
+				text = p.encryption.encrypt(key, i+1, text)
+			}
+			fmt.Fprintf(&buf, "%d 0 obj\n<%x>\nendobj\n", i+1, text)
+			continue
+		}
+		if object.stream == nil {
+			fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object.body)
+			continue
+		}
+
+		data := object.stream
+		if key != nil {
+			data = p.encryption.encrypt(key, i+1, data)
+		}
+		fmt.Fprintf(&buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", i+1, object.body, len(data))
+		buf.Write(data)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(p.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R %s >>\nstartxref\n%d\n%%%%EOF\n",
+		len(p.objects)+1, root, trailerExtra, xref)
+
+	return buf.Bytes()
+}
+
+// testDocumentID is the first element of the trailer /ID array
+var testDocumentID = []byte("go-filetext-test")
+
+// testPasswordPad is the padding string from PDF 32000-1:2008, §7.6.3.3
+var testPasswordPad = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// testPermissions grants all permissions
+const testPermissions = -4
+
+// padPassword pads or truncates a password to 32 bytes
+func padPassword(password string) []byte {
+	padded := append([]byte(password), testPasswordPad...)
+	return padded[:32]
+}
+
+// setup computes the file key and the /Encrypt dictionary (algorithms 2, 3, 4 and 5)
+func (e *testEncryption) setup() ([]byte, string) {
+	if e.revision >= 5 {
+		return e.setupAES256()
+	}
+
+	keyLength := 16
+	if e.revision == 2 {
+		keyLength = 5
+	}
+
+	owner := e.ownerPassword
+	if owner == "" {
+		owner = e.userPassword
+	}
+
+	// Algorithm 3: the O entry
+	ownerHash := md5.Sum(padPassword(owner))
+	ownerKey := ownerHash[:]
+	if e.revision >= 3 {
+		for i := 0; i < 50; i++ {
+			next := md5.Sum(ownerKey)
+			ownerKey = next[:]
+		}
+	}
+	ownerKey = ownerKey[:keyLength]
+	o := padPassword(e.userPassword)
+	rc4XOR(ownerKey, o)
+	if e.revision >= 3 {
+		for i := 1; i <= 19; i++ {
+			rc4XOR(xorKey(ownerKey, byte(i)), o)
+		}
+	}
+
+	// Algorithm 2: the file key
+	var signedPermissions int32 = testPermissions
+	permissions := uint32(signedPermissions)
+	h := md5.New()
+	h.Write(padPassword(e.userPassword))
+	h.Write(o)
+	h.Write([]byte{byte(permissions), byte(permissions >> 8), byte(permissions >> 16), byte(permissions >> 24)})
+	h.Write(testDocumentID)
+	key := h.Sum(nil)
+	if e.revision >= 3 {
+		for i := 0; i < 50; i++ {
+			next := md5.Sum(key[:keyLength])
+			key = next[:]
+		}
+	}
+	key = key[:keyLength]
+
+	// Algorithms 4 and 5: the U entry
+	var u []byte
+	if e.revision == 2 {
+		u = append([]byte(nil), testPasswordPad...)
+		rc4XOR(key, u)
+	} else {
+		hash := md5.Sum(append(append([]byte(nil), testPasswordPad...), testDocumentID...))
+		u = hash[:]
+		rc4XOR(key, u)
+		for i := 1; i <= 19; i++ {
+			rc4XOR(xorKey(key, byte(i)), u)
+		}
+		u = append(u, make([]byte, 16)...)
+	}
+
+	var dict string
+	switch e.revision {
+	case 4:
+		dict = fmt.Sprintf("<< /Filter /Standard /V 4 /R 4 /Length 128 /CF << /StdCF << /CFM /%s /AuthEvent /DocOpen /Length 16 >> >> /StmF /StdCF /StrF /StdCF /O <%x> /U <%x> /P %d >>",
+			e.method(), o, u, testPermissions)
+	default:
+		dict = fmt.Sprintf("<< /Filter /Standard /V %d /R %d /Length %d /O <%x> /U <%x> /P %d >>",
+			e.revision-1, e.revision, keyLength*8, o, u, testPermissions)
+	}
+
+	return key, dict
+}
+
+// setupAES256 computes the /Encrypt dictionary of revisions 5 and 6 for a
+// fixed file key (ISO 32000-2, algorithms 8 and 9)
+func (e *testEncryption) setupAES256() ([]byte, string) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	owner := e.ownerPassword
+	if owner == "" {
+		owner = e.userPassword
+	}
+
+	u := append(e.hash([]byte(e.userPassword), []byte("uvalsalt"), nil), "uvalsaltukeysalt"...)
+	ue := aes256Wrap(e.hash([]byte(e.userPassword), []byte("ukeysalt"), nil), key)
+	o := append(e.hash([]byte(owner), []byte("ovalsalt"), u), "ovalsaltokeysalt"...)
+	oe := aes256Wrap(e.hash([]byte(owner), []byte("okeysalt"), u), key)
+
+	dict := fmt.Sprintf("<< /Filter /Standard /V 5 /R %d /Length 256 /CF << /StdCF << /CFM /AESV3 /AuthEvent /DocOpen /Length 32 >> >> /StmF /StdCF /StrF /StdCF /O <%x> /U <%x> /OE <%x> /UE <%x> /P %d >>",
+		e.revision, o, u, oe, ue, testPermissions)
+	return key, dict
+}
+
+// hash is the password hash of revision 5, or the iterated hash of revision 6
+func (e *testEncryption) hash(password, salt, userData []byte) []byte {
+	k := sha256.Sum256(append(append(append([]byte(nil), password...), salt...), userData...))
+	if e.revision == 5 {
+		return k[:]
+	}
+
+	hash := k[:]
+	for round := 0; ; round++ {
+		k1 := bytes.Repeat(append(append(append([]byte(nil), password...), hash...), userData...), 64)
+		block, _ := aes.NewCipher(hash[:16])
+		encrypted := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, hash[16:32]).CryptBlocks(encrypted, k1)
+
+		sum := 0
+		for _, b := range encrypted[:16] {
+			sum += int(b)
+		}
+		switch sum % 3 {
+		case 0:
+			next := sha256.Sum256(encrypted)
+			hash = next[:]
+		case 1:
+			next := sha512.Sum384(encrypted)
+			hash = next[:]
+		default:
+			next := sha512.Sum512(encrypted)
+			hash = next[:]
+		}
+		if round >= 63 && int(encrypted[len(encrypted)-1]) <= round-31 {
+			return hash[:32]
+		}
+	}
+}
+
+// aes256Wrap encrypts the file key for the UE and OE entries
+func aes256Wrap(intermediate, key []byte) []byte {
+	block, _ := aes.NewCipher(intermediate)
+	out := make([]byte, len(key))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, key)
+	return out
+}
+
+// method returns the crypt filter method for streams
+func (e *testEncryption) method() string {
+	switch {
+	case e.revision >= 5:
+		return "AESV3"
+	case e.revision == 4:
+		return "AESV2"
+	}
+	return "V2"
+}
+
+// encrypt encrypts stream data with the per-object key (algorithm 1); AES-256
+// uses the file key itself
+func (e *testEncryption) encrypt(key []byte, id int, data []byte) []byte {
+	method := e.method()
+	objectKey := key
+	if method != "AESV3" {
+		h := md5.New()
+		h.Write(key)
+		h.Write([]byte{byte(id), byte(id >> 8), byte(id >> 16), 0, 0})
+		if method == "AESV2" {
+			h.Write([]byte("sAlT"))
+		}
+		objectKey = h.Sum(nil)
+		if n := len(key) + 5; n < len(objectKey) {
+			objectKey = objectKey[:n]
+		}
+	}
+
+	if method == "V2" {
+		out := append([]byte(nil), data...)
+		rc4XOR(objectKey, out)
+		return out
+	}
+
+	block, _ := aes.NewCipher(objectKey)
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	plain := append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	out := make([]byte, aes.BlockSize+len(plain))
+	copy(out, "0123456789abcdef") // fixed IV keeps test documents deterministic
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], plain)
+	return out
+}
+
+// rc4XOR encrypts or decrypts data in place
+func rc4XOR(key []byte, data []byte) {
+	c, _ := rc4.NewCipher(key)
+	c.XORKeyStream(data, data)
+}
+
+// xorKey returns a copy of key with every byte XORed with b
+func xorKey(key []byte, b byte) []byte {
+	out := make([]byte, len(key))
+	for i := range key {
+		out[i] = key[i] ^ b
+	}
+	return out
+}
+
+// courierFont returns a font dictionary for Courier with explicit widths, so
+// glyph positions are known exactly: every glyph advances 0.6 em
+func courierFont() string {
+	widths := strings.TrimSpace(strings.Repeat("600 ", 95))
+	return fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding /FirstChar 32 /LastChar 126 /Widths [%s] >>", widths)
+}
+
+// buildTestPDF creates a document with one page per content stream, all
+// sharing a Courier font resource named F1
+func buildTestPDF(contents ...string) []byte {
+	p := &testPDF{}
+	catalog := p.reserve()
+	pages := p.reserve()
+	font := p.add(courierFont())
+
+	var kids []string
+	for _, content := range contents {
+		stream := p.addStream("", content)
+		page := p.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pages, font, stream))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+
+	p.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	p.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	return p.bytes(catalog, "")
+}
+
+// buildEncryptedTestPDF creates a single-page document with the title
+// "Secret title", protected with the given security handler parameters
+func buildEncryptedTestPDF(content string, encryption *testEncryption) []byte {
+	p := &testPDF{encryption: encryption}
+	catalog := p.reserve()
+	pages := p.reserve()
+	font := p.add(courierFont())
+	stream := p.addStream("", content)
+	page := p.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pages, font, stream))
+
+	info := p.add(fmt.Sprintf("<< /Title %d 0 R >>", p.addString("Secret title")))
+
+	p.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	p.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", page))
+
+	return p.bytes(catalog, fmt.Sprintf("/Info %d 0 R", info))
+}
+
+// textAt returns a content stream fragment drawing s at the given position
+func textAt(x, y float64, s string) string {
+	return fmt.Sprintf("BT /F1 10 Tf %g %g Td (%s) Tj ET\n", x, y, s)
+}
+
+// buildScannedTestPDF creates a single-page document that draws one image
+// XObject named Im1 across the page, followed by the given content
+func buildScannedTestPDF(imageDict string, imageData []byte, content string) []byte {
+	return buildEncryptedScannedTestPDF(imageDict, imageData, content, nil)
+}
+
+// buildEncryptedScannedTestPDF creates the document of buildScannedTestPDF,
+// encrypted when encryption is set
+func buildEncryptedScannedTestPDF(imageDict string, imageData []byte, content string, encryption *testEncryption) []byte {
+	p := &testPDF{encryption: encryption}
+	catalog := p.reserve()
+	pages := p.reserve()
+	font := p.add(courierFont())
+	image := p.addStream("/Type /XObject /Subtype /Image "+imageDict, string(imageData))
+	stream := p.addStream("", "q 612 0 0 792 0 0 cm /Im1 Do Q\n"+content)
+	page := p.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 %d 0 R >> /XObject << /Im1 %d 0 R >> >> /Contents %d 0 R >>",
+		pages, font, image, stream))
+
+	p.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	p.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", page))
+
+	return p.bytes(catalog, "")
+}
@@ -1,7 +1,16 @@
 package test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Puhan-Zhou/go-filetext/extractor"
 )
@@ -43,3 +52,817 @@ func TestPDFExtractionWithOptions(t *testing.T) {
 		t.Error("Expected error due to file size limit")
 	}
 }
+
+func TestPDFLayoutReconstruction(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "positioned words get spaces",
+			content:  textAt(72, 700, "Hello") + textAt(106, 700, "World"),
+			expected: "Hello World",
+		},
+		{
+			name:     "adjacent fragments stay joined",
+			content:  textAt(72, 700, "Wor") + textAt(90, 700, "ld"),
+			expected: "World",
+		},
+		{
+			name:     "lines follow reading order",
+			content:  textAt(72, 688, "second") + textAt(72, 700, "first"),
+			expected: "first\nsecond",
+		},
+		{
+			name:     "superscripts stay on their line",
+			content:  textAt(72, 700, "E=mc") + textAt(96, 703, "2"),
+			expected: "E=mc2",
+		},
+		{
+			name: "paragraph gaps become blank lines",
+			content: textAt(72, 700, "one") + textAt(72, 688, "two") + textAt(72, 676, "three") +
+				textAt(72, 640, "four") + textAt(72, 628, "five"),
+			expected: "one\ntwo\nthree\n\nfour\nfive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdfExtractor := &extractor.PDFExtractor{}
+			result, err := pdfExtractor.Extract(bytes.NewReader(buildTestPDF(tt.content)), extractor.DefaultExtractOptions())
+			if err != nil {
+				t.Fatalf("PDF extraction failed: %v", err)
+			}
+
+			if result.Text != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result.Text)
+			}
+		})
+	}
+}
+
+func TestPDFColumnDetection(t *testing.T) {
+	content := textAt(200, 740, "A Two Column Title")
+	left := []string{"left one", "left two", "left three", "left four"}
+	right := []string{"right one", "right two", "right three", "right four"}
+	for i := range left {
+		y := float64(700 - 12*i)
+		content += textAt(72, y, left[i]) + textAt(320, y, right[i])
+	}
+	document := buildTestPDF(content)
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	result, err := pdfExtractor.Extract(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if !strings.Contains(result.Text, "left one right one\nleft two right two") {
+		t.Errorf("Expected interleaved lines without column detection, got %q", result.Text)
+	}
+
+	pdfExtractor = &extractor.PDFExtractor{DetectColumns: true}
+	result, err = pdfExtractor.Extract(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+
+	expected := "A Two Column Title\n\n" + strings.Join(left, "\n") + "\n\n" + strings.Join(right, "\n")
+	if result.Text != expected {
+		t.Errorf("Expected %q, got %q", expected, result.Text)
+	}
+}
+
+func TestPDFPerPageResults(t *testing.T) {
+	document := buildTestPDF(
+		textAt(72, 700, "first page"),
+		"BT /F1 10 Tf 72 700 Td 1 2 Tj ET", // malformed Tj operator
+		textAt(72, 700, "third page"),
+	)
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	result, err := pdfExtractor.Extract(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+
+	if len(result.Pages) != 3 {
+		t.Fatalf("Expected 3 pages, got %d", len(result.Pages))
+	}
+
+	if result.Pages[1].Err == nil {
+		t.Error("Expected page 2 to report an error")
+	}
+	if result.Pages[0].Err != nil || result.Pages[2].Err != nil {
+		t.Errorf("Unexpected page errors: %v, %v", result.Pages[0].Err, result.Pages[2].Err)
+	}
+
+	failedPages, ok := result.Metadata["failed_pages"].([]int)
+	if !ok || len(failedPages) != 1 || failedPages[0] != 2 {
+		t.Errorf("Expected failed_pages [2], got %v", result.Metadata["failed_pages"])
+	}
+
+	offset := strings.Index(result.Text, "third")
+	page, ok := result.PageAt(len([]rune(result.Text[:offset])))
+	if !ok || page.Number != 3 {
+		t.Errorf("Expected offset of 'third' to map to page 3, got %+v", page)
+	}
+
+	if _, ok := result.PageAt(len([]rune(result.Text))); ok {
+		t.Error("Expected offset past the end of the text to map to no page")
+	}
+}
+
+func TestPDFPageRange(t *testing.T) {
+	document := buildTestPDF(
+		textAt(72, 700, "page one"),
+		textAt(72, 700, "page two"),
+		textAt(72, 700, "page three"),
+	)
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	options := extractor.DefaultExtractOptions()
+	options.FirstPage = 2
+	options.LastPage = 3
+
+	result, err := pdfExtractor.Extract(bytes.NewReader(document), options)
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+
+	if result.Text != "page two\n\npage three" {
+		t.Errorf("Unexpected text for page range: %q", result.Text)
+	}
+	if len(result.Pages) != 2 || result.Pages[0].Number != 2 {
+		t.Errorf("Expected pages 2-3, got %+v", result.Pages)
+	}
+	if result.Metadata["page_count"] != 3 {
+		t.Errorf("Expected page_count to report all pages, got %v", result.Metadata["page_count"])
+	}
+
+	options.FirstPage = 4
+	options.LastPage = 0
+	if _, err := pdfExtractor.Extract(bytes.NewReader(document), options); err == nil {
+		t.Error("Expected error for page range beyond the document")
+	}
+
+	// A document without pages has empty text rather than an invalid range
+	result, err = pdfExtractor.Extract(bytes.NewReader(buildTestPDF()), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction without pages failed: %v", err)
+	}
+	if result.Text != "" || len(result.Pages) != 0 || result.Metadata["page_count"] != 0 {
+		t.Errorf("Expected no pages, got %q (%v)", result.Text, result.Metadata["page_count"])
+	}
+}
+
+func TestPDFEncrypted(t *testing.T) {
+	// RC4 and AES-128 documents open with the user password only
+	tests := []struct {
+		name       string
+		revision   int
+		encryption string
+		owner      bool
+	}{
+		{name: "RC4 128-bit", revision: 3, encryption: "RC4-128"},
+		{name: "AES 128-bit", revision: 4, encryption: "AES-128"},
+		{name: "AES 256-bit revision 5", revision: 5, encryption: "AES-256", owner: true},
+		{name: "AES 256-bit revision 6", revision: 6, encryption: "AES-256", owner: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := buildEncryptedTestPDF(textAt(72, 700, "secret text"), &testEncryption{
+				userPassword:  "user",
+				ownerPassword: "owner",
+				revision:      tt.revision,
+			})
+			pdfExtractor := &extractor.PDFExtractor{}
+
+			options := extractor.DefaultExtractOptions()
+			_, err := pdfExtractor.Extract(bytes.NewReader(document), options)
+			if !errors.Is(err, extractor.ErrPasswordRequired) {
+				t.Errorf("Expected ErrPasswordRequired, got %v", err)
+			}
+
+			options.Password = "wrong"
+			_, err = pdfExtractor.Extract(bytes.NewReader(document), options)
+			if !errors.Is(err, extractor.ErrWrongPassword) {
+				t.Errorf("Expected ErrWrongPassword, got %v", err)
+			}
+
+			options.Password = "user"
+			result, err := pdfExtractor.Extract(bytes.NewReader(document), options)
+			if err != nil {
+				t.Fatalf("PDF extraction failed: %v", err)
+			}
+			if result.Text != "secret text" {
+				t.Errorf("Expected decrypted text, got %q", result.Text)
+			}
+			if result.Metadata["title"] != "Secret title" {
+				t.Errorf("Expected decrypted title, got %v", result.Metadata["title"])
+			}
+			if result.Metadata["encrypted"] != true || result.Metadata["encryption"] != tt.encryption {
+				t.Errorf("Expected %s encryption metadata, got %v", tt.encryption, result.Metadata)
+			}
+			if !tt.owner {
+				return
+			}
+
+			options.Password = "owner"
+			result, err = pdfExtractor.Extract(bytes.NewReader(document), options)
+			if err != nil {
+				t.Fatalf("PDF extraction with the owner password failed: %v", err)
+			}
+			if result.Text != "secret text" {
+				t.Errorf("Expected decrypted text with the owner password, got %q", result.Text)
+			}
+		})
+	}
+}
+
+func TestPDFEncryptedUnreadableXref(t *testing.T) {
+	// The PDF library ignores /XRefStm, so it still opens the document when
+	// the cross-reference stream it points to cannot be read
+	document := buildEncryptedTestPDF(textAt(72, 700, "secret text"), &testEncryption{userPassword: "user", revision: 3})
+	document = bytes.Replace(document, []byte("trailer\n<<"), []byte("trailer\n<< /XRefStm 5"), 1)
+	pdfExtractor := &extractor.PDFExtractor{}
+
+	options := extractor.DefaultExtractOptions()
+	if _, err := pdfExtractor.Extract(bytes.NewReader(document), options); !errors.Is(err, extractor.ErrPasswordRequired) {
+		t.Errorf("Expected ErrPasswordRequired, got %v", err)
+	}
+
+	options.Password = "user"
+	result, err := pdfExtractor.Extract(bytes.NewReader(document), options)
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if result.Text != "secret text" || result.Metadata["encryption"] != "RC4-128" {
+		t.Errorf("Expected decrypted RC4-128 text, got %q and %v", result.Text, result.Metadata["encryption"])
+	}
+}
+
+func TestPDFEncryptedFixtures(t *testing.T) {
+	pdfExtractor := &extractor.PDFExtractor{}
+	plain, err := pdfExtractor.ExtractFromFile("testdata/sample.pdf", extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+
+	// Encrypted copies of sample.pdf written by pdfcpu with the user password
+	// "user" and the owner password "owner". The revision 6 copy was encrypted
+	// as PDF 2.0, which pdfcpu requires for it, and its header set back to 1.7
+	// for the PDF library.
+	tests := []struct {
+		file       string
+		encryption string
+		passwords  []string
+	}{
+		{file: "testdata/sample-aes128.pdf", encryption: "AES-128", passwords: []string{"user"}},
+		{file: "testdata/sample-aes256-r5.pdf", encryption: "AES-256", passwords: []string{"user", "owner"}},
+		{file: "testdata/sample-aes256-r6.pdf", encryption: "AES-256", passwords: []string{"user", "owner"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			options := extractor.DefaultExtractOptions()
+			if _, err := pdfExtractor.ExtractFromFile(tt.file, options); !errors.Is(err, extractor.ErrPasswordRequired) {
+				t.Errorf("Expected ErrPasswordRequired, got %v", err)
+			}
+
+			for _, password := range tt.passwords {
+				options.Password = password
+				result, err := pdfExtractor.ExtractFromFile(tt.file, options)
+				if err != nil {
+					t.Fatalf("PDF extraction with password %q failed: %v", password, err)
+				}
+				if result.Text != plain.Text {
+					t.Errorf("Expected the text of sample.pdf with password %q, got %q", password, result.Text)
+				}
+				if result.Metadata["encryption"] != tt.encryption {
+					t.Errorf("Expected %s encryption metadata, got %v", tt.encryption, result.Metadata["encryption"])
+				}
+			}
+		})
+	}
+}
+
+func TestPDFUnsupportedEncryption(t *testing.T) {
+	p := &testPDF{}
+	catalog := p.reserve()
+	pages := p.add("<< /Type /Pages /Kids [] /Count 0 >>")
+	p.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	for _, encrypt := range []string{
+		// Public-key security handlers need a certificate rather than a password
+		"/Encrypt << /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s5 /V 4 >>",
+		"/Encrypt << /Filter /Standard /V 3 /R 3 /Length 128 >>",
+		// The PDF library derives 40-bit RC4 object keys incorrectly
+		"/Encrypt << /Filter /Standard /V 1 /R 2 >>",
+		"/Encrypt << /Filter /Standard /V 4 /R 4 /Length 128 /CF << /StdCF << /CFM /V2 >> >> /StmF /StdCF /StrF /StdCF >>",
+		"/Encrypt << /Filter /Standard /V 4 /R 4 /CF << /StdCF << /CFM /None >> >> /StmF /StdCF /StrF /StdCF >>",
+	} {
+		_, err := pdfExtractor.Extract(bytes.NewReader(p.bytes(catalog, encrypt+" /ID [<00> <00>]")), extractor.DefaultExtractOptions())
+		if !errors.Is(err, extractor.ErrUnsupportedEncryption) {
+			t.Errorf("%s: expected ErrUnsupportedEncryption, got %v", encrypt, err)
+		}
+	}
+}
+
+func TestPDFDocumentInfo(t *testing.T) {
+	p := &testPDF{}
+	catalog := p.reserve()
+	pages := p.reserve()
+	font := p.add(courierFont())
+
+	var pageIDs []int
+	for i := 0; i < 5; i++ {
+		stream := p.addStream("", textAt(72, 700, fmt.Sprintf("page %d", i+1)))
+		pageIDs = append(pageIDs, p.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pages, font, stream)))
+	}
+	p.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R %d 0 R %d 0 R %d 0 R %d 0 R] /Count 5 >>",
+		pageIDs[0], pageIDs[1], pageIDs[2], pageIDs[3], pageIDs[4]))
+
+	info := p.add("<< /Title (Annual Report) /Author (Jane Doe) /Keywords (finance, 2024) /CreationDate (D:20240131093000+01'00') >>")
+	xmp := p.addStream("/Type /Metadata /Subtype /XML", `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:pdf="http://ns.adobe.com/pdf/1.3/" pdf:Producer="Test Producer">
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">Annual Report XMP</rdf:li></rdf:Alt></dc:title>
+<dc:subject><rdf:Bag><rdf:li>finance</rdf:li><rdf:li>annual</rdf:li></rdf:Bag></dc:subject>
+</rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>`)
+
+	outlines := p.reserve()
+	chapter := p.reserve()
+	section := p.add(fmt.Sprintf("<< /Title (Section 1.1) /Parent %d 0 R /Dest (appendix) >>", chapter))
+	p.set(chapter, fmt.Sprintf("<< /Title (Chapter 1) /Parent %d 0 R /Dest [%d 0 R /Fit] /First %d 0 R /Last %d 0 R >>",
+		outlines, pageIDs[2], section, section))
+	p.set(outlines, fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count 2 >>", chapter, chapter))
+
+	p.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R /Metadata %d 0 R /Outlines %d 0 R /Names << /Dests << /Names [(appendix) [%d 0 R /Fit]] >> >> /PageLabels << /Nums [0 << /S /r >> 2 << /S /D >> 4 << /S /A /P (App-) >>] >> >>",
+		pages, xmp, outlines, pageIDs[4]))
+	document := p.bytes(catalog, fmt.Sprintf("/Info %d 0 R", info))
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	result, err := pdfExtractor.ExtractPDF(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+
+	if result.Info.Title != "Annual Report" || result.Info.Author != "Jane Doe" || result.Info.Keywords != "finance, 2024" {
+		t.Errorf("Unexpected document info: %+v", result.Info)
+	}
+	if result.Info.CreationDate.Format(time.RFC3339) != "2024-01-31T09:30:00+01:00" {
+		t.Errorf("Unexpected creation date: %v", result.Info.CreationDate)
+	}
+	if result.Metadata["title"] != "Annual Report" || result.Metadata["producer"] != "Test Producer" {
+		t.Errorf("Expected flattened document metadata, got %v", result.Metadata)
+	}
+
+	if result.XMP == nil {
+		t.Fatal("Expected XMP metadata")
+	}
+	if result.XMP.Title != "Annual Report XMP" || strings.Join(result.XMP.Subjects, ",") != "finance,annual" {
+		t.Errorf("Unexpected XMP metadata: %+v", result.XMP)
+	}
+
+	if len(result.Outline) != 1 || result.Outline[0].Title != "Chapter 1" || result.Outline[0].Page != 3 {
+		t.Fatalf("Unexpected outline: %+v", result.Outline)
+	}
+	if children := result.Outline[0].Children; len(children) != 1 || children[0].Title != "Section 1.1" || children[0].Page != 5 {
+		t.Errorf("Unexpected nested outline: %+v", children)
+	}
+
+	expectedLabels := []string{"i", "ii", "1", "2", "App-A"}
+	if strings.Join(result.PageLabels, ",") != strings.Join(expectedLabels, ",") {
+		t.Errorf("Expected page labels %v, got %v", expectedLabels, result.PageLabels)
+	}
+	if result.Pages[1].Label != "ii" {
+		t.Errorf("Expected page 2 label 'ii', got %q", result.Pages[1].Label)
+	}
+}
+
+func TestPDFOutlineIdenticalPages(t *testing.T) {
+	// Both pages draw the same content stream, so their dictionaries are
+	// identical and only their object references tell them apart
+	p := &testPDF{}
+	catalog := p.reserve()
+	pages := p.reserve()
+	font := p.add(courierFont())
+	stream := p.addStream("", textAt(72, 700, "same page"))
+	page := fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pages, font, stream)
+	first := p.add(page)
+	second := p.add(page)
+	p.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R %d 0 R] /Count 2 >>", first, second))
+
+	outlines := p.reserve()
+	item := p.add(fmt.Sprintf("<< /Title (Second) /Parent %d 0 R /Dest [%d 0 R /Fit] >>", outlines, second))
+	p.set(outlines, fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count 1 >>", item, item))
+	p.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R /Outlines %d 0 R >>", pages, outlines))
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	result, err := pdfExtractor.ExtractPDF(bytes.NewReader(p.bytes(catalog, "")), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if len(result.Outline) != 1 || result.Outline[0].Page != 2 {
+		t.Errorf("Expected the outline item on page 2, got %+v", result.Outline)
+	}
+}
+
+func TestPDFFormsAndAnnotations(t *testing.T) {
+	p := &testPDF{}
+	catalog := p.reserve()
+	pages := p.reserve()
+	page := p.reserve()
+	font := p.add(courierFont())
+	stream := p.addStream("", textAt(72, 700, "Application form"))
+
+	applicant := p.reserve()
+	name := p.add(fmt.Sprintf("<< /Type /Annot /Subtype /Widget /Rect [72 600 300 620] /P %d 0 R /Parent %d 0 R /T (name) /FT /Tx /V (Jane Doe) >>", page, applicant))
+	p.set(applicant, fmt.Sprintf("<< /T (applicant) /Kids [%d 0 R] >>", name))
+	agree := p.add(fmt.Sprintf("<< /Type /Annot /Subtype /Widget /Rect [72 560 90 578] /P %d 0 R /T (agree) /FT /Btn /V /Yes >>", page))
+	hidden := p.add("<< /T (internal) /FT /Tx /V (not shown) >>")
+	amount := p.add("<< /T (amount) /FT /Tx /V 1234567 >>")
+	rate := p.add("<< /T (rate) /FT /Tx /V 2.5 >>")
+
+	// Widgets with identical dictionaries are told apart by their references
+	widget := fmt.Sprintf("<< /Type /Annot /Subtype /Widget /Rect [72 520 90 538] /P %d 0 R >>", page)
+	first := p.add(widget)
+	second := p.add(widget)
+	copy1 := p.add(fmt.Sprintf("<< /T (copy1) /FT /Btn /Kids [%d 0 R] >>", first))
+	copy2 := p.add(fmt.Sprintf("<< /T (copy2) /FT /Btn /Kids [%d 0 R] >>", second))
+	note := p.add("<< /Type /Annot /Subtype /Text /Rect [400 700 420 720] /Contents (Please double-check the address) /T (Reviewer) >>")
+	popup := p.add("<< /Type /Annot /Subtype /Popup /Rect [400 600 500 700] /Contents (Please double-check the address) >>")
+
+	p.set(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R /Annots [%d 0 R %d 0 R %d 0 R %d 0 R %d 0 R %d 0 R] >>",
+		pages, font, stream, name, agree, note, popup, first, second))
+	p.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", page))
+	p.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R /AcroForm << /Fields [%d 0 R %d 0 R %d 0 R %d 0 R %d 0 R %d 0 R %d 0 R] >> >>",
+		pages, applicant, agree, hidden, amount, rate, copy1, copy2))
+	document := p.bytes(catalog, "")
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	result, err := pdfExtractor.ExtractPDF(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+
+	if result.Text != "Application form" {
+		t.Errorf("Expected form content to be excluded from text by default, got %q", result.Text)
+	}
+
+	expectedFields := map[string]extractor.PDFFormField{
+		"applicant.name": {Name: "applicant.name", Type: "text", Value: "Jane Doe", Page: 1},
+		"agree":          {Name: "agree", Type: "button", Value: "Yes", Page: 1},
+		"internal":       {Name: "internal", Type: "text", Value: "not shown", Page: 0},
+		"amount":         {Name: "amount", Type: "text", Value: "1234567", Page: 0},
+		"rate":           {Name: "rate", Type: "text", Value: "2.5", Page: 0},
+		"copy1":          {Name: "copy1", Type: "button", Page: 1},
+		"copy2":          {Name: "copy2", Type: "button", Page: 1},
+	}
+	if len(result.Fields) != len(expectedFields) {
+		t.Fatalf("Expected %d fields, got %+v", len(expectedFields), result.Fields)
+	}
+	for _, field := range result.Fields {
+		if expectedFields[field.Name] != field {
+			t.Errorf("Unexpected field %+v", field)
+		}
+	}
+
+	if len(result.Annotations) != 1 || result.Annotations[0].Subtype != "Text" || result.Annotations[0].Author != "Reviewer" {
+		t.Fatalf("Expected one sticky note annotation, got %+v", result.Annotations)
+	}
+
+	pdfExtractor = &extractor.PDFExtractor{IncludeFormFields: true, IncludeAnnotations: true}
+	result, err = pdfExtractor.ExtractPDF(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+
+	expected := "Application form\n\napplicant.name: Jane Doe\nagree: Yes\n[Text] Please double-check the address"
+	if result.Text != expected {
+		t.Errorf("Expected %q, got %q", expected, result.Text)
+	}
+}
+
+func TestPDFExtractReaderAt(t *testing.T) {
+	// Extraction must not depend on a writable temporary directory
+	t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+
+	file, err := os.Open("testdata/sample.pdf")
+	if err != nil {
+		t.Fatalf("failed to open sample: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatalf("failed to stat sample: %v", err)
+	}
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	result, err := pdfExtractor.ExtractReaderAt(file, info.Size(), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction from file failed: %v", err)
+	}
+	if result.Text != "A pdf sample" {
+		t.Errorf("Unexpected text: %q", result.Text)
+	}
+
+	document := buildTestPDF(textAt(72, 700, "in memory"))
+	result, err = pdfExtractor.ExtractReaderAt(bytes.NewReader(document), int64(len(document)), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction from bytes failed: %v", err)
+	}
+	if result.Text != "in memory" {
+		t.Errorf("Unexpected text: %q", result.Text)
+	}
+
+	options := extractor.DefaultExtractOptions()
+	options.MaxFileSize = 1
+	if _, err := pdfExtractor.ExtractReaderAt(file, info.Size(), options); err == nil {
+		t.Error("Expected error due to file size limit")
+	}
+}
+
+func TestPDFCleanup(t *testing.T) {
+	bodies := []string{"Revenue grew", "Costs fell", "Outlook"}
+	var contents []string
+	for page, body := range bodies {
+		contents = append(contents,
+			textAt(72, 750, "Annual Report")+
+				textAt(72, 700, body+" after extrac-")+
+				textAt(72, 688, "tion of "+strings.ToLower(body))+
+				textAt(72, 676, "a man\\234uvre in "+strings.ToLower(body))+
+				textAt(72, 50, fmt.Sprintf("Page %d of 3", page+1)))
+	}
+	document := buildTestPDF(contents...)
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	result, err := pdfExtractor.Extract(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if !strings.Contains(result.Text, "Annual Report") || !strings.Contains(result.Text, "extrac-\ntion") {
+		t.Errorf("Cleanup should be off by default: %q", result.Text)
+	}
+	if _, ok := result.Metadata["header_footer_lines_removed"]; ok {
+		t.Error("Unexpected header_footer_lines_removed without cleanup")
+	}
+
+	pdfExtractor.Cleanup = extractor.DefaultPDFCleanup()
+	pdfResult, err := pdfExtractor.ExtractPDF(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction with cleanup failed: %v", err)
+	}
+
+	// Œ is a letter in French, not a typographic ligature, so it is kept
+	expected := "Costs fell after extraction\nof costs fell\na manœuvre in costs fell"
+	if pdfResult.Pages[1].Text != expected {
+		t.Errorf("Expected page text %q, got %q", expected, pdfResult.Pages[1].Text)
+	}
+	if strings.Contains(pdfResult.Text, "Annual Report") || strings.Contains(pdfResult.Text, "of 3") {
+		t.Errorf("Running header or footer was not removed: %q", pdfResult.Text)
+	}
+	if removed := pdfResult.Metadata["header_footer_lines_removed"]; removed != 6 {
+		t.Errorf("Expected 6 header and footer lines removed, got %v", removed)
+	}
+
+	// Page offsets must follow the cleaned text
+	for _, page := range pdfResult.Pages {
+		if !strings.HasPrefix(string([]rune(pdfResult.Text)[page.Offset:]), page.Text) {
+			t.Errorf("Page %d offset %d does not point at its text", page.Number, page.Offset)
+		}
+	}
+
+	// A single page has nothing to compare against
+	single, err := pdfExtractor.Extract(bytes.NewReader(buildTestPDF(contents[0])), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if !strings.Contains(single.Text, "Annual Report") {
+		t.Errorf("Header removed from a single page document: %q", single.Text)
+	}
+}
+
+func TestPDFTableDetection(t *testing.T) {
+	// A ruled grid: outer border as a rectangle, inner lines as path segments
+	ruled := textAt(72, 730, "Price list") +
+		"0.5 w 72 640 300 60 re S\n" +
+		"72 680 m 372 680 l 72 660 m 372 660 l S\n" +
+		"172 640 m 172 700 l 272 640 m 272 700 l S\n" +
+		"0 0 612 792 re W n\n" // a clipping path is not a ruling
+	for i, row := range [][]string{{"Item", "Qty", "Price"}, {"Apple", "3", "1.50"}, {"Pear", "10", "0.75"}} {
+		for j, cell := range row {
+			ruled += textAt(float64(76+100*j), float64(686-20*i), cell)
+		}
+	}
+	ruled += textAt(72, 600, "Thank you")
+
+	// An unruled table whose columns are separated only by whitespace
+	aligned := textAt(72, 730, "Invoice 42 for services rendered")
+	for i, row := range [][]string{{"Description", "Hours", "Amount"}, {"Design work", "12", "960.00"}, {"Code review", "3", "240.00"}} {
+		y := float64(700 - 14*i)
+		aligned += textAt(72, y, row[0]) + textAt(300, y, row[1]) + textAt(400, y, row[2])
+	}
+	aligned += textAt(72, 600, "Payment is due within 30 days")
+
+	document := buildTestPDF(ruled, aligned)
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	result, err := pdfExtractor.ExtractPDF(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if len(result.Tables) != 0 {
+		t.Errorf("Tables detected without DetectTables: %d", len(result.Tables))
+	}
+
+	pdfExtractor.DetectTables = true
+	result, err = pdfExtractor.ExtractPDF(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction with table detection failed: %v", err)
+	}
+	if len(result.Tables) != 2 {
+		t.Fatalf("Expected 2 tables, got %d", len(result.Tables))
+	}
+
+	ruledTable := result.Tables[0]
+	if ruledTable.Page != 1 || !ruledTable.HasHeader {
+		t.Errorf("Unexpected ruled table page %d, header %v", ruledTable.Page, ruledTable.HasHeader)
+	}
+	if fmt.Sprint(ruledTable.Columns) != "[Item Qty Price]" || fmt.Sprint(ruledTable.Rows) != "[[Apple 3 1.50] [Pear 10 0.75]]" {
+		t.Errorf("Unexpected ruled table: %v %v", ruledTable.Columns, ruledTable.Rows)
+	}
+
+	alignedTable := result.Tables[1]
+	if alignedTable.Page != 2 || fmt.Sprint(alignedTable.Columns) != "[Description Hours Amount]" {
+		t.Errorf("Unexpected aligned table on page %d: %v", alignedTable.Page, alignedTable.Columns)
+	}
+	if fmt.Sprint(alignedTable.Rows) != "[[Design work 12 960.00] [Code review 3 240.00]]" {
+		t.Errorf("Unexpected aligned table rows: %v", alignedTable.Rows)
+	}
+
+	expected := "Price list\n\nItem\tQty\tPrice\nApple\t3\t1.50\nPear\t10\t0.75\n\nThank you"
+	if result.Pages[0].Text != expected {
+		t.Errorf("Expected page text %q, got %q", expected, result.Pages[0].Text)
+	}
+	if result.Pages[0].Metadata["tables"] != 1 || result.Metadata["tables"] != 2 {
+		t.Errorf("Unexpected table counts: page %v, document %v", result.Pages[0].Metadata["tables"], result.Metadata["tables"])
+	}
+
+	pdfExtractor.TableFormat = extractor.TableFormatMarkdown
+	result, err = pdfExtractor.ExtractPDF(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction with Markdown tables failed: %v", err)
+	}
+	expected = "Invoice 42 for services rendered\n\n" +
+		"| Description | Hours | Amount |\n| --- | --- | --- |\n| Design work | 12 | 960.00 |\n| Code review | 3 | 240.00 |\n\n" +
+		"Payment is due within 30 days"
+	if result.Pages[1].Text != expected {
+		t.Errorf("Expected page text %q, got %q", expected, result.Pages[1].Text)
+	}
+
+	// Ordinary paragraphs are left alone
+	prose := buildTestPDF(textAt(72, 700, "A line of ordinary text") + textAt(72, 688, "and another one below it") + textAt(72, 676, "and a third"))
+	proseResult, err := pdfExtractor.ExtractPDF(bytes.NewReader(prose), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if len(proseResult.Tables) != 0 {
+		t.Errorf("Unexpected tables in prose: %v", proseResult.Tables[0].Rows)
+	}
+}
+
+func TestPDFTableRulingsAcrossStreams(t *testing.T) {
+	// The first content stream sets a transformation that the ruling lines in
+	// the second stream rely on, as producers often do
+	cells := "1 0 0 1 0 -100 cm\n"
+	// The empty cell keeps the rows from being read as an unruled table
+	for i, row := range [][]string{{"Item", "Qty"}, {"Apple", ""}, {"Pear", "10"}} {
+		for j, cell := range row {
+			cells += textAt(float64(76+100*j), float64(786-20*i), cell)
+		}
+	}
+	grid := "0.5 w 72 740 200 60 re S\n" +
+		"72 780 m 272 780 l 72 760 m 272 760 l S\n" +
+		"172 740 m 172 800 l S\n"
+
+	p := &testPDF{}
+	catalog := p.reserve()
+	pages := p.reserve()
+	font := p.add(courierFont())
+	first := p.addStream("", cells)
+	second := p.addStream("", grid)
+	page := p.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 %d 0 R >> >> /Contents [%d 0 R %d 0 R] >>",
+		pages, font, first, second))
+	p.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	p.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", page))
+
+	pdfExtractor := &extractor.PDFExtractor{DetectTables: true}
+	result, err := pdfExtractor.ExtractPDF(bytes.NewReader(p.bytes(catalog, "")), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if len(result.Tables) != 1 {
+		t.Fatalf("Expected 1 ruled table, got %d", len(result.Tables))
+	}
+	if fmt.Sprint(result.Tables[0].Columns) != "[Item Qty]" || fmt.Sprint(result.Tables[0].Rows) != "[[Apple ] [Pear 10]]" {
+		t.Errorf("Unexpected table: %v %v", result.Tables[0].Columns, result.Tables[0].Rows)
+	}
+}
+
+func TestPDFScannedPages(t *testing.T) {
+	grayImage := "/Width 4 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 8"
+	scanned := buildScannedTestPDF(grayImage, []byte{0, 255, 255, 255, 255, 255, 255, 0}, "")
+
+	pdfExtractor := &extractor.PDFExtractor{}
+	result, err := pdfExtractor.ExtractPDF(bytes.NewReader(scanned), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if result.Text != "" || result.Pages[0].Metadata["scanned"] != true || result.Pages[0].Metadata["images"] != 1 {
+		t.Errorf("Expected an empty scanned page, got %q with %v", result.Text, result.Pages[0].Metadata)
+	}
+	if fmt.Sprint(result.Metadata["scanned_pages"]) != "[1]" {
+		t.Errorf("Unexpected scanned_pages: %v", result.Metadata["scanned_pages"])
+	}
+
+	engine := &fakeOCREngine{}
+	pdfExtractor.OCR = engine
+	options := extractor.DefaultExtractOptions()
+	options.OCRLanguage = "deu"
+	result, err = pdfExtractor.ExtractPDF(bytes.NewReader(scanned), options)
+	if err != nil {
+		t.Fatalf("PDF extraction with OCR failed: %v", err)
+	}
+	if result.Text != "scanned 4x2 starting 0" {
+		t.Errorf("Unexpected OCR text: %q", result.Text)
+	}
+	if result.Pages[0].Metadata["ocr"] != true || result.Pages[0].Metadata["ocr_confidence"] != 0.9 {
+		t.Errorf("Unexpected OCR metadata: %v", result.Pages[0].Metadata)
+	}
+	if fmt.Sprint(engine.languages) != "[deu]" {
+		t.Errorf("Expected OCR language to be passed on, got %v", engine.languages)
+	}
+
+	// JPEG images are read from the raw stream data
+	var jpegData bytes.Buffer
+	photo := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range photo.Pix {
+		photo.Pix[i] = 255
+	}
+	if err := jpeg.Encode(&jpegData, photo, nil); err != nil {
+		t.Fatalf("failed to encode JPEG: %v", err)
+	}
+	document := buildScannedTestPDF("/Width 8 /Height 8 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode", jpegData.Bytes(), "")
+	result, err = pdfExtractor.ExtractPDF(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction of JPEG scan failed: %v", err)
+	}
+	if result.Text != "scanned 8x8 starting 255" {
+		t.Errorf("Unexpected OCR text for JPEG scan: %q", result.Text)
+	}
+
+	// The raw JPEG data of an AES-256 document is read decrypted
+	document = buildEncryptedScannedTestPDF("/Width 8 /Height 8 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode", jpegData.Bytes(), "",
+		&testEncryption{userPassword: "user", revision: 6})
+	options = extractor.DefaultExtractOptions()
+	options.Password = "user"
+	result, err = pdfExtractor.ExtractPDF(bytes.NewReader(document), options)
+	if err != nil {
+		t.Fatalf("PDF extraction of encrypted JPEG scan failed: %v", err)
+	}
+	if result.Text != "scanned 8x8 starting 255" {
+		t.Errorf("Unexpected OCR text for encrypted JPEG scan: %q", result.Text)
+	}
+
+	// Images under real text do not make a scan
+	document = buildScannedTestPDF(grayImage, []byte{0, 255, 255, 255, 255, 255, 255, 0}, textAt(72, 700, "Letterhead"))
+	result, err = pdfExtractor.ExtractPDF(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if result.Text != "Letterhead" || result.Pages[0].Metadata["scanned"] != nil {
+		t.Errorf("Page with text treated as scanned: %q %v", result.Text, result.Pages[0].Metadata)
+	}
+
+	// Images that cannot be decoded are reported as a page failure
+	document = buildScannedTestPDF("/Width 4 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 1 /Filter /CCITTFaxDecode", []byte{0}, "")
+	result, err = pdfExtractor.ExtractPDF(bytes.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("PDF extraction failed: %v", err)
+	}
+	if result.Pages[0].Err == nil || !strings.Contains(result.Pages[0].Err.Error(), "CCITTFaxDecode") {
+		t.Errorf("Expected an unsupported filter error, got %v", result.Pages[0].Err)
+	}
+	if fmt.Sprint(result.Metadata["failed_pages"]) != "[1]" {
+		t.Errorf("Unexpected failed_pages: %v", result.Metadata["failed_pages"])
+	}
+}
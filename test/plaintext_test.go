@@ -0,0 +1,276 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Puhan-Zhou/go-filetext/extractor"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+)
+
+func TestCharsetDetection(t *testing.T) {
+	tests := []struct {
+		encoding string
+		enc      encoding.Encoding
+		text     string
+	}{
+		{"Shift_JIS", japanese.ShiftJIS, "東京の天気は晴れです。明日は雨が降るかもしれないので、傘を持って行ってください。"},
+		{"EUC-JP", japanese.EUCJP, "会議は午後三時から始まります。資料は事前に確認しておいてください。"},
+		{"ISO-2022-JP", japanese.ISO2022JP, "お問い合わせいただきありがとうございます。担当者から連絡します。"},
+		{"GBK", simplifiedchinese.GBK, "我们的会议将在下午三点开始，请大家提前准备好相关的资料。"},
+		{"Big5", traditionalchinese.Big5, "我們的會議將在下午三點開始，請大家提前準備好相關的資料。"},
+		{"EUC-KR", korean.EUCKR, "오늘 회의는 오후 세 시에 시작합니다. 관련 자료를 미리 준비해 주시기 바랍니다."},
+		{"Windows-1251", charmap.Windows1251, "Совещание начнётся в три часа дня. Пожалуйста, подготовьте все необходимые материалы заранее."},
+		{"KOI8-R", charmap.KOI8R, "Совещание начнётся в три часа дня. Пожалуйста, подготовьте все необходимые материалы заранее."},
+		{"Windows-1252", charmap.Windows1252, "La réunion commencera à quinze heures. Veuillez préparer les documents nécessaires à l'avance."},
+		{"Windows-1250", charmap.Windows1250, "Spotkanie rozpocznie się o piętnastej. Proszę przygotować wszystkie potrzebne materiały wcześniej."},
+	}
+
+	plainExtractor := extractor.NewPlainTextExtractor()
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			content, err := tt.enc.NewEncoder().Bytes([]byte(tt.text))
+			if err != nil {
+				t.Fatalf("Failed to encode sample: %v", err)
+			}
+
+			result, err := plainExtractor.Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+			if err != nil {
+				t.Fatalf("Extraction failed: %v", err)
+			}
+			if result.Metadata["encoding"] != tt.encoding {
+				t.Errorf("Expected encoding %s, got %v (confidence %v)", tt.encoding, result.Metadata["encoding"], result.Metadata["encoding_confidence"])
+			}
+			if result.Text != tt.text {
+				t.Errorf("Expected text %q, got %q", tt.text, result.Text)
+			}
+			if confidence, _ := result.Metadata["encoding_confidence"].(float64); confidence <= 0 || confidence > 1 {
+				t.Errorf("Expected confidence in (0, 1], got %v", result.Metadata["encoding_confidence"])
+			}
+		})
+	}
+
+	// Valid UTF-8 is detected with full confidence
+	result, err := plainExtractor.Extract(bytes.NewReader([]byte("naïve café")), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("Extraction failed: %v", err)
+	}
+	if result.Metadata["encoding"] != "UTF-8" || result.Metadata["encoding_confidence"] != 1.0 {
+		t.Errorf("Expected UTF-8 with confidence 1, got %v (%v)", result.Metadata["encoding"], result.Metadata["encoding_confidence"])
+	}
+}
+
+func TestForcedEncoding(t *testing.T) {
+	// Windows-1251 bytes that also decode as Windows-1252 mojibake
+	text := "Привет, мир"
+	content, err := charmap.Windows1251.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatalf("Failed to encode sample: %v", err)
+	}
+
+	options := extractor.DefaultExtractOptions()
+	options.Encoding = "windows-1252"
+	result, err := extractor.NewPlainTextExtractor().Extract(bytes.NewReader(content), options)
+	if err != nil {
+		t.Fatalf("Extraction failed: %v", err)
+	}
+	if result.Text != "Ïðèâåò, ìèð" {
+		t.Errorf("Expected forced Windows-1252 decoding, got %q", result.Text)
+	}
+	if result.Metadata["encoding"] != "windows-1252" || result.Metadata["encoding_confidence"] != 1.0 {
+		t.Errorf("Expected forced encoding windows-1252, got %v (%v)", result.Metadata["encoding"], result.Metadata["encoding_confidence"])
+	}
+
+	// IANA names and aliases are accepted
+	options.Encoding = "cp1251"
+	result, err = extractor.NewPlainTextExtractor().Extract(bytes.NewReader(content), options)
+	if err != nil {
+		t.Fatalf("Extraction failed: %v", err)
+	}
+	if result.Text != text {
+		t.Errorf("Expected %q, got %q", text, result.Text)
+	}
+
+	options.Encoding = "no-such-charset"
+	_, err = extractor.NewPlainTextExtractor().Extract(bytes.NewReader(content), options)
+	if !errors.Is(err, extractor.ErrUnsupportedEncoding) {
+		t.Errorf("Expected ErrUnsupportedEncoding, got %v", err)
+	}
+}
+
+func TestByteOrderMarks(t *testing.T) {
+	text := "Größe,Menge\nÄpfel,3"
+	tests := []struct {
+		encoding string
+		enc      encoding.Encoding
+	}{
+		{"UTF-8", unicode.UTF8BOM},
+		{"UTF-16LE", unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)},
+		{"UTF-16BE", unicode.UTF16(unicode.BigEndian, unicode.UseBOM)},
+		{"UTF-32LE", utf32.UTF32(utf32.LittleEndian, utf32.UseBOM)},
+		{"UTF-32BE", utf32.UTF32(utf32.BigEndian, utf32.UseBOM)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			content, err := tt.enc.NewEncoder().Bytes([]byte(text))
+			if err != nil {
+				t.Fatalf("Failed to encode sample: %v", err)
+			}
+
+			result, err := extractor.NewPlainTextExtractor().Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+			if err != nil {
+				t.Fatalf("Extraction failed: %v", err)
+			}
+			if result.Text != text {
+				t.Errorf("Expected text %q without BOM, got %q", text, result.Text)
+			}
+			if result.Metadata["encoding"] != tt.encoding || result.Metadata["bom"] != true {
+				t.Errorf("Expected %s with BOM, got %v (bom %v)", tt.encoding, result.Metadata["encoding"], result.Metadata["bom"])
+			}
+
+			// The BOM does not end up in the first header cell of a CSV file
+			result, err = extractor.NewCSVExtractor().Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+			if err != nil {
+				t.Fatalf("CSV extraction failed: %v", err)
+			}
+			if !strings.HasPrefix(result.Text, "Größe,") || result.Metadata["bom"] != true {
+				t.Errorf("Expected CSV text without BOM, got %q (bom %v)", result.Text, result.Metadata["bom"])
+			}
+		})
+	}
+
+	// Forcing an encoding still strips its BOM
+	content, _ := unicode.UTF8BOM.NewEncoder().Bytes([]byte(text))
+	options := extractor.DefaultExtractOptions()
+	options.Encoding = "utf-8"
+	result, err := extractor.NewPlainTextExtractor().Extract(bytes.NewReader(content), options)
+	if err != nil {
+		t.Fatalf("Extraction failed: %v", err)
+	}
+	if result.Text != text || result.Metadata["bom"] != true {
+		t.Errorf("Expected forced UTF-8 without BOM, got %q (bom %v)", result.Text, result.Metadata["bom"])
+	}
+
+	result, err = extractor.NewPlainTextExtractor().Extract(strings.NewReader(text), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("Extraction failed: %v", err)
+	}
+	if result.Metadata["bom"] != false {
+		t.Errorf("Expected bom false, got %v", result.Metadata["bom"])
+	}
+}
+
+func TestUnicodeWithoutBOM(t *testing.T) {
+	text := "Quarterly report\nRevenue grew by 12% to €4.2 million."
+	tests := []struct {
+		encoding string
+		enc      encoding.Encoding
+	}{
+		{"UTF-16LE", unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)},
+		{"UTF-16BE", unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)},
+		{"UTF-32LE", utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM)},
+		{"UTF-32BE", utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			content, err := tt.enc.NewEncoder().Bytes([]byte(text))
+			if err != nil {
+				t.Fatalf("Failed to encode sample: %v", err)
+			}
+
+			result, err := extractor.NewPlainTextExtractor().Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+			if err != nil {
+				t.Fatalf("Extraction failed: %v", err)
+			}
+			if result.Text != text {
+				t.Errorf("Expected text %q, got %q", text, result.Text)
+			}
+			if result.Metadata["encoding"] != tt.encoding || result.Metadata["bom"] != false {
+				t.Errorf("Expected %s without BOM, got %v (bom %v)", tt.encoding, result.Metadata["encoding"], result.Metadata["bom"])
+			}
+		})
+	}
+}
+
+func TestBinaryContent(t *testing.T) {
+	// A blob with an ASCII string, a UTF-16LE string and a short run between NUL bytes
+	var blob bytes.Buffer
+	blob.Write([]byte{0x7F, 'E', 'L', 'F', 0x02, 0x01, 0x00, 0x00})
+	blob.WriteString("GCC: (GNU) 13.2.0")
+	blob.Write([]byte{0x00, 0x01, 0x00})
+	blob.WriteString("abc")
+	blob.Write([]byte{0x00, 0x00})
+	utf16, _ := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder().Bytes([]byte("Settings.ini"))
+	blob.Write(utf16)
+	blob.Write([]byte{0x00, 0x00, 0xFF, 0xFE, 0x03})
+	content := blob.Bytes()
+
+	plainExtractor := extractor.NewPlainTextExtractor()
+	_, err := plainExtractor.Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if !errors.Is(err, extractor.ErrBinaryContent) {
+		t.Errorf("Expected ErrBinaryContent, got %v", err)
+	}
+
+	// Control characters give binary content away without NUL bytes
+	controls := bytes.Repeat([]byte{0x01, 0x02, 'a', 0x03, 0x04, 'b', 0x1F, 0x05}, 64)
+	_, err = plainExtractor.Extract(bytes.NewReader(controls), extractor.DefaultExtractOptions())
+	if !errors.Is(err, extractor.ErrBinaryContent) {
+		t.Errorf("Expected ErrBinaryContent for control characters, got %v", err)
+	}
+
+	// Text with tabs, form feeds and ANSI escapes is still text
+	text := "col1\tcol2\n\x1b[1mbold\x1b[0m\fnext page\n"
+	result, err := plainExtractor.Extract(strings.NewReader(text), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("Extraction failed: %v", err)
+	}
+	if result.Text != text {
+		t.Errorf("Expected %q, got %q", text, result.Text)
+	}
+
+	// Strings mode reports printable runs in the order they occur
+	plainExtractor.Strings = true
+	result, err = plainExtractor.Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("Strings extraction failed: %v", err)
+	}
+	expected := "GCC: (GNU) 13.2.0\nSettings.ini"
+	if result.Text != expected {
+		t.Errorf("Expected strings %q, got %q", expected, result.Text)
+	}
+	if result.Metadata["binary"] != true || result.Metadata["string_count"] != 2 {
+		t.Errorf("Expected 2 strings from binary content, got %v (binary %v)", result.Metadata["string_count"], result.Metadata["binary"])
+	}
+	if offsets, _ := result.Metadata["string_offsets"].([]int); len(offsets) != 2 || offsets[0] != 8 || offsets[1] != 33 {
+		t.Errorf("Expected string offsets [8 33], got %v", result.Metadata["string_offsets"])
+	}
+
+	plainExtractor.MinStringLength = 3
+	result, err = plainExtractor.Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("Strings extraction failed: %v", err)
+	}
+	expected = "ELF\nGCC: (GNU) 13.2.0\nabc\nSettings.ini"
+	if result.Text != expected {
+		t.Errorf("Expected strings %q, got %q", expected, result.Text)
+	}
+
+	// A forced encoding skips the check
+	options := extractor.DefaultExtractOptions()
+	options.Encoding = "iso-8859-1"
+	plainExtractor.Strings = false
+	if _, err := plainExtractor.Extract(bytes.NewReader(content), options); err != nil {
+		t.Errorf("Expected forced encoding to bypass the binary check, got %v", err)
+	}
+}
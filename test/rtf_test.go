@@ -0,0 +1,192 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Puhan-Zhou/go-filetext/extractor"
+)
+
+func TestRTFExtraction(t *testing.T) {
+	rtfExtractor := extractor.NewRTFExtractor()
+	result, err := rtfExtractor.ExtractFromFile("testdata/sample.rtf", extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("RTF extraction failed: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"Intake memo",
+		"The tenant’s claim concerns the café lease signed on 1 March\u00a02023.",
+		"·\tSigned lease",
+		"·\tPayment records",
+		"Parties:",
+		"Landlord\tACME Properties",
+		"Item\tAmount",
+		"Deposit\t€ 1,200",
+		"Next hearing: court calendar.",
+	}, "\n")
+	if result.Text != expected {
+		t.Errorf("Expected text:\n%q\ngot:\n%q", expected, result.Text)
+	}
+	for _, dropped := range []string{"Calibri", "Riched20", "wmetafile", "0100090000", "HYPERLINK", "Internal reference", "\\"} {
+		if strings.Contains(result.Text, dropped) {
+			t.Errorf("Expected %q to be dropped, got %q", dropped, result.Text)
+		}
+	}
+
+	expectedMetadata := map[string]interface{}{
+		"title":            "Intake memo",
+		"subject":          "Lease dispute",
+		"author":           "Jordan Blake",
+		"last_modified_by": "Casey Ng",
+		"description":      "Prepared for the first hearing.",
+		"created":          "2024-03-05T09:15:00",
+		"modified":         "2024-03-06T17:40:00",
+		"encoding":         "windows-1252",
+		"paragraphs":       "6",
+		"table_rows":       "2",
+	}
+	for key, value := range expectedMetadata {
+		if result.Metadata[key] != value {
+			t.Errorf("Metadata[%q] = %v, expected %v", key, result.Metadata[key], value)
+		}
+	}
+	if keywords := result.Metadata["keywords"]; !reflect.DeepEqual(keywords, []string{"lease", "dispute", "intake"}) {
+		t.Errorf("Expected keywords [lease dispute intake], got %v", keywords)
+	}
+	if result.FileType != "rtf" {
+		t.Errorf("Expected file type 'rtf', got '%s'", result.FileType)
+	}
+
+	// The factory routes RTF files to the RTF extractor rather than plain text
+	created, err := extractor.CreateExtractorFromPath("testdata/sample.rtf")
+	if err != nil {
+		t.Fatalf("CreateExtractorFromPath failed: %v", err)
+	}
+	if _, ok := created.(*extractor.RTFExtractor); !ok {
+		t.Errorf("Expected *extractor.RTFExtractor, got %T", created)
+	}
+
+	_, err = rtfExtractor.Extract(strings.NewReader("plain text"), extractor.DefaultExtractOptions())
+	if !errors.Is(err, extractor.ErrNotRTF) {
+		t.Errorf("Expected ErrNotRTF, got %v", err)
+	}
+}
+
+func TestRTFEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		encoding string
+		text     string
+	}{
+		{
+			name:     "ansicpg",
+			document: `{\rtf1\ansi\ansicpg1251{\fonttbl{\f0\fcharset204 Arial;}}\f0 \'cf\'f0\'e8\'e2\'e5\'f2\par}`,
+			encoding: "windows-1251",
+			text:     "Привет",
+		},
+		{
+			name:     "double-byte code page",
+			document: `{\rtf1\ansi\ansicpg932 \'93\'fa\'96\'7b\par}`,
+			encoding: "shift_jis",
+			text:     "日本",
+		},
+		{
+			name:     "unicode with fallback",
+			document: `{\rtf1\ansi\ansicpg1252\uc1 \u26085?\u26412?{\uc2 \u-3913\'3f\'3f}\uc0 \u8364 !\par}`,
+			encoding: "windows-1252",
+			text:     "日本\uf0b7€!",
+		},
+		{
+			name:     "default fallback count",
+			document: `{\rtf1\ansi caf\u233?}`,
+			encoding: "windows-1252",
+			text:     "café",
+		},
+		{
+			name:     "surrogate pair",
+			document: `{\rtf1\ansi Done \u-10179?\u-8704? ok\par}`,
+			encoding: "windows-1252",
+			text:     "Done \U0001F600 ok",
+		},
+		{
+			name:     "unpaired surrogate",
+			document: `{\rtf1\ansi\uc1 a\u-10179?b}`,
+			encoding: "windows-1252",
+			text:     "a\ufffdb",
+		},
+		{
+			name:     "escapes",
+			document: "{\\rtf1 Braces \\{x\\} and \\\\ slash,\\-hyphen\\_ok\\\n{\\*\\unknowndest hidden}\\endash done}",
+			encoding: "windows-1252",
+			text:     "Braces {x} and \\ slash,hyphen\u2011ok\n–done",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := extractor.NewRTFExtractor().Extract(strings.NewReader(tt.document), extractor.DefaultExtractOptions())
+			if err != nil {
+				t.Fatalf("RTF extraction failed: %v", err)
+			}
+			if result.Text != tt.text {
+				t.Errorf("Expected %q, got %q", tt.text, result.Text)
+			}
+			if result.Metadata["encoding"] != tt.encoding {
+				t.Errorf("Expected encoding %s, got %v", tt.encoding, result.Metadata["encoding"])
+			}
+		})
+	}
+
+	// A forced encoding overrides \ansicpg
+	options := extractor.DefaultExtractOptions()
+	options.Encoding = "koi8-r"
+	result, err := extractor.NewRTFExtractor().Extract(strings.NewReader(`{\rtf1\ansi\ansicpg1252 \'f0\'d2\'c9\'d7\'c5\'d4}`), options)
+	if err != nil {
+		t.Fatalf("RTF extraction failed: %v", err)
+	}
+	if result.Text != "Привет" || result.Metadata["encoding"] != "koi8-r" {
+		t.Errorf("Expected KOI8-R text, got %q (%v)", result.Text, result.Metadata["encoding"])
+	}
+}
+
+func TestRTFByteOrderMark(t *testing.T) {
+	document := `{\rtf1\ansi\ansicpg1252 caf\'e9\par}`
+	utf16 := []byte{0xff, 0xfe}
+	for _, r := range document {
+		utf16 = append(utf16, byte(r), 0)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"utf-8", "\xef\xbb\xbf" + document},
+		{"utf-16le", string(utf16)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := extractor.NewRTFExtractor().Extract(strings.NewReader(tt.content), extractor.DefaultExtractOptions())
+			if err != nil {
+				t.Fatalf("RTF extraction failed: %v", err)
+			}
+			if result.Text != "café" || result.Metadata["bom"] != true {
+				t.Errorf("Expected %q with BOM, got %q (bom %v)", "café", result.Text, result.Metadata["bom"])
+			}
+			if result.Metadata["size_bytes"] != len(tt.content) {
+				t.Errorf("Expected size_bytes %d, got %v", len(tt.content), result.Metadata["size_bytes"])
+			}
+		})
+	}
+
+	result, err := extractor.NewRTFExtractor().Extract(strings.NewReader(document), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("RTF extraction failed: %v", err)
+	}
+	if result.Metadata["bom"] != false {
+		t.Errorf("Expected bom false, got %v", result.Metadata["bom"])
+	}
+}
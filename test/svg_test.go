@@ -0,0 +1,107 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Puhan-Zhou/go-filetext/extractor"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestSVGExtraction(t *testing.T) {
+	svgExtractor := extractor.NewSVGExtractor()
+	result, err := svgExtractor.ExtractFromFile("testdata/sample.svg", extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("SVG extraction failed: %v", err)
+	}
+
+	expected := "Client & browser\nAPI\ngateway\nDatabase\nRequests are retried\nup to three times."
+	if result.Text != expected {
+		t.Errorf("Expected text %q, got %q", expected, result.Text)
+	}
+	if result.FileType != "svg" {
+		t.Errorf("Expected file type 'svg', got '%s'", result.FileType)
+	}
+
+	expectedMetadata := map[string]interface{}{
+		"title":          "Request flow",
+		"width":          "640",
+		"height":         "360",
+		"viewbox":        "0 0 640 360",
+		"viewbox_width":  640.0,
+		"viewbox_height": 360.0,
+		"text_elements":  5,
+	}
+	for key, value := range expectedMetadata {
+		if result.Metadata[key] != value {
+			t.Errorf("Metadata[%q] = %v, expected %v", key, result.Metadata[key], value)
+		}
+	}
+
+	// Titles and descriptions are included on request, in document order
+	svgExtractor.IncludeDescriptions = true
+	result, err = svgExtractor.ExtractFromFile("testdata/sample.svg", extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("SVG extraction failed: %v", err)
+	}
+	expected = "Request flow\nHow a request travels from the client to the database\nClient & browser\nApplication server\nAPI\ngateway\nDatabase\nRequests are retried\nup to three times."
+	if result.Text != expected {
+		t.Errorf("Expected text %q, got %q", expected, result.Text)
+	}
+
+	// The factory routes SVG files to the SVG extractor
+	created, err := extractor.CreateExtractorFromPath("testdata/sample.svg")
+	if err != nil {
+		t.Fatalf("CreateExtractorFromPath failed: %v", err)
+	}
+	if _, ok := created.(*extractor.SVGExtractor); !ok {
+		t.Errorf("Expected *extractor.SVGExtractor, got %T", created)
+	}
+}
+
+func TestSVGExtractionErrors(t *testing.T) {
+	svgExtractor := extractor.NewSVGExtractor()
+	for name, content := range map[string]string{
+		"not SVG":   `<html><body>Hello</body></html>`,
+		"malformed": `<svg><text>Unclosed`,
+		"empty":     ``,
+	} {
+		if _, err := svgExtractor.Extract(strings.NewReader(content), extractor.DefaultExtractOptions()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	options := extractor.DefaultExtractOptions()
+	options.MaxFileSize = 10
+	if _, err := svgExtractor.Extract(strings.NewReader(`<svg xmlns="http://www.w3.org/2000/svg"/>`), options); err == nil {
+		t.Error("Expected an error for a file above the size limit")
+	}
+}
+
+func TestSVGByteOrderMark(t *testing.T) {
+	svg := `<?xml version="1.0" encoding="UTF-16"?><svg xmlns="http://www.w3.org/2000/svg"><text>Größe</text></svg>`
+	content, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(svg))
+	if err != nil {
+		t.Fatalf("Failed to encode sample: %v", err)
+	}
+
+	result, err := extractor.NewSVGExtractor().Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("SVG extraction failed: %v", err)
+	}
+	if result.Text != "Größe" || result.Metadata["bom"] != true {
+		t.Errorf("Expected %q with BOM, got %q (bom %v)", "Größe", result.Text, result.Metadata["bom"])
+	}
+
+	// A declared legacy encoding is converted without a BOM
+	content, _ = charmap.ISO8859_1.NewEncoder().Bytes([]byte(strings.Replace(svg, "UTF-16", "ISO-8859-1", 1)))
+	result, err = extractor.NewSVGExtractor().Extract(bytes.NewReader(content), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("SVG extraction failed: %v", err)
+	}
+	if result.Text != "Größe" || result.Metadata["bom"] != false {
+		t.Errorf("Expected %q without BOM, got %q (bom %v)", "Größe", result.Text, result.Metadata["bom"])
+	}
+}
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Puhan-Zhou/go-filetext/extractor"
+)
+
+func TestCSVTableExtraction(t *testing.T) {
+	csvExtractor := extractor.NewCSVExtractor()
+	options := extractor.DefaultExtractOptions()
+
+	tables, err := csvExtractor.ExtractTablesFromFile("testdata/sample.csv", options, extractor.DefaultTableOptions())
+	if err != nil {
+		t.Fatalf("CSV table extraction failed: %v", err)
+	}
+
+	if len(tables) != 1 {
+		t.Fatalf("Expected 1 table, got %d", len(tables))
+	}
+
+	table := tables[0]
+	if !table.HasHeader {
+		t.Error("Expected header to be detected")
+	}
+
+	expectedColumns := []string{"Name", "Email", "Phone", "Age", "City"}
+	if strings.Join(table.Columns, ",") != strings.Join(expectedColumns, ",") {
+		t.Errorf("Expected columns %v, got %v", expectedColumns, table.Columns)
+	}
+
+	records := table.Records()
+	if len(records) != 5 {
+		t.Fatalf("Expected 5 records, got %d", len(records))
+	}
+
+	if records[0]["Name"] != "John Doe" || records[0]["Age"] != "30" {
+		t.Errorf("Unexpected first record: %v", records[0])
+	}
+}
+
+func TestTableOptions(t *testing.T) {
+	csvExtractor := extractor.NewCSVExtractor()
+	options := extractor.DefaultExtractOptions()
+	input := "Report generated 2024\n\nid,label,,\n1,alpha,,\n\n2,beta,,\n"
+
+	tableOptions := extractor.DefaultTableOptions()
+	tableOptions.HeaderRow = 1
+	tables, err := csvExtractor.ExtractTables(strings.NewReader(input), options, tableOptions)
+	if err != nil {
+		t.Fatalf("CSV table extraction failed: %v", err)
+	}
+
+	table := tables[0]
+	if strings.Join(table.Columns, ",") != "id,label" {
+		t.Errorf("Expected trailing empty columns to be trimmed, got %v", table.Columns)
+	}
+	if len(table.Rows) != 2 {
+		t.Errorf("Expected blank rows to be skipped, got %d rows", len(table.Rows))
+	}
+
+	tableOptions = extractor.DefaultTableOptions()
+	tableOptions.HeaderRow = -1
+	tableOptions.SkipBlankRows = false
+	tableOptions.TrimTrailingEmptyColumns = false
+	tables, err = csvExtractor.ExtractTables(strings.NewReader("1,2\n,\n3,4\n"), options, tableOptions)
+	if err != nil {
+		t.Fatalf("CSV table extraction failed: %v", err)
+	}
+
+	table = tables[0]
+	if table.HasHeader {
+		t.Error("Expected no header when HeaderRow is negative")
+	}
+	if strings.Join(table.Columns, ",") != "Column1,Column2" {
+		t.Errorf("Expected generated column names, got %v", table.Columns)
+	}
+	if len(table.Rows) != 3 {
+		t.Errorf("Expected blank rows to be kept, got %d rows", len(table.Rows))
+	}
+
+	// HeaderRow counts the source rows, whether or not blank rows are skipped
+	for _, skipBlankRows := range []bool{true, false} {
+		tableOptions = extractor.DefaultTableOptions()
+		tableOptions.HeaderRow = 2
+		tableOptions.SkipBlankRows = skipBlankRows
+		tables, err = csvExtractor.ExtractTables(strings.NewReader("\n\nid,name\n1,a\n"), options, tableOptions)
+		if err != nil {
+			t.Fatalf("CSV table extraction failed: %v", err)
+		}
+		table = tables[0]
+		if !table.HasHeader || strings.Join(table.Columns, ",") != "id,name" || len(table.Rows) != 1 {
+			t.Errorf("SkipBlankRows=%v: expected header id,name and 1 row, got %v and %v", skipBlankRows, table.Columns, table.Rows)
+		}
+	}
+
+	tableOptions = extractor.DefaultTableOptions()
+	tableOptions.HeaderRow = 5
+	if _, err := csvExtractor.ExtractTables(strings.NewReader("id,name\n1,a\n"), options, tableOptions); err == nil {
+		t.Error("Expected an error for a header row beyond the last row")
+	}
+}
+
+func TestTableColumnNames(t *testing.T) {
+	tableOptions := extractor.DefaultTableOptions()
+	tableOptions.DetectHeader = false
+	tables, err := extractor.NewCSVExtractor().ExtractTables(strings.NewReader("a,a_2,a,,a\n1,2,3,4,5\n"), extractor.DefaultExtractOptions(), tableOptions)
+	if err != nil {
+		t.Fatalf("CSV table extraction failed: %v", err)
+	}
+
+	table := tables[0]
+	if expected := "a,a_2,a_3,Column4,a_4"; strings.Join(table.Columns, ",") != expected {
+		t.Errorf("Expected unique columns %s, got %v", expected, table.Columns)
+	}
+	if record := table.Records()[0]; len(record) != 5 || record["a_3"] != "3" || record["a_2"] != "2" {
+		t.Errorf("Expected every column in the record, got %v", record)
+	}
+}
+
+func TestXLSXTableExtraction(t *testing.T) {
+	xlsxExtractor := extractor.NewXLSXExtractor()
+	options := extractor.DefaultExtractOptions()
+
+	tables, err := xlsxExtractor.ExtractTablesFromFile("testdata/sample.xlsx", options, extractor.DefaultTableOptions())
+	if err != nil {
+		t.Fatalf("XLSX table extraction failed: %v", err)
+	}
+
+	if len(tables) == 0 {
+		t.Fatal("Expected at least one table")
+	}
+
+	for _, table := range tables {
+		if table.Name == "" {
+			t.Error("Expected sheet name on table")
+		}
+		for _, row := range table.Rows {
+			if len(row) != len(table.Columns) {
+				t.Errorf("Row %v not aligned with columns %v", row, table.Columns)
+			}
+		}
+		t.Logf("Sheet %s columns: %v, rows: %d", table.Name, table.Columns, len(table.Rows))
+	}
+}
+
+func TestTableRender(t *testing.T) {
+	table := &extractor.Table{
+		Columns:   []string{"Key", "Value"},
+		Rows:      [][]string{{"a|b", "two\twords"}, {"c", ""}},
+		HasHeader: true,
+	}
+
+	if text := table.Render(extractor.TableFormatTSV); text != "Key\tValue\na|b\ttwo words\nc\t" {
+		t.Errorf("Unexpected TSV rendering: %q", text)
+	}
+	expected := "| Key | Value |\n| --- | --- |\n| a\\|b | two words |\n| c |  |"
+	if text := table.Render(extractor.TableFormatMarkdown); text != expected {
+		t.Errorf("Unexpected Markdown rendering: %q", text)
+	}
+
+	// Generated column names only appear where the format needs a header row
+	table.HasHeader = false
+	table.Columns = []string{"Column1", "Column2"}
+	if text := table.Render(""); text != "a|b\ttwo words\nc\t" {
+		t.Errorf("Unexpected TSV rendering without header: %q", text)
+	}
+}
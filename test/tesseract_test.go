@@ -0,0 +1,205 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Puhan-Zhou/go-filetext/extractor"
+)
+
+// fakeTesseractScript mimics the tesseract command line: it records its
+// arguments and input next to itself and prints canned TSV or hOCR output
+const fakeTesseractScript = `#!/bin/sh
+echo "$@" > "$0.args"
+cat > "$0.input"
+for format; do :; done
+case "$format" in
+tsv)
+	printf 'level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n'
+	printf '1\t1\t0\t0\t0\t0\t0\t0\t640\t480\t-1\t\n'
+	printf '4\t1\t1\t1\t1\t0\t36\t92\t130\t24\t-1\t\n'
+	printf '5\t1\t1\t1\t1\t1\t36\t92\t60\t24\t96.5\tHello\n'
+	printf '5\t1\t1\t1\t1\t2\t106\t92\t60\t24\t91\tWorld\n'
+	printf '5\t1\t1\t1\t2\t1\t36\t122\t40\t24\t88\tagain\n'
+	printf '5\t1\t1\t1\t2\t2\t80\t122\t8\t24\t95\t \n'
+	printf '5\t1\t2\t1\t1\t1\t36\t200\t50\t24\t80\tSigned\n'
+	;;
+hocr)
+	cat <<'EOF'
+<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en" lang="en">
+ <head><meta name='ocr-system' content='tesseract 5.3.0' /></head>
+ <body>
+  <div class='ocr_page' id='page_1' title='image "stdin"; bbox 0 0 640 480; ppageno 0'>
+   <div class='ocr_carea' id='block_1_1' title="bbox 36 92 166 146">
+    <p class='ocr_par' id='par_1_1' lang='eng' title="bbox 36 92 166 146">
+     <span class='ocr_line' id='line_1_1' title="bbox 36 92 166 116; baseline 0 -5">
+      <span class='ocrx_word' id='word_1_1' title='bbox 36 92 96 116; x_wconf 96'>Hello</span>
+      <span class='ocrx_word' id='word_1_2' title='bbox 106 92 166 116; x_wconf 91'><strong>World</strong></span>
+     </span>
+     <span class='ocr_line' id='line_1_2' title="bbox 36 122 76 146; baseline 0 -5">
+      <span class='ocrx_word' id='word_1_3' title='bbox 36 122 76 146; x_wconf 88'>R&amp;D&nbsp;</span>
+     </span>
+    </p>
+   </div>
+  </div>
+ </body>
+</html>
+EOF
+	;;
+*)
+	echo "read_params_file: Can't open $format" >&2
+	exit 1
+	;;
+esac
+`
+
+// writeScript writes an executable shell script to a temporary directory
+func writeScript(t *testing.T, name, content string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported on Windows")
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	return path
+}
+
+func TestTesseractOCREngine(t *testing.T) {
+	script := writeScript(t, "tesseract", fakeTesseractScript)
+	img := image.NewGray(image.Rect(0, 0, 64, 48))
+
+	engine := extractor.NewTesseractOCREngine(script)
+	engine.PageSegMode = 6
+	result, err := engine.Recognize(img, extractor.OCROptions{Language: "eng+deu", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+
+	args, _ := os.ReadFile(script + ".args")
+	if got := strings.TrimSpace(string(args)); got != "stdin stdout -l eng+deu --psm 6 tsv" {
+		t.Errorf("tesseract arguments = %q", got)
+	}
+	input, _ := os.ReadFile(script + ".input")
+	if !bytes.HasPrefix(input, []byte("\x89PNG")) {
+		t.Errorf("Expected a PNG image on standard input")
+	}
+
+	if result.Text != "Hello World\nagain\n\nSigned" {
+		t.Errorf("Recognize() text = %q", result.Text)
+	}
+	if len(result.Words) != 4 {
+		t.Fatalf("Expected 4 words, got %d", len(result.Words))
+	}
+	if result.Words[0].Bounds != image.Rect(36, 92, 96, 116) || result.Words[0].Confidence != 0.965 {
+		t.Errorf("Unexpected first word %+v", result.Words[0])
+	}
+	if result.Confidence < 0.88 || result.Confidence > 0.89 {
+		t.Errorf("Recognize() confidence = %v", result.Confidence)
+	}
+
+	// hOCR output, with tesseract's default page segmentation
+	engine = extractor.NewTesseractOCREngine(script)
+	engine.Format = extractor.TesseractHOCR
+	result, err = engine.Recognize(img, extractor.OCROptions{})
+	if err != nil {
+		t.Fatalf("Recognize() with hOCR error = %v", err)
+	}
+	args, _ = os.ReadFile(script + ".args")
+	if got := strings.TrimSpace(string(args)); got != "stdin stdout -l eng hocr" {
+		t.Errorf("tesseract arguments = %q", got)
+	}
+	if result.Text != "Hello World\nR&D" {
+		t.Errorf("Recognize() with hOCR text = %q", result.Text)
+	}
+	if len(result.Words) != 3 || result.Words[1].Bounds != image.Rect(106, 92, 166, 116) || result.Words[1].Confidence != 0.91 {
+		t.Errorf("Unexpected hOCR words %+v", result.Words)
+	}
+
+	// Through the image extractor
+	imageExtractor := extractor.NewImageExtractor()
+	imageExtractor.OCR = extractor.NewTesseractOCREngine(script)
+	extracted, err := imageExtractor.ExtractFromFile(filepath.Join("testdata", "sample.png"), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ImageExtractor.ExtractFromFile() error = %v", err)
+	}
+	if extracted.Text != "Hello World\nagain\n\nSigned" || extracted.Metadata["ocr_language"] != "eng" {
+		t.Errorf("Unexpected extraction result %q, %v", extracted.Text, extracted.Metadata)
+	}
+}
+
+func TestTesseractOCREngineErrors(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+
+	failing := writeScript(t, "tesseract", "#!/bin/sh\necho 'Failed loading language klingon' >&2\nexit 1\n")
+	_, err := extractor.NewTesseractOCREngine(failing).Recognize(img, extractor.OCROptions{Language: "klingon"})
+	if err == nil || !strings.Contains(err.Error(), "Failed loading language klingon") {
+		t.Errorf("Expected the tesseract error message, got %v", err)
+	}
+
+	// The sleep keeps the output open after the script itself is killed
+	slow := writeScript(t, "tesseract", "#!/bin/sh\nsleep 10\n")
+	start := time.Now()
+	_, err = extractor.NewTesseractOCREngine(slow).Recognize(img, extractor.OCROptions{Timeout: 100 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Recognize() returned after %v", elapsed)
+	}
+
+	garbled := writeScript(t, "tesseract", "#!/bin/sh\necho 'not tsv'\n")
+	if _, err := extractor.NewTesseractOCREngine(garbled).Recognize(img, extractor.OCROptions{}); err == nil {
+		t.Error("Expected an error for output without TSV columns")
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := extractor.FindTesseract(); !errors.Is(err, extractor.ErrTesseractNotFound) {
+		t.Errorf("Expected ErrTesseractNotFound, got %v", err)
+	}
+}
+
+func TestImageExtractorDefaultOCR(t *testing.T) {
+	script := writeScript(t, "tesseract", fakeTesseractScript)
+
+	// The tesseract binary on the PATH is preferred
+	t.Setenv("PATH", filepath.Dir(script))
+	if engine, ok := extractor.NewImageExtractor().OCR.(*extractor.TesseractOCREngine); !ok || engine.Path != script {
+		t.Errorf("Expected the tesseract engine at %s, got %#v", script, extractor.NewImageExtractor().OCR)
+	}
+	created, err := extractor.CreateExtractorFromPath(filepath.Join("testdata", "sample.png"))
+	if err != nil {
+		t.Fatalf("CreateExtractorFromPath failed: %v", err)
+	}
+	imageExtractor, ok := created.(*extractor.ImageExtractor)
+	if !ok {
+		t.Fatalf("Expected *extractor.ImageExtractor, got %T", created)
+	}
+	if _, ok := imageExtractor.OCR.(*extractor.TesseractOCREngine); !ok {
+		t.Errorf("Expected the factory to use the tesseract engine, got %T", imageExtractor.OCR)
+	}
+	result, err := imageExtractor.ExtractFromFile(filepath.Join("testdata", "sample.png"), extractor.DefaultExtractOptions())
+	if err != nil {
+		t.Fatalf("ImageExtractor.ExtractFromFile() error = %v", err)
+	}
+	if result.Text != "Hello World\nagain\n\nSigned" {
+		t.Errorf("Expected the tesseract text, got %q", result.Text)
+	}
+
+	// Without tesseract the built-in engine is used
+	t.Setenv("PATH", t.TempDir())
+	if _, ok := extractor.NewImageExtractor().OCR.(*extractor.BuiltinOCREngine); !ok {
+		t.Errorf("Expected the built-in engine, got %T", extractor.NewImageExtractor().OCR)
+	}
+}